@@ -0,0 +1,29 @@
+// Package buildinfo reports which toolchain built the running module and which optional
+// features it compiled in. Both the native and the TinyGo mains import it, so their
+// version and capability maps come from one implementation and only the build tag
+// decides the answer.
+package buildinfo
+
+import "runtime"
+
+// Version returns the compiler, Go version and buildTime of the running module.
+// buildTime is whatever the caller's main had stamped via -ldflags "-X main.buildTime=...".
+func Version(buildTime string) map[string]any {
+    return map[string]any{
+        "compiler":  Compiler,
+        "goVersion": runtime.Version(),
+        "buildTime": buildTime,
+    }
+}
+
+// Capabilities returns the feature flags JS can check before calling an optional
+// export. parallel is passed in because only the caller knows whether it has a worker
+// pool; the rest follow the build-tagged constants.
+func Capabilities(parallel bool) map[string]any {
+    return map[string]any{
+        "gzip":      Gzip,
+        "sha256":    SHA256,
+        "normalize": Normalize,
+        "parallel":  parallel,
+    }
+}
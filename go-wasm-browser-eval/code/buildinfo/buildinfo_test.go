@@ -0,0 +1,27 @@
+package buildinfo
+
+import (
+    "reflect"
+    "runtime"
+    "testing"
+)
+
+func TestVersion(t *testing.T) {
+    got := Version("2026-10-14T00:00:00Z")
+    if got["compiler"] != "go" {
+        t.Errorf("compiler = %v; want go for the standard toolchain", got["compiler"])
+    }
+    if got["goVersion"] != runtime.Version() || got["buildTime"] != "2026-10-14T00:00:00Z" {
+        t.Errorf("Version = %v", got)
+    }
+}
+
+func TestCapabilities(t *testing.T) {
+    want := map[string]any{"gzip": true, "sha256": true, "normalize": true, "parallel": false}
+    if got := Capabilities(false); !reflect.DeepEqual(got, want) {
+        t.Errorf("Capabilities(false) = %v; want %v", got, want)
+    }
+    if got := Capabilities(true); got["parallel"] != true {
+        t.Errorf("Capabilities(true) parallel = %v; want true", got["parallel"])
+    }
+}
@@ -0,0 +1,6 @@
+//go:build !tinygo
+
+package buildinfo
+
+// Compiler identifies the standard Go toolchain.
+const Compiler = "go"
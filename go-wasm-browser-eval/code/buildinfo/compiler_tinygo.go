@@ -0,0 +1,6 @@
+//go:build tinygo
+
+package buildinfo
+
+// Compiler identifies TinyGo, which sets the tinygo build tag automatically.
+const Compiler = "tinygo"
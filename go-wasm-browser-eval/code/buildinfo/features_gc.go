@@ -0,0 +1,11 @@
+//go:build !tinygo
+
+package buildinfo
+
+// Optional features the standard toolchain compiles in: compress/gzip and
+// golang.org/x/text normalization both build and behave as on the host.
+const (
+    Gzip      = true
+    SHA256    = true
+    Normalize = true
+)
@@ -0,0 +1,11 @@
+//go:build tinygo
+
+package buildinfo
+
+// Optional features under TinyGo. crypto/sha256 is on its supported-package list, but
+// its compress/gzip and golang.org/x/text support are too limited to advertise.
+const (
+    Gzip      = false
+    SHA256    = true
+    Normalize = false
+)
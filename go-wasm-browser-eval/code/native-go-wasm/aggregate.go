@@ -0,0 +1,287 @@
+package main
+
+import (
+    "cmp"
+    "math"
+    "slices"
+    "strconv"
+    "strings"
+    "unicode"
+)
+
+// groupByCount counts rows by the value in keyCol. Rows too short to reach keyCol are
+// grouped under the empty-string key alongside genuinely empty cells.
+func groupByCount(rows [][]string, keyCol int) map[string]int {
+    counts := map[string]int{}
+    for _, row := range rows {
+        counts[cell(row, keyCol)]++
+    }
+    return counts
+}
+
+// GroupSum is the per-group result of groupBySum.
+type GroupSum struct {
+    Sum     float64
+    Avg     float64
+    Count   int
+    Skipped int
+}
+
+// toMap renders g for JavaScript.
+func (g GroupSum) toMap() map[string]any {
+    return map[string]any{
+        "sum":     g.Sum,
+        "avg":     g.Avg,
+        "count":   g.Count,
+        "skipped": g.Skipped,
+    }
+}
+
+// groupBySum sums valueCol per distinct keyCol value. Cells that do not parse as finite
+// numbers (including blanks, NaN and Inf) are left out of the sum and tallied in the
+// group's Skipped count.
+func groupBySum(rows [][]string, keyCol, valueCol int) map[string]GroupSum {
+    groups := map[string]GroupSum{}
+    for _, row := range rows {
+        key := cell(row, keyCol)
+        g := groups[key]
+        if x, err := strconv.ParseFloat(cell(row, valueCol), 64); err == nil && !math.IsNaN(x) && !math.IsInf(x, 0) {
+            g.Sum += x
+            g.Count++
+        } else {
+            g.Skipped++
+        }
+        groups[key] = g
+    }
+    for key, g := range groups {
+        if g.Count > 0 {
+            g.Avg = g.Sum / float64(g.Count)
+            groups[key] = g
+        }
+    }
+    return groups
+}
+
+// groupByShare builds on groupBySum: "groups" maps each keyCol value to its {sum, share},
+// where share is the group's fraction of the grand total "total", so shares add up to 1.
+// When the total is zero every share is 0. "skipped" counts the rows whose valueCol cell
+// was not a number.
+func groupByShare(rows [][]string, keyCol, valueCol int) map[string]any {
+    sums := groupBySum(rows, keyCol, valueCol)
+    total, skipped := 0.0, 0
+    for _, g := range sums {
+        total += g.Sum
+        skipped += g.Skipped
+    }
+    groups := make(map[string]any, len(sums))
+    for key, g := range sums {
+        share := 0.0
+        if total != 0 {
+            share = g.Sum / total
+        }
+        groups[key] = map[string]any{"sum": g.Sum, "share": share}
+    }
+    return map[string]any{"groups": groups, "total": total, "skipped": skipped}
+}
+
+// distinctValues returns the sorted distinct values of col across rows. A positive limit
+// keeps only the first limit values in sort order and reports truncated when more existed.
+func distinctValues(rows [][]string, col, limit int) (values []string, truncated bool) {
+    seen := map[string]bool{}
+    values = []string{}
+    for _, row := range rows {
+        v := cell(row, col)
+        if !seen[v] {
+            seen[v] = true
+            values = append(values, v)
+        }
+    }
+    slices.Sort(values)
+    if limit > 0 && len(values) > limit {
+        return values[:limit], true
+    }
+    return values, false
+}
+
+// termFrequency counts, for each term, how many cells of col contain it, ignoring case.
+// Terms are matched independently, so overlapping terms such as "err" and "error" both
+// count a cell containing "error". Every term appears in the result, with zero when
+// nothing matched.
+func termFrequency(rows [][]string, col int, terms []string) map[string]int {
+    counts := make(map[string]int, len(terms))
+    folded := make([]string, len(terms))
+    for i, term := range terms {
+        counts[term] = 0
+        folded[i] = strings.ToLower(term)
+    }
+    for _, row := range rows {
+        value := strings.ToLower(cell(row, col))
+        for i, term := range folded {
+            if strings.Contains(value, term) {
+                counts[terms[i]]++
+            }
+        }
+    }
+    return counts
+}
+
+// columnMatchCount counts the rows where the colA and colB cells are equal, exactly or
+// (with ignoreCase) under Unicode case folding, and the rows where they differ. Cells
+// missing from short rows compare as empty.
+func columnMatchCount(rows [][]string, colA, colB int, ignoreCase bool) (matches, mismatches int) {
+    for _, row := range rows {
+        a, b := cell(row, colA), cell(row, colB)
+        if a == b || ignoreCase && strings.EqualFold(a, b) {
+            matches++
+        } else {
+            mismatches++
+        }
+    }
+    return matches, mismatches
+}
+
+// WordCount is one entry of wordCounts.
+type WordCount struct {
+    Word  string
+    Count int
+}
+
+// toMap renders w for JavaScript.
+func (w WordCount) toMap() map[string]any {
+    return map[string]any{"word": w.Word, "count": w.Count}
+}
+
+// isWordBreak reports whether r separates words for wordCounts.
+func isWordBreak(r rune) bool {
+    return unicode.IsSpace(r) || unicode.IsPunct(r)
+}
+
+// wordCounts lowercases the cells of col, splits them on Unicode whitespace and
+// punctuation and returns the topN most frequent words, most frequent first with ties in
+// alphabetical order. Words in stopwords (compared case-insensitively) are left out, and a
+// non-positive topN returns every word.
+func wordCounts(rows [][]string, col, topN int, stopwords []string) []WordCount {
+    skip := make(map[string]bool, len(stopwords))
+    for _, w := range stopwords {
+        skip[strings.ToLower(w)] = true
+    }
+    counts := map[string]int{}
+    for _, row := range rows {
+        for _, word := range strings.FieldsFunc(strings.ToLower(cell(row, col)), isWordBreak) {
+            if !skip[word] {
+                counts[word]++
+            }
+        }
+    }
+    ranked := rankByCount(counts, topN)
+    words := make([]WordCount, len(ranked))
+    for i, word := range ranked {
+        words[i] = WordCount{Word: word, Count: counts[word]}
+    }
+    return words
+}
+
+// rankByCount returns the keys of counts ordered by count descending, then by key, keeping
+// the first limit when limit is positive.
+func rankByCount(counts map[string]int, limit int) []string {
+    keys := make([]string, 0, len(counts))
+    for key := range counts {
+        keys = append(keys, key)
+    }
+    slices.SortFunc(keys, func(a, b string) int {
+        return cmp.Or(cmp.Compare(counts[b], counts[a]), strings.Compare(a, b))
+    })
+    if limit > 0 && len(keys) > limit {
+        keys = keys[:limit]
+    }
+    return keys
+}
+
+// ValueCount is one entry of topValues.
+type ValueCount struct {
+    Value string
+    Count int
+}
+
+// toMap renders v for JavaScript.
+func (v ValueCount) toMap() map[string]any {
+    return map[string]any{"value": v.Value, "count": v.Count}
+}
+
+// topValues returns the k most common values of col, most common first with ties in
+// ascending value order. Empty cells count as a value like any other; a k at or above
+// the number of distinct values returns them all.
+func topValues(rows [][]string, col, k int) ([]ValueCount, error) {
+    if k < 1 {
+        return nil, badArgument("k must be positive, got %d", k)
+    }
+    counts := groupByCount(rows, col)
+    ranked := rankByCount(counts, k)
+    values := make([]ValueCount, len(ranked))
+    for i, value := range ranked {
+        values[i] = ValueCount{Value: value, Count: counts[value]}
+    }
+    return values, nil
+}
+
+// columnMode returns the most frequent non-empty value of col and how often it occurs.
+// Ties go to the value seen first. A column with no non-empty cells is an error.
+func columnMode(rows [][]string, col int) (string, int, error) {
+    counts := make(map[string]int)
+    var order []string
+    for _, row := range rows {
+        value := cell(row, col)
+        if value == "" {
+            continue
+        }
+        if counts[value] == 0 {
+            order = append(order, value)
+        }
+        counts[value]++
+    }
+    mode, best := "", 0
+    for _, value := range order {
+        if counts[value] > best {
+            mode, best = value, counts[value]
+        }
+    }
+    if best == 0 {
+        return "", 0, badArgument("column %d has no values", col)
+    }
+    return mode, best, nil
+}
+
+// weightedMean returns sum(value*weight)/sum(weight) over the rows where both the
+// valueCol and weightCol cells are finite numbers; other rows are left out. Cells are
+// parsed as they are, or trimmed first when opts.TrimSpace is set, like columnStatsOf.
+// No usable rows, or weights that sum to zero, is an error.
+func weightedMean(rows [][]string, valueCol, weightCol int, opts csvOptions) (float64, error) {
+    parse := func(value string) (float64, error) {
+        if opts.TrimSpace {
+            value = strings.TrimSpace(value)
+        }
+        return strconv.ParseFloat(value, 64)
+    }
+    var total, weights float64
+    used := 0
+    for _, row := range rows {
+        x, err := parse(cell(row, valueCol))
+        if err != nil || math.IsNaN(x) || math.IsInf(x, 0) {
+            continue
+        }
+        w, err := parse(cell(row, weightCol))
+        if err != nil || math.IsNaN(w) || math.IsInf(w, 0) {
+            continue
+        }
+        total += x * w
+        weights += w
+        used++
+    }
+    if used == 0 {
+        return 0, badArgument("columns %d and %d have no rows with a numeric value and weight", valueCol, weightCol)
+    }
+    if weights == 0 {
+        return 0, badArgument("weights in column %d sum to zero", weightCol)
+    }
+    return total / weights, nil
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestGroupByCount(t *testing.T) {
+    _, rows, err := splitHeader("name,team\nann,red\nbob,\ncy,red\ndee\n", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    got := groupByCount(rows, 1)
+    if want := map[string]int{"red": 2, "": 2}; !reflect.DeepEqual(got, want) {
+        t.Errorf("groupByCount = %v; want %v", got, want)
+    }
+}
+
+func TestGroupBySum(t *testing.T) {
+    _, rows, err := splitHeader("team,n\nred,1\nred,\nred,2.5\nblue,x\nblue,NaN\nblue,Inf\nblue,-4\n", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    got := groupBySum(rows, 0, 1)
+    want := map[string]GroupSum{
+        "red":  {Sum: 3.5, Avg: 1.75, Count: 2, Skipped: 1},
+        "blue": {Sum: -4, Avg: -4, Count: 1, Skipped: 3},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("groupBySum = %+v; want %+v", got, want)
+    }
+}
+
+func TestGroupBySumAllSkipped(t *testing.T) {
+    got := groupBySum([][]string{{"a", ""}, {"a", "n/a"}}, 0, 1)
+    if want := (GroupSum{Skipped: 2}); got["a"] != want {
+        t.Errorf("groupBySum = %+v; want %+v with a zero average", got["a"], want)
+    }
+}
+
+func TestDistinctValues(t *testing.T) {
+    rows := [][]string{{"pear"}, {"Apple"}, {"pear"}, {""}, {"banana"}, {}}
+    tests := []struct {
+        name      string
+        limit     int
+        want      []string
+        truncated bool
+    }{
+        {"sorted bytewise", 0, []string{"", "Apple", "banana", "pear"}, false},
+        {"cap keeps the first in sort order", 2, []string{"", "Apple"}, true},
+        {"cap equal to the count", 4, []string{"", "Apple", "banana", "pear"}, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, truncated := distinctValues(rows, 0, tt.limit)
+            if !reflect.DeepEqual(got, tt.want) || truncated != tt.truncated {
+                t.Errorf("distinctValues = %q, %v; want %q, %v", got, truncated, tt.want, tt.truncated)
+            }
+        })
+    }
+}
+
+func TestTermFrequency(t *testing.T) {
+    _, rows, err := splitHeader("id,msg\n1,ERROR: disk\n2,warning: error rate\n3,ok\n4\n", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    // "warn" and "warning" overlap, and a cell counts once per term however often the
+    // term appears in it.
+    got := termFrequency(rows, 1, []string{"Error", "warn", "warning", "msg", "fatal"})
+    want := map[string]int{"Error": 2, "warn": 1, "warning": 1, "msg": 0, "fatal": 0}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("termFrequency = %v; want %v", got, want)
+    }
+}
+
+func TestWordCounts(t *testing.T) {
+    rows := [][]string{
+        {"1", "The cat, the DOG!"},
+        {"2", "dog\tbird; ant"},
+        {"3", "Naïve café—cat"},
+        {"4"},
+    }
+    tests := []struct {
+        name      string
+        topN      int
+        stopwords []string
+        want      []WordCount
+    }{
+        // cat, dog and the tie on two each and come out alphabetically, as do the words seen once.
+        {"ties alphabetical", 0, nil, []WordCount{{"cat", 2}, {"dog", 2}, {"the", 2}, {"ant", 1}, {"bird", 1}, {"café", 1}, {"naïve", 1}}},
+        {"top n", 2, nil, []WordCount{{"cat", 2}, {"dog", 2}}},
+        {"stopwords", 3, []string{"THE", "cat"}, []WordCount{{"dog", 2}, {"ant", 1}, {"bird", 1}}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := wordCounts(rows, 1, tt.topN, tt.stopwords)
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("wordCounts = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestColumnMatchCount(t *testing.T) {
+    rows := [][]string{
+        {"1", "Oslo", "Oslo"},
+        {"2", "oslo", "OSLO"},
+        {"3", "Straße", "STRASSE"},
+        {"4", "Rome", "Paris"},
+        {"5", "", ""},
+        {"6", "Σ"},
+        {"7", "ǅ", "ǆ"},
+    }
+    tests := []struct {
+        name                string
+        ignoreCase          bool
+        matches, mismatches int
+    }{
+        // The blank pair matches; the short row compares "Σ" with "".
+        {"exact", false, 2, 5},
+        // Simple case folding pairs ǅ with ǆ but not ß with SS.
+        {"ignore case", true, 4, 3},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            matches, mismatches := columnMatchCount(rows, 1, 2, tt.ignoreCase)
+            if matches != tt.matches || mismatches != tt.mismatches {
+                t.Errorf("columnMatchCount = %d, %d; want %d, %d", matches, mismatches, tt.matches, tt.mismatches)
+            }
+        })
+    }
+}
+
+func TestTopValues(t *testing.T) {
+    rows := [][]string{{"b"}, {"a"}, {"c"}, {"b"}, {"a"}, {""}, {"d"}, {"b"}, {}}
+    tests := []struct {
+        name string
+        k    int
+        want []ValueCount
+    }{
+        {"ties by value", 3, []ValueCount{{"b", 3}, {"", 2}, {"a", 2}}},
+        // The short row counts as a blank, like the empty cell.
+        {"k above distinct count", 10, []ValueCount{{"b", 3}, {"", 2}, {"a", 2}, {"c", 1}, {"d", 1}}},
+        {"k of one", 1, []ValueCount{{"b", 3}}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := topValues(rows, 0, tt.k)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("topValues = %v; want %v", got, tt.want)
+            }
+        })
+    }
+    if _, err := topValues(rows, 0, 0); err == nil {
+        t.Error("k = 0 accepted")
+    }
+}
+
+func TestColumnMode(t *testing.T) {
+    tests := []struct {
+        name   string
+        values []string
+        mode   string
+        count  int
+    }{
+        {"clear mode", []string{"a", "b", "", "b", "c", "b"}, "b", 3},
+        {"tie goes to first seen", []string{"y", "x", "x", "y"}, "y", 2},
+        {"empties do not count", []string{"", "", "z"}, "z", 1},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rows := make([][]string, len(tt.values))
+            for i, v := range tt.values {
+                rows[i] = []string{v}
+            }
+            mode, count, err := columnMode(rows, 0)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if mode != tt.mode || count != tt.count {
+                t.Errorf("columnMode = %q, %d; want %q, %d", mode, count, tt.mode, tt.count)
+            }
+        })
+    }
+    _, _, err := columnMode([][]string{{""}, {}}, 0)
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != "column 0 has no values" {
+        t.Errorf("empty column: got %v", m)
+    }
+}
+
+func TestGroupByShare(t *testing.T) {
+    rows := [][]string{{"a", "1"}, {"b", "2.5"}, {"a", "x"}, {"c", "0.5"}, {"a", "1"}}
+    got := groupByShare(rows, 0, 1)
+    if got["total"] != 5.0 || got["skipped"] != 1 {
+        t.Errorf("total, skipped = %v, %v; want 5, 1", got["total"], got["skipped"])
+    }
+    groups := got["groups"].(map[string]any)
+    sum := 0.0
+    for key, want := range map[string]float64{"a": 0.4, "b": 0.5, "c": 0.1} {
+        share := groups[key].(map[string]any)["share"].(float64)
+        if !approx(share, want) {
+            t.Errorf("share of %s = %v; want %v", key, share, want)
+        }
+        sum += share
+    }
+    if !approx(sum, 1) {
+        t.Errorf("shares add up to %v; want 1", sum)
+    }
+    // Sums that cancel out leave a zero total, and every share is zero rather than NaN.
+    got = groupByShare([][]string{{"a", "1"}, {"b", "-1"}}, 0, 1)
+    want := map[string]any{
+        "groups":  map[string]any{"a": map[string]any{"sum": 1.0, "share": 0.0}, "b": map[string]any{"sum": -1.0, "share": 0.0}},
+        "total":   0.0,
+        "skipped": 0,
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("zero total: groupByShare = %v; want %v", got, want)
+    }
+}
+
+func TestWeightedMean(t *testing.T) {
+    // The rows with x and a blank weight are always left out. " 3 " only parses with
+    // trimSpace: without it the mean is (10*1 + 40*1) / 2 = 25, with it
+    // (10*1 + 20*3 + 40*1) / 5 = 22.
+    rows := [][]string{{"10", "1"}, {"20", " 3 "}, {"x", "5"}, {"30", ""}, {"40", "1"}}
+    for _, tt := range []struct {
+        trim bool
+        want float64
+    }{
+        {false, 25},
+        {true, 22},
+    } {
+        got, err := weightedMean(rows, 0, 1, csvOptions{TrimSpace: tt.trim})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if !approx(got, tt.want) {
+            t.Errorf("trimSpace %v: weightedMean = %v; want %v", tt.trim, got, tt.want)
+        }
+    }
+    tests := []struct {
+        name string
+        rows [][]string
+        msg  string
+    }{
+        {"zero weight", [][]string{{"10", "1"}, {"20", "-1"}}, "weights in column 1 sum to zero"},
+        {"no usable rows", [][]string{{"x", "1"}, {"1", "NaN"}}, "columns 0 and 1 have no rows with a numeric value and weight"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := weightedMean(tt.rows, 0, 1, csvOptions{})
+            if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.msg {
+                t.Errorf("got %v; want bad_argument %q", m, tt.msg)
+            }
+        })
+    }
+}
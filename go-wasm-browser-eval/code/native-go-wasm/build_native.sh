@@ -6,7 +6,8 @@ TASK_DIR=$(cd -- "$(dirname -- "$0")"/.. && pwd)
 OUT_DIR="$TASK_DIR/native-go-wasm/dist"
 mkdir -p "$OUT_DIR"
 
-GOOS=js GOARCH=wasm go build -o "$OUT_DIR/native-go.wasm" "$TASK_DIR/native-go-wasm"
+BUILD_TIME=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+GOOS=js GOARCH=wasm go build -ldflags "-X main.buildTime=$BUILD_TIME" -o "$OUT_DIR/native-go.wasm" "$TASK_DIR/native-go-wasm"
 
 # Locate wasm_exec.js (its location differs between Go distributions).
 WASM_EXEC_PATH="$(go env GOROOT)/misc/wasm/wasm_exec.js"
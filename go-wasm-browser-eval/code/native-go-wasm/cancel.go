@@ -0,0 +1,45 @@
+package main
+
+import "errors"
+
+// cancelCheckEvery is how many records pass between cancellation checks, so a long
+// operation pays one map lookup per thousand records rather than per record.
+const cancelCheckEvery = 1000
+
+// errCancelled is returned by operations whose cancel token was flagged.
+var errCancelled = &codedError{code: codeCancelled, err: errors.New("cancelled")}
+
+var (
+    // tokens maps live cancel tokens to whether wasmCancel has flagged them.
+    tokens = map[int]bool{}
+    // nextToken is never reused, so a stale token cannot cancel a newer operation.
+    nextToken = 1
+)
+
+// newToken registers an unflagged cancel token and returns its id.
+func newToken() int {
+    id := nextToken
+    nextToken++
+    tokens[id] = false
+    return id
+}
+
+// lookupToken returns a bad_argument error for ids that were never issued or have
+// already been freed.
+func lookupToken(id int) error {
+    if _, ok := tokens[id]; !ok {
+        return badArgument("unknown or freed cancel token %d", id)
+    }
+    return nil
+}
+
+// isCancelled reports whether opts carries a cancel token that has been flagged.
+func (o csvOptions) isCancelled() bool {
+    return o.CancelToken != 0 && tokens[o.CancelToken]
+}
+
+// cancelled is isCancelled checked only every cancelCheckEvery records, for per-record
+// loops that have processed records so far.
+func (o csvOptions) cancelled(processed int) bool {
+    return o.CancelToken != 0 && processed%cancelCheckEvery == 0 && tokens[o.CancelToken]
+}
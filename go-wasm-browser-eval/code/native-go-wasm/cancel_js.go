@@ -0,0 +1,41 @@
+//go:build js && wasm
+
+package main
+
+import "syscall/js"
+
+// wrapNewToken returns a fresh cancel token id for the cancelToken option: wasmNewToken().
+func wrapNewToken(this js.Value, args []js.Value) any {
+    return newToken()
+}
+
+// tokenArg reads the cancel token id at args[0] and checks that it is live.
+func tokenArg(args []js.Value) (int, error) {
+    if len(args) < 1 || args[0].Type() != js.TypeNumber {
+        return 0, badArgument("expected a cancel token")
+    }
+    id := args[0].Int()
+    return id, lookupToken(id)
+}
+
+// wrapCancel flags a cancel token so operations using it stop at their next check:
+// wasmCancel(id). Cancelling twice is harmless.
+func wrapCancel(this js.Value, args []js.Value) any {
+    id, err := tokenArg(args)
+    if err != nil {
+        return errorMap(err)
+    }
+    tokens[id] = true
+    return nil
+}
+
+// wrapFreeToken releases a cancel token once its operation is over: wasmFreeToken(id).
+// Like wasmTableFree, freeing twice is a bad_argument error.
+func wrapFreeToken(this js.Value, args []js.Value) any {
+    id, err := tokenArg(args)
+    if err != nil {
+        return errorMap(err)
+    }
+    delete(tokens, id)
+    return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+    "io"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// testToken registers a cancel token that is freed when the test ends.
+func testToken(t *testing.T) int {
+    id := newToken()
+    t.Cleanup(func() { delete(tokens, id) })
+    return id
+}
+
+// cancellingReader flags token once the first read has been served, so the operation
+// reading from it is cancelled part way through rather than before it starts.
+type cancellingReader struct {
+    r     io.Reader
+    token int
+    read  int
+}
+
+func (c *cancellingReader) Read(p []byte) (int, error) {
+    if c.read > 0 {
+        tokens[c.token] = true
+    }
+    n, err := c.r.Read(p)
+    c.read += n
+    return n, err
+}
+
+// manyRows is a one-column file with n data rows, several cancellation checks long.
+func manyRows(n int) string {
+    return "n\n" + strings.Repeat("1\n", n)
+}
+
+func TestSummarizeStreamCancelled(t *testing.T) {
+    text := manyRows(20 * cancelCheckEvery)
+    id := testToken(t)
+    r := &cancellingReader{r: strings.NewReader(text), token: id}
+    _, err := summarizeStream(r, csvOptions{HasHeader: true, CancelToken: id})
+    if m := errorMap(err); m["code"] != codeCancelled || m["error"] != "cancelled" {
+        t.Fatalf("got %v; want the cancelled error", m)
+    }
+    if r.read >= len(text) {
+        t.Errorf("read all %d bytes before stopping; want a mid-run stop", r.read)
+    }
+}
+
+func TestProfileCancelled(t *testing.T) {
+    text := manyRows(3 * cancelCheckEvery)
+    id := testToken(t)
+    tokens[id] = true
+    opts := csvOptions{HasHeader: true, CancelToken: id}
+    _, err := profile(text, opts)
+    if m := errorMap(err); m["code"] != codeCancelled {
+        t.Errorf("profile: got %v; want the cancelled error", m)
+    }
+    _, err = inferProfile(text, opts)
+    if m := errorMap(err); m["code"] != codeCancelled {
+        t.Errorf("inferProfile: got %v; want the cancelled error", m)
+    }
+    // A short file finishes before the first check, so there is nothing to cancel.
+    if _, err := profile(manyRows(10), opts); err != nil {
+        t.Errorf("short profile: %v", err)
+    }
+}
+
+func TestPushStreamCancelled(t *testing.T) {
+    id := testToken(t)
+    s := &pushStream{opts: csvOptions{Delimiter: ',', HasHeader: true, CancelToken: id}}
+    if err := s.push([]byte(manyRows(2 * cancelCheckEvery))); err != nil {
+        t.Fatal(err)
+    }
+    tokens[id] = true
+    err := s.push([]byte(strings.Repeat("1\n", 2*cancelCheckEvery)))
+    if m := errorMap(err); m["code"] != codeCancelled {
+        t.Errorf("got %v; want the cancelled error", m)
+    }
+}
+
+func TestUnflaggedTokenMatchesNone(t *testing.T) {
+    text := manyRows(3 * cancelCheckEvery)
+    want, err := summaryFromCSV(text, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    live := testToken(t)
+    freed := newToken()
+    delete(tokens, freed)
+    // A live token nobody flagged, a freed one and one never issued all leave the
+    // summary alone.
+    for _, id := range []int{live, freed, nextToken + 100} {
+        got, err := summaryFromCSV(text, csvOptions{HasHeader: true, CancelToken: id})
+        if err != nil {
+            t.Fatalf("token %d: %v", id, err)
+        }
+        if !reflect.DeepEqual(got, want) {
+            t.Errorf("token %d changed the summary", id)
+        }
+    }
+}
+
+func TestLookupToken(t *testing.T) {
+    id := testToken(t)
+    if err := lookupToken(id); err != nil {
+        t.Errorf("live token: %v", err)
+    }
+    delete(tokens, id)
+    for _, bad := range []int{id, 0, -1, nextToken} {
+        if m := errorMap(lookupToken(bad)); m["code"] != codeBadArgument {
+            t.Errorf("lookupToken(%d) = %v; want bad_argument", bad, m)
+        }
+    }
+    if next := newToken(); next == id {
+        t.Errorf("freed token %d was reissued", id)
+    } else {
+        delete(tokens, next)
+    }
+}
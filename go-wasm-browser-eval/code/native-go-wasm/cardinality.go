@@ -0,0 +1,91 @@
+package main
+
+// defaultCardinalityCap bounds how many distinct values are tracked per column.
+const defaultCardinalityCap = 10000
+
+// cardinalityTracker counts distinct values in one column, giving up once the cap is
+// exceeded so a high-cardinality column cannot grow without bound.
+type cardinalityTracker struct {
+    seen   map[string]struct{}
+    capped bool
+}
+
+// observe adds value to the column's set unless the cap has already been exceeded.
+func (t *cardinalityTracker) observe(value string, limit int) {
+    if t.capped {
+        return
+    }
+    if t.seen == nil {
+        t.seen = map[string]struct{}{}
+    }
+    if _, ok := t.seen[value]; ok {
+        return
+    }
+    if len(t.seen) >= limit {
+        t.capped = true
+        t.seen = nil
+        return
+    }
+    t.seen[value] = struct{}{}
+}
+
+// result returns the distinct count, or -1 when the column went over the cap.
+func (t *cardinalityTracker) result() int {
+    if t.capped {
+        return -1
+    }
+    return len(t.seen)
+}
+
+// columnCardinality returns the number of distinct values per column of rows, with -1
+// for columns holding more than limit distinct values. A non-positive limit uses
+// defaultCardinalityCap.
+func columnCardinality(rows [][]string, limit int) []int {
+    if limit <= 0 {
+        limit = defaultCardinalityCap
+    }
+    var trackers []cardinalityTracker
+    for _, row := range rows {
+        for len(trackers) < len(row) {
+            trackers = append(trackers, cardinalityTracker{})
+        }
+        for i, value := range row {
+            trackers[i].observe(value, limit)
+        }
+    }
+    counts := make([]int, len(trackers))
+    for i := range trackers {
+        counts[i] = trackers[i].result()
+    }
+    return counts
+}
+
+// keyTracker checks whether one column could serve as a primary key: every cell
+// non-blank and no value repeated. The set is dropped at the first blank or repeat, so
+// only columns still in the running hold memory.
+type keyTracker struct {
+    seen   map[string]struct{}
+    failed bool
+}
+
+// observe adds one cell, failing the column on a blank or a value already seen.
+func (t *keyTracker) observe(value string) {
+    if t.failed {
+        return
+    }
+    if t.seen == nil {
+        t.seen = map[string]struct{}{}
+    }
+    if _, dup := t.seen[value]; dup || isBlank(value) {
+        t.failed = true
+        t.seen = nil
+        return
+    }
+    t.seen[value] = struct{}{}
+}
+
+// unique reports whether the column held a distinct non-blank value in each of rows
+// data rows; a row too short to reach the column leaves the count short.
+func (t *keyTracker) unique(rows int) bool {
+    return !t.failed && rows > 0 && len(t.seen) == rows
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+    "fmt"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// idsAndColors is a CSV with a high-cardinality id column and a three-value color column.
+func idsAndColors(rows int) string {
+    var b strings.Builder
+    b.WriteString("id,color\n")
+    colors := []string{"red", "green", "blue"}
+    for i := 0; i < rows; i++ {
+        fmt.Fprintf(&b, "%d,%s\n", i, colors[i%len(colors)])
+    }
+    return b.String()
+}
+
+func TestSummaryCardinality(t *testing.T) {
+    tests := []struct {
+        name string
+        cap  int
+        want []int
+    }{
+        {"default cap", 0, []int{500, 3}},
+        {"at the cap", 500, []int{500, 3}},
+        {"id column over the cap", 100, []int{-1, 3}},
+        {"both over the cap", 2, []int{-1, -1}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            summary, err := summaryFromCSV(idsAndColors(500), csvOptions{HasHeader: true, Cardinality: true, CardinalityCap: tt.cap})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got := summary["cardinality"]; !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("cardinality = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestColumnCardinality(t *testing.T) {
+    _, rows, err := splitHeader(idsAndColors(500), csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    tests := []struct {
+        name  string
+        limit int
+        want  []int
+    }{
+        {"default cap", 0, []int{500, 3}},
+        {"at the cap", 500, []int{500, 3}},
+        {"id column over the cap", 100, []int{-1, 3}},
+        {"both over the cap", 2, []int{-1, -1}},
+    }
+    for _, tt := range tests {
+        if got := columnCardinality(rows, tt.limit); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s: columnCardinality = %v; want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestCardinalityTrackerDropsSetOverCap(t *testing.T) {
+    var tracker cardinalityTracker
+    for _, v := range []string{"a", "b", "a", "c"} {
+        tracker.observe(v, 2)
+    }
+    if tracker.result() != -1 || tracker.seen != nil {
+        t.Errorf("result = %d, seen = %v; want -1 with the set released", tracker.result(), tracker.seen)
+    }
+}
+
+func TestSummaryCandidateKeys(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        opts csvOptions
+        want []int
+    }{
+        {"id qualifies, category does not", idsAndColors(50), csvOptions{HasHeader: true}, []int{0}},
+        // The blank email and the short row each rule their column out.
+        {"blanks and short rows", "id,email,code\n1,a@x,p\n2,,q\n3,c@x\n", csvOptions{HasHeader: true}, []int{0}},
+        {"every column unique", "a,b\n1,x\n2,y\n", csvOptions{HasHeader: true}, []int{0, 1}},
+        {"selected columns keep their index", idsAndColors(10), csvOptions{HasHeader: true, Columns: []int{1, 0}}, []int{0}},
+        {"no data rows", "id,color\n", csvOptions{HasHeader: true}, []int{}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            tt.opts.CandidateKeys = true
+            result, err := summaryFromCSV(tt.text, tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(result["candidateKeys"], tt.want) {
+                t.Errorf("candidateKeys = %v; want %v", result["candidateKeys"], tt.want)
+            }
+        })
+    }
+    result, err := summaryFromCSV(idsAndColors(5), csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, ok := result["candidateKeys"]; ok {
+        t.Error("candidateKeys reported without the option")
+    }
+}
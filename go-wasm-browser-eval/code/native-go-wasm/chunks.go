@@ -0,0 +1,54 @@
+package main
+
+import "strings"
+
+// defaultChunkRows is how many records chunkedOutput gathers per chunk when
+// csvOptions.ChunkRows is unset.
+const defaultChunkRows = 1000
+
+// chunkedOutput collects encoded records for the re-encoding helpers. Without a chunk
+// callback it keeps everything for one returned string; with one it hands the buffer
+// over every few records so only a chunk is held at a time.
+type chunkedOutput struct {
+    buf     strings.Builder
+    emit    func(chunk string)
+    every   int
+    pending int
+}
+
+// newChunkedOutput returns a chunkedOutput honouring opts.Chunk and opts.ChunkRows.
+func newChunkedOutput(opts csvOptions) *chunkedOutput {
+    every := opts.ChunkRows
+    if every <= 0 {
+        every = defaultChunkRows
+    }
+    return &chunkedOutput{emit: opts.Chunk, every: every}
+}
+
+// endRecord marks the end of one record written to buf, emitting a chunk when enough
+// have gathered.
+func (c *chunkedOutput) endRecord() {
+    c.pending++
+    if c.emit != nil && c.pending == c.every {
+        c.flush()
+    }
+}
+
+// flush emits whatever is buffered.
+func (c *chunkedOutput) flush() {
+    if c.buf.Len() > 0 {
+        c.emit(c.buf.String())
+        c.buf.Reset()
+    }
+    c.pending = 0
+}
+
+// finish emits the last partial chunk and returns "" when chunking, or the whole output
+// otherwise.
+func (c *chunkedOutput) finish() string {
+    if c.emit != nil {
+        c.flush()
+        return ""
+    }
+    return c.buf.String()
+}
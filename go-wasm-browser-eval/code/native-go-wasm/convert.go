@@ -0,0 +1,210 @@
+package main
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "math"
+    "slices"
+    "strconv"
+    "strings"
+)
+
+// uniqueHeaders returns headers with repeated names suffixed _2, _3, ... in order of
+// appearance, skipping any suffix that would collide with an existing name.
+func uniqueHeaders(headers []string) []string {
+    taken := make(map[string]bool, len(headers))
+    for _, h := range headers {
+        taken[h] = true
+    }
+    seen := make(map[string]int, len(headers))
+    out := make([]string, len(headers))
+    for i, h := range headers {
+        seen[h]++
+        if seen[h] == 1 {
+            out[i] = h
+            continue
+        }
+        name := h
+        for n := seen[h]; ; n++ {
+            name = h + "_" + strconv.Itoa(n)
+            if !taken[name] {
+                seen[h] = n
+                break
+            }
+        }
+        taken[name] = true
+        out[i] = name
+    }
+    return out
+}
+
+// csvToJSON converts csvText into one object per data row keyed by the header row.
+// Duplicate header names are made unique, short rows fill missing keys with empty
+// strings, and fields beyond the header width are dropped.
+func csvToJSON(csvText string) ([]map[string]string, error) {
+    rows, err := readAllRecords(csvText, csvOptions{})
+    if err != nil {
+        return nil, err
+    }
+    records := []map[string]string{}
+    if len(rows) == 0 {
+        return records, nil
+    }
+    headers := uniqueHeaders(rows[0])
+    for _, row := range rows[1:] {
+        record := make(map[string]string, len(headers))
+        for i, h := range headers {
+            if i < len(row) {
+                record[h] = row[i]
+            } else {
+                record[h] = ""
+            }
+        }
+        records = append(records, record)
+    }
+    return records, nil
+}
+
+// csvToColumns converts csvText into one array per header name holding that column's
+// values in row order, the columnar shape charting libraries take. Headers are made
+// unique as in csvToJSON, short rows contribute empty strings so every array has one
+// entry per data row, and fields beyond the header width are dropped.
+func csvToColumns(csvText string) (map[string][]string, error) {
+    rows, err := readAllRecords(csvText, csvOptions{})
+    if err != nil {
+        return nil, err
+    }
+    columns := map[string][]string{}
+    if len(rows) == 0 {
+        return columns, nil
+    }
+    headers := uniqueHeaders(rows[0])
+    for i, h := range headers {
+        values := make([]string, len(rows)-1)
+        for r, row := range rows[1:] {
+            values[r] = cell(row, i)
+        }
+        columns[h] = values
+    }
+    return columns, nil
+}
+
+// csvToTypedJSON is csvToJSON with values coerced by column: columns inferColumnTypes
+// reports as integer or float become numbers and boolean columns become booleans, with
+// empty cells in those columns as nil. Date, string and mixed columns keep their strings.
+func csvToTypedJSON(csvText string) ([]map[string]any, error) {
+    rows, err := readAllRecords(csvText, csvOptions{})
+    if err != nil {
+        return nil, err
+    }
+    records := []map[string]any{}
+    if len(rows) == 0 {
+        return records, nil
+    }
+    headers := uniqueHeaders(rows[0])
+    types := inferColumnTypes(rows[1:])
+    for _, row := range rows[1:] {
+        record := make(map[string]any, len(headers))
+        for i, h := range headers {
+            kind := typeString
+            if i < len(types) {
+                kind = types[i]
+            }
+            record[h] = coerceValue(cell(row, i), kind)
+        }
+        records = append(records, record)
+    }
+    return records, nil
+}
+
+// coerceValue converts one cell to the Go value matching its column type label.
+func coerceValue(value, kind string) any {
+    switch kind {
+    case typeInteger, typeFloat:
+        if x, err := strconv.ParseFloat(value, 64); err == nil {
+            return x
+        }
+        return nil
+    case typeBoolean:
+        if value == "" {
+            return nil
+        }
+        return strings.EqualFold(value, "true")
+    }
+    return value
+}
+
+// formatValue stringifies a decoded JSON value deterministically for CSV output.
+// Numbers avoid scientific notation below 1e21 (matching JS), booleans render as
+// true/false, null is empty, and nested arrays or objects are re-encoded as JSON.
+func formatValue(v any) (string, error) {
+    switch value := v.(type) {
+    case nil:
+        return "", nil
+    case string:
+        return value, nil
+    case bool:
+        return strconv.FormatBool(value), nil
+    case float64:
+        if math.Abs(value) < 1e21 {
+            return strconv.FormatFloat(value, 'f', -1, 64), nil
+        }
+        return strconv.FormatFloat(value, 'g', -1, 64), nil
+    case int:
+        return strconv.Itoa(value), nil
+    case json.Number:
+        return value.String(), nil
+    default:
+        encoded, err := json.Marshal(value)
+        if err != nil {
+            return "", fmt.Errorf("cannot encode value %v: %w", value, err)
+        }
+        return string(encoded), nil
+    }
+}
+
+// jsonToCSV writes data as CSV whose header is the sorted union of every object's keys.
+// Objects missing a key get an empty field; quoting follows RFC 4180 via csv.Writer.
+// With crlf set, records end in \r\n instead of \n.
+func jsonToCSV(data []map[string]any, crlf bool) (string, error) {
+    keySet := map[string]bool{}
+    for _, record := range data {
+        for k := range record {
+            keySet[k] = true
+        }
+    }
+    headers := make([]string, 0, len(keySet))
+    for k := range keySet {
+        headers = append(headers, k)
+    }
+    slices.Sort(headers)
+    if len(headers) == 0 {
+        return "", nil
+    }
+
+    var out strings.Builder
+    writer := csv.NewWriter(&out)
+    writer.UseCRLF = crlf
+    if err := writer.Write(headers); err != nil {
+        return "", err
+    }
+    row := make([]string, len(headers))
+    for _, record := range data {
+        for i, h := range headers {
+            field, err := formatValue(record[h])
+            if err != nil {
+                return "", err
+            }
+            row[i] = field
+        }
+        if err := writer.Write(row); err != nil {
+            return "", err
+        }
+    }
+    writer.Flush()
+    if err := writer.Error(); err != nil {
+        return "", err
+    }
+    return out.String(), nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestCSVToJSON(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        want []map[string]string
+    }{
+        {
+            "duplicate headers",
+            "id,name,name,name\n1,a,b,c\n",
+            []map[string]string{{"id": "1", "name": "a", "name_2": "b", "name_3": "c"}},
+        },
+        {
+            "short row",
+            "id,name,city\n1,a\n2,b,c\n",
+            []map[string]string{{"id": "1", "name": "a", "city": ""}, {"id": "2", "name": "b", "city": "c"}},
+        },
+        {"header only", "id,name\n", []map[string]string{}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := csvToJSON(tt.text)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("csvToJSON = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCSVToTypedJSON(t *testing.T) {
+    got, err := csvToTypedJSON("id,mixed,price,ok\n1,10,1.5,true\n2,x,,FALSE\n,3,2,\n")
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []map[string]any{
+        {"id": 1.0, "mixed": "10", "price": 1.5, "ok": true},
+        {"id": 2.0, "mixed": "x", "price": nil, "ok": false},
+        {"id": nil, "mixed": "3", "price": 2.0, "ok": nil},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("csvToTypedJSON = %v; want %v", got, want)
+    }
+}
+
+func TestCSVToColumns(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        want map[string][]string
+    }{
+        {
+            // Row 2 is short and row 3 too long; index r of every array is data row r.
+            "ragged rows",
+            "id,name,city\n1,ann,oslo\n2,bob\n3,cy,rome,extra\n",
+            map[string][]string{"id": {"1", "2", "3"}, "name": {"ann", "bob", "cy"}, "city": {"oslo", "", "rome"}},
+        },
+        {"duplicate headers", "a,a\n1,2\n", map[string][]string{"a": {"1"}, "a_2": {"2"}}},
+        {"header only", "a,b\n", map[string][]string{"a": {}, "b": {}}},
+        {"empty", "", map[string][]string{}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := csvToColumns(tt.text)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("csvToColumns = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestJSONToCSV(t *testing.T) {
+    tests := []struct {
+        name string
+        data []map[string]any
+        crlf bool
+        want string
+    }{
+        {
+            "differing key sets",
+            []map[string]any{{"b": "x", "a": 1.0}, {"c": true}},
+            false,
+            "a,b,c\n1,x,\n,,true\n",
+        },
+        {
+            "values needing quotes",
+            []map[string]any{{"note": `say "hi", then go`, "n": 1e20}},
+            false,
+            "n,note\n100000000000000000000,\"say \"\"hi\"\", then go\"\n",
+        },
+        {"crlf", []map[string]any{{"a": nil}}, true, "a\r\n\r\n"},
+        {"no keys", []map[string]any{{}}, false, ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := jsonToCSV(tt.data, tt.crlf)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("jsonToCSV = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestFormatValue(t *testing.T) {
+    tests := []struct {
+        value any
+        want  string
+    }{
+        {0.1, "0.1"},
+        {123456789.0, "123456789"},
+        {1e21, "1e+21"},
+        {false, "false"},
+        {nil, ""},
+        {[]any{1.0, "a"}, `[1,"a"]`},
+    }
+    for _, tt := range tests {
+        if got, err := formatValue(tt.value); err != nil || got != tt.want {
+            t.Errorf("formatValue(%v) = %q, %v; want %q", tt.value, got, err, tt.want)
+        }
+    }
+}
+
+func TestCSVToJSONStripsBOM(t *testing.T) {
+    rows, err := csvToJSON("\ufeffName\nx\n")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := []map[string]string{{"Name": "x"}}; !reflect.DeepEqual(rows, want) {
+        t.Errorf("csvToJSON = %v; want %v", rows, want)
+    }
+}
+
+func TestLineTerminator(t *testing.T) {
+    for _, crlf := range []bool{false, true} {
+        fromJSON, err := jsonToCSV([]map[string]any{{"a": 1.0, "b": "x"}}, crlf)
+        if err != nil {
+            t.Fatal(err)
+        }
+        transposed, err := transposeCSV("a,b\n1,2\n", crlf)
+        if err != nil {
+            t.Fatal(err)
+        }
+        for name, got := range map[string]string{"jsonToCSV": fromJSON, "transposeCSV": transposed} {
+            bare := strings.ReplaceAll(got, "\r\n", "")
+            if crlf && (!strings.Contains(got, "\r\n") || strings.Contains(bare, "\n")) {
+                t.Errorf("%s with crlf = %q; want every record to end in \\r\\n", name, got)
+            }
+            if !crlf && strings.Contains(got, "\r") {
+                t.Errorf("%s without crlf = %q; want only \\n", name, got)
+            }
+        }
+    }
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+    "strings"
+    "time"
+)
+
+// isoDate is the RFC 3339 full-date layout that normalizeDates writes.
+const isoDate = "2006-01-02"
+
+// normalizeDates rewrites the cells of col that parse under one of layouts (Go reference
+// time layouts, tried in order) as RFC 3339 dates such as 2024-03-09. Cells matching no
+// layout are left unchanged and counted under "unparsed"; blank cells are neither
+// rewritten nor counted. The header row (when opts.HasHeader is set) is skipped. The new
+// table is returned under "csv".
+func normalizeDates(csvText string, col int, layouts []string, opts csvOptions) (map[string]any, error) {
+    if len(layouts) == 0 {
+        return nil, badArgument("expected at least one input date format")
+    }
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return nil, err
+    }
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return nil, err
+    }
+    unparsed := 0
+    for _, row := range rows {
+        if col >= len(row) || strings.TrimSpace(row[col]) == "" {
+            continue
+        }
+        value := strings.TrimSpace(row[col])
+        parsed := false
+        for _, layout := range layouts {
+            if t, err := time.Parse(layout, value); err == nil {
+                row[col] = t.Format(isoDate)
+                parsed = true
+                break
+            }
+        }
+        if !parsed {
+            unparsed++
+        }
+    }
+    if header != nil {
+        rows = append([][]string{header}, rows...)
+    }
+    text, err := encodeCSV(rows, false)
+    if err != nil {
+        return nil, err
+    }
+    return map[string]any{"csv": text, "unparsed": unparsed}, nil
+}
+
+// countInDateRange counts the rows whose col cell, parsed with layout (a Go reference
+// time layout), falls within [from, to] inclusive; from and to use the same layout.
+// Cells that fail to parse are left out and reported as unparsed, and blank cells are
+// skipped without being counted.
+func countInDateRange(rows [][]string, col int, layout, from, to string) (count, unparsed int, err error) {
+    start, err := time.Parse(layout, from)
+    if err != nil {
+        return 0, 0, badArgument("from %q does not match layout %q", from, layout)
+    }
+    end, err := time.Parse(layout, to)
+    if err != nil {
+        return 0, 0, badArgument("to %q does not match layout %q", to, layout)
+    }
+    if end.Before(start) {
+        return 0, 0, badArgument("from %q is after to %q", from, to)
+    }
+    for _, row := range rows {
+        value := strings.TrimSpace(cell(row, col))
+        if value == "" {
+            continue
+        }
+        t, err := time.Parse(layout, value)
+        switch {
+        case err != nil:
+            unparsed++
+        case !t.Before(start) && !t.After(end):
+            count++
+        }
+    }
+    return count, unparsed, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestNormalizeDates(t *testing.T) {
+    // 03/09/2024 parses under the first layout, so it is March 9th, not September 3rd;
+    // " 2024-01-02 " is trimmed before parsing.
+    const text = "id,when\n1,03/09/2024\n2, 2024-01-02 \n3,9 March 2024\n4,\n5,13/45/2024\n6\n"
+    got, err := normalizeDates(text, 1, []string{"01/02/2006", "2006-01-02"}, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := map[string]any{
+        "csv":      "id,when\n1,2024-03-09\n2,2024-01-02\n3,9 March 2024\n4,\n5,13/45/2024\n6\n",
+        "unparsed": 2,
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("normalizeDates = %v; want %v", got, want)
+    }
+}
+
+func TestNormalizeDatesErrors(t *testing.T) {
+    tests := []struct {
+        name    string
+        col     int
+        layouts []string
+        msg     string
+    }{
+        {"no layouts", 0, nil, "expected at least one input date format"},
+        {"column out of range", 3, []string{"2006-01-02"}, "column index 3 out of range (table has 2 columns)"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := normalizeDates("a,b\n1,2\n", tt.col, tt.layouts, csvOptions{})
+            if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.msg {
+                t.Errorf("got %v; want bad_argument %q", m, tt.msg)
+            }
+        })
+    }
+}
+
+func TestCountInDateRange(t *testing.T) {
+    rows := [][]string{
+        {"2024-01-01"}, // on the lower bound
+        {"2024-01-15"},
+        {" 2024-01-31 "}, // on the upper bound, trimmed
+        {"2024-02-01"},
+        {"2023-12-31"},
+        {"31/01/2024"}, // wrong layout
+        {"bad"},
+        {""}, // blank, neither counted nor unparsed
+        {},
+    }
+    count, unparsed, err := countInDateRange(rows, 0, "2006-01-02", "2024-01-01", "2024-01-31")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if count != 3 || unparsed != 2 {
+        t.Errorf("countInDateRange = %d, %d unparsed; want 3, 2", count, unparsed)
+    }
+    count, _, err = countInDateRange(rows, 0, "2006-01-02", "2024-01-15", "2024-01-15")
+    if err != nil || count != 1 {
+        t.Errorf("single-day range = %d, %v; want 1", count, err)
+    }
+}
+
+func TestCountInDateRangeErrors(t *testing.T) {
+    tests := []struct {
+        from, to, msg string
+    }{
+        {"x", "2024-01-31", `from "x" does not match layout "2006-01-02"`},
+        {"2024-01-01", "Jan 31", `to "Jan 31" does not match layout "2006-01-02"`},
+        {"2024-02-01", "2024-01-31", `from "2024-02-01" is after to "2024-01-31"`},
+    }
+    for _, tt := range tests {
+        _, _, err := countInDateRange(nil, 0, "2006-01-02", tt.from, tt.to)
+        if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.msg {
+            t.Errorf("countInDateRange(%q, %q): got %v; want %q", tt.from, tt.to, m, tt.msg)
+        }
+    }
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+    "strconv"
+    "strings"
+)
+
+// rowKey joins the selected cells of record (all cells when cols is empty) into a string
+// that is unambiguous regardless of cell content: each cell is length-prefixed.
+// Columns beyond the end of a short record count as empty.
+func rowKey(record []string, cols []int) string {
+    var b strings.Builder
+    write := func(value string) {
+        b.WriteString(strconv.Itoa(len(value)))
+        b.WriteByte(':')
+        b.WriteString(value)
+    }
+    if len(cols) == 0 {
+        for _, value := range record {
+            write(value)
+        }
+        return b.String()
+    }
+    for _, col := range cols {
+        if col >= 0 && col < len(record) {
+            write(record[col])
+        } else {
+            write("")
+        }
+    }
+    return b.String()
+}
+
+// dedupeTracker counts unique and duplicate rows in one pass with a hash set of row keys.
+type dedupeTracker struct {
+    key        []int
+    seen       map[string]struct{}
+    duplicates int
+}
+
+// observe records one row, counting it as a duplicate if its key was seen before, and
+// reports whether it was the first with its key.
+func (t *dedupeTracker) observe(record []string) bool {
+    if t.seen == nil {
+        t.seen = map[string]struct{}{}
+    }
+    k := rowKey(record, t.key)
+    if _, ok := t.seen[k]; ok {
+        t.duplicates++
+        return false
+    }
+    t.seen[k] = struct{}{}
+    return true
+}
+
+// result renders the counts under the keys merged into the summary map.
+func (t *dedupeTracker) result() map[string]any {
+    return map[string]any{
+        "uniqueRows":    len(t.seen),
+        "duplicateRows": t.duplicates,
+    }
+}
+
+// dedupeSummary counts rows whose full value (or the cells at key, when given) matches
+// an earlier row, returning the same "uniqueRows" and "duplicateRows" counts as the summary.
+func dedupeSummary(rows [][]string, key []int) map[string]any {
+    t := dedupeTracker{key: key}
+    for _, row := range rows {
+        t.observe(row)
+    }
+    return t.result()
+}
+
+// uniqueRows re-encodes csvText keeping only the first data row for each key (the cells
+// at keyCols, or the whole row when keyCols is empty) in original order. The header row
+// (when opts.HasHeader is set) is always kept.
+func uniqueRows(csvText string, keyCols []int, opts csvOptions) (string, error) {
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    width := max(len(header), tableWidth(rows))
+    for _, col := range keyCols {
+        if err := checkColumn(col, width); err != nil {
+            return "", err
+        }
+    }
+    t := dedupeTracker{key: keyCols}
+    kept := [][]string{}
+    if header != nil {
+        kept = append(kept, header)
+    }
+    for _, row := range rows {
+        if t.observe(row) {
+            kept = append(kept, row)
+        }
+    }
+    return encodeCSV(kept, false)
+}
@@ -0,0 +1,91 @@
+package main
+
+import "testing"
+
+func TestSummaryDedupe(t *testing.T) {
+    text := "id,name,city\n1,ann,oslo\n1,ann,oslo\n2,bob,rome\n1,ann,bergen\n"
+    tests := []struct {
+        name       string
+        key        []int
+        unique     int
+        duplicates int
+    }{
+        {"exact duplicates", nil, 3, 1},
+        {"key ignores other columns", []int{0, 1}, 2, 2},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            summary, err := summaryFromCSV(text, csvOptions{HasHeader: true, Dedupe: true, DedupeKey: tt.key})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if summary["uniqueRows"] != tt.unique || summary["duplicateRows"] != tt.duplicates {
+                t.Errorf("uniqueRows, duplicateRows = %v, %v; want %d, %d",
+                    summary["uniqueRows"], summary["duplicateRows"], tt.unique, tt.duplicates)
+            }
+        })
+    }
+}
+
+func TestDedupeSummary(t *testing.T) {
+    rows := [][]string{
+        {"1", "ann", "oslo"},
+        {"1", "ann", "oslo"},
+        {"2", "bob", "rome"},
+        {"1", "ann", "bergen"},
+    }
+    tests := []struct {
+        name       string
+        key        []int
+        unique     int
+        duplicates int
+    }{
+        {"exact duplicates", nil, 3, 1},
+        {"key ignores other columns", []int{0, 1}, 2, 2},
+        {"key past the end counts as empty", []int{2, 5}, 3, 1},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := dedupeSummary(rows, tt.key)
+            if got["uniqueRows"] != tt.unique || got["duplicateRows"] != tt.duplicates {
+                t.Errorf("dedupeSummary(%v) = %v; want %d unique, %d duplicate", tt.key, got, tt.unique, tt.duplicates)
+            }
+        })
+    }
+}
+
+func TestRowKeyIsUnambiguous(t *testing.T) {
+    if rowKey([]string{"a", "bc"}, nil) == rowKey([]string{"ab", "c"}, nil) {
+        t.Error("a|bc and ab|c share a row key")
+    }
+    if rowKey([]string{"x"}, []int{0, 3}) != rowKey([]string{"x", "", "", ""}, []int{0, 3}) {
+        t.Error("a missing key column should count as empty")
+    }
+}
+
+func TestUniqueRows(t *testing.T) {
+    const text = "id,name\n1,ann\n2,bob\n1,ann\n1,cy\n3,bob\n"
+    tests := []struct {
+        name string
+        key  []int
+        want string
+    }{
+        {"full row", nil, "id,name\n1,ann\n2,bob\n1,cy\n3,bob\n"},
+        {"first match per key", []int{0}, "id,name\n1,ann\n2,bob\n3,bob\n"},
+        {"other key", []int{1}, "id,name\n1,ann\n2,bob\n1,cy\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := uniqueRows(text, tt.key, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("uniqueRows(%v) = %q; want %q", tt.key, got, tt.want)
+            }
+        })
+    }
+    if _, err := uniqueRows(text, []int{2}, csvOptions{HasHeader: true}); err == nil {
+        t.Error("out-of-range key column accepted")
+    }
+}
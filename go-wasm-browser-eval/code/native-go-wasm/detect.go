@@ -0,0 +1,205 @@
+package main
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "errors"
+    "io"
+    "strings"
+)
+
+const (
+    // detectSampleLines is how many records detectDelimiter looks at.
+    detectSampleLines = 10
+    // detectSampleBytes bounds how much of a payload is handed to detectDelimiter.
+    detectSampleBytes = 64 * 1024
+)
+
+// delimiterCandidates are tried in order; earlier entries win ties, so comma is first.
+var delimiterCandidates = []rune{',', '\t', ';', '|'}
+
+// delimiterScore parses the first lines of sample with delimiter and returns how many
+// records share the most common field count, along with how many records were read.
+// Counts of one field do not score, since every delimiter "splits" a line it does not
+// occur in into a single field.
+func delimiterScore(sample string, delimiter rune) (score, records int) {
+    reader := csv.NewReader(strings.NewReader(sample))
+    reader.Comma = delimiter
+    reader.FieldsPerRecord = -1
+    reader.LazyQuotes = true
+    widths := map[int]int{}
+    for i := 0; i < detectSampleLines; i++ {
+        record, err := reader.Read()
+        if errors.Is(err, io.EOF) {
+            break
+        }
+        if err != nil {
+            // A truncated sample may end mid-record; score what was read so far.
+            break
+        }
+        widths[len(record)]++
+        records++
+    }
+    for width, count := range widths {
+        if width > 1 && count > score {
+            score = count
+        }
+    }
+    return score, records
+}
+
+// detectDelimiter picks the candidate delimiter producing the most consistent column
+// count across the first lines of sample. Ties, and samples with no clear winner,
+// resolve to comma.
+func detectDelimiter(sample string) rune {
+    best, bestScore := ',', 0
+    for _, candidate := range delimiterCandidates {
+        if score, _ := delimiterScore(sample, candidate); score > bestScore {
+            best, bestScore = candidate, score
+        }
+    }
+    return best
+}
+
+// sampleOf returns the leading detectSampleBytes of text for sniffing.
+func sampleOf(text string) string {
+    if len(text) > detectSampleBytes {
+        return text[:detectSampleBytes]
+    }
+    return text
+}
+
+// detectUnsetDelimiter fills in opts.Delimiter from sample when the caller did not
+// supply one, reporting whether detection ran.
+func detectUnsetDelimiter(opts *csvOptions, sample string) bool {
+    if opts.Delimiter != 0 {
+        return false
+    }
+    opts.Delimiter = detectDelimiter(sample)
+    return true
+}
+
+// hasHeaderHeuristic guesses whether rows[0] is a header: every cell of it must be
+// non-empty text, and at least one of its columns must hold only numbers in the rows
+// below. An all-text file is ambiguous and reported as having no header.
+func hasHeaderHeuristic(rows [][]string) bool {
+    if len(rows) < 2 || len(rows[0]) == 0 {
+        return false
+    }
+    for _, value := range rows[0] {
+        value = strings.TrimSpace(value)
+        if value == "" || classifyValue(value) != typeString {
+            return false
+        }
+    }
+    for col := range rows[0] {
+        numeric := 0
+        for _, row := range rows[1:] {
+            value := strings.TrimSpace(cell(row, col))
+            if value == "" {
+                continue
+            }
+            if kind := classifyValue(value); kind != typeInteger && kind != typeFloat {
+                numeric = 0
+                break
+            }
+            numeric++
+        }
+        if numeric > 0 {
+            return true
+        }
+    }
+    return false
+}
+
+// detectUnsetHeader fills in opts.HasHeader from the first records of sample when the
+// caller did not say whether there is a header, reporting whether detection ran. It
+// should run after the delimiter is known.
+func detectUnsetHeader(opts *csvOptions, sample string) bool {
+    if !opts.HeaderUnset {
+        return false
+    }
+    reader := newCSVReader(strings.NewReader(sample), *opts)
+    var rows [][]string
+    for len(rows) < detectSampleLines {
+        record, err := reader.Read()
+        if err != nil {
+            // EOF, or a truncated sample ending mid-record; judge what was read.
+            break
+        }
+        rows = append(rows, record)
+    }
+    opts.HasHeader = hasHeaderHeuristic(rows)
+    return true
+}
+
+// jsonTruncated reports whether sample reads as the start of a JSON document that was
+// cut short: tokenizing it, which also keeps braces and brackets balanced, runs into the
+// end of input before any syntax error.
+func jsonTruncated(sample string) bool {
+    decoder := json.NewDecoder(strings.NewReader(sample))
+    for {
+        if _, err := decoder.Token(); err != nil {
+            return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+        }
+    }
+}
+
+// ndjsonShare returns the fraction of the non-blank lines of sample that are JSON
+// objects, and how many non-blank lines there were. The last line is ignored when it
+// fails to parse, since a sample may cut it short.
+func ndjsonShare(sample string) (float64, int) {
+    lines := strings.FieldsFunc(sample, func(r rune) bool { return r == '\n' || r == '\r' })
+    valid, total := 0, 0
+    for i, line := range lines {
+        line = strings.TrimSpace(line)
+        if line == "" {
+            continue
+        }
+        ok := strings.HasPrefix(line, "{") && json.Valid([]byte(line))
+        if !ok && i == len(lines)-1 && total > 0 {
+            break
+        }
+        total++
+        if ok {
+            valid++
+        }
+    }
+    if total == 0 {
+        return 0, 0
+    }
+    return float64(valid) / float64(total), total
+}
+
+// sniffFormat guesses whether sample is "json", "ndjson", "csv" or "tsv", with a
+// confidence between 0 and 1; anything else is "unknown" with confidence 0. JSON must
+// parse, or at least tokenize cleanly up to a truncation point; NDJSON needs two or more
+// lines of JSON objects; CSV and TSV need at least two records agreeing on a field count
+// under detectDelimiter's scoring, with confidence the share of records that agree.
+func sniffFormat(sample string) (format string, confidence float64) {
+    trimmed := strings.TrimSpace(strings.TrimPrefix(sampleOf(sample), utf8BOM))
+    if trimmed == "" {
+        return "unknown", 0
+    }
+    if share, lines := ndjsonShare(trimmed); lines >= 2 && share >= 0.8 {
+        return "ndjson", share
+    }
+    if trimmed[0] == '{' || trimmed[0] == '[' {
+        if json.Valid([]byte(trimmed)) {
+            return "json", 1
+        }
+        if jsonTruncated(trimmed) {
+            return "json", 0.5
+        }
+    }
+    delimiter := detectDelimiter(trimmed)
+    score, records := delimiterScore(trimmed, delimiter)
+    if score < 2 {
+        return "unknown", 0
+    }
+    format = "csv"
+    if delimiter == '\t' {
+        format = "tsv"
+    }
+    return format, float64(score) / float64(records)
+}
@@ -0,0 +1,102 @@
+package main
+
+import "testing"
+
+func TestDetectDelimiter(t *testing.T) {
+    tests := []struct {
+        name   string
+        sample string
+        want   rune
+    }{
+        {"comma", "a,b,c\n1,2,3\n", ','},
+        {"tsv", "name\tcity\tage\nann\toslo, no\t30\nbob\trome\t41\n", '\t'},
+        {"semicolon", "a;b;c\n1,5;2,5;3\n4;5;6\n", ';'},
+        {"pipe", "a|b\n1|2\n", '|'},
+        {"tie goes to comma", "a,b;c\n1,2;3\n", ','},
+        {"no delimiter at all", "just\nwords\nhere\n", ','},
+        {"empty", "", ','},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := detectDelimiter(tt.sample); got != tt.want {
+                t.Errorf("detectDelimiter = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestDetectUnsetDelimiter(t *testing.T) {
+    opts := csvOptions{Delimiter: '|'}
+    if detectUnsetDelimiter(&opts, "a;b\n1;2\n") || opts.Delimiter != '|' {
+        t.Errorf("an explicit delimiter was replaced with %q", opts.Delimiter)
+    }
+    opts = csvOptions{}
+    if !detectUnsetDelimiter(&opts, "a;b\n1;2\n") || opts.Delimiter != ';' {
+        t.Errorf("detected %q; want ';'", opts.Delimiter)
+    }
+}
+
+func TestHasHeaderHeuristic(t *testing.T) {
+    tests := []struct {
+        name string
+        rows [][]string
+        want bool
+    }{
+        {"clear header", [][]string{{"name", "age"}, {"ann", "30"}, {"bob", "41"}}, true},
+        {"numeric only", [][]string{{"1", "2"}, {"3", "4"}}, false},
+        {"all text is ambiguous", [][]string{{"name", "city"}, {"ann", "oslo"}}, false},
+        {"blank header cell", [][]string{{"name", ""}, {"ann", "30"}}, false},
+        {"blank cells below are ignored", [][]string{{"id", "note"}, {"1", "x"}, {"", "y"}, {"3", ""}}, true},
+        {"mixed numeric column does not count", [][]string{{"id"}, {"1"}, {"n/a"}}, false},
+        {"header only", [][]string{{"name"}}, false},
+        {"empty", nil, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := hasHeaderHeuristic(tt.rows); got != tt.want {
+                t.Errorf("hasHeaderHeuristic = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestDetectUnsetHeader(t *testing.T) {
+    opts := csvOptions{HeaderUnset: true}
+    if !detectUnsetHeader(&opts, "name,age\nann,30\n") || !opts.HasHeader {
+        t.Error("header not detected for name,age over numeric ages")
+    }
+    opts = csvOptions{}
+    if detectUnsetHeader(&opts, "name,age\nann,30\n") || opts.HasHeader {
+        t.Error("an explicit no-header flag was overridden")
+    }
+}
+
+func TestSniffFormat(t *testing.T) {
+    tests := []struct {
+        name       string
+        sample     string
+        format     string
+        confidence float64
+    }{
+        {"json object", `{"a": [1, 2]}`, "json", 1},
+        {"json array", " [1, {\"b\": null}]\n", "json", 1},
+        {"truncated json", `[{"a":1},{"b":`, "json", 0.5},
+        {"ndjson", "{\"a\":1}\n{\"a\":2}\n", "ndjson", 1},
+        {"ndjson cut short", "{\"a\":1}\n{\"a\":2}\n{\"a\"", "ndjson", 1},
+        {"csv", "a,b\n1,2\n3,4\n", "csv", 1},
+        {"ragged csv", "a,b\n1,2\n3\n4,5\n", "csv", 0.75},
+        {"semicolon csv with BOM", "\ufeffa;b\n1;2\n", "csv", 1},
+        {"tsv", "a\tb\n1\t2\n", "tsv", 1},
+        {"prose", "Dear team,\nthanks for the notes. See you soon.\nBye", "unknown", 0},
+        {"single word", "hello", "unknown", 0},
+        {"empty", "  \n", "unknown", 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            format, confidence := sniffFormat(tt.sample)
+            if format != tt.format || confidence != tt.confidence {
+                t.Errorf("sniffFormat = %q, %v; want %q, %v", format, confidence, tt.format, tt.confidence)
+            }
+        })
+    }
+}
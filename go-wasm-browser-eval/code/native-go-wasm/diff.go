@@ -0,0 +1,71 @@
+package main
+
+// keyedRows indexes rows by their keyCol cell, keeping the keys in input order. A key seen
+// twice is an error since diffCSV could not tell which rows to pair.
+func keyedRows(rows [][]string, keyCol int, side string) (map[string][]string, []string, error) {
+    byKey := make(map[string][]string, len(rows))
+    order := make([]string, 0, len(rows))
+    for _, row := range rows {
+        key := cell(row, keyCol)
+        if _, dup := byKey[key]; dup {
+            return nil, nil, badArgument("duplicate key %q in %s table", key, side)
+        }
+        byKey[key] = row
+        order = append(order, key)
+    }
+    return byKey, order, nil
+}
+
+// sameExceptKey reports whether a and b hold the same cells outside keyCol. Cells missing
+// from a short row compare as empty.
+func sameExceptKey(a, b []string, keyCol int) bool {
+    for i := range max(len(a), len(b)) {
+        if i != keyCol && cell(a, i) != cell(b, i) {
+            return false
+        }
+    }
+    return true
+}
+
+// diffCSV compares two versions of a table keyed by keyCol. "added" lists keys only in
+// bText and "removed" keys only in aText, while "changed" lists keys present in both
+// whose other cells differ. Keys must be unique within each table; added keys are in
+// bText order and the others in aText order. The header rows (when opts.HasHeader is
+// set) are not compared.
+func diffCSV(aText, bText string, keyCol int, opts csvOptions) (map[string]any, error) {
+    aHeader, aRows, err := splitHeader(aText, opts)
+    if err != nil {
+        return nil, err
+    }
+    bHeader, bRows, err := splitHeader(bText, opts)
+    if err != nil {
+        return nil, err
+    }
+    if err := checkColumn(keyCol, max(len(aHeader), tableWidth(aRows), len(bHeader), tableWidth(bRows))); err != nil {
+        return nil, err
+    }
+    aByKey, aOrder, err := keyedRows(aRows, keyCol, "first")
+    if err != nil {
+        return nil, err
+    }
+    bByKey, bOrder, err := keyedRows(bRows, keyCol, "second")
+    if err != nil {
+        return nil, err
+    }
+    added, removed, changed := []string{}, []string{}, []string{}
+    for _, key := range aOrder {
+        b, ok := bByKey[key]
+        switch {
+        case !ok:
+            removed = append(removed, key)
+        case !sameExceptKey(aByKey[key], b, keyCol):
+            changed = append(changed, key)
+        }
+    }
+    for _, key := range bOrder {
+        if _, ok := aByKey[key]; !ok {
+            added = append(added, key)
+        }
+    }
+    return map[string]any{"added": added, "removed": removed, "changed": changed}, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestDiffCSV(t *testing.T) {
+    const a = "id,name,score\n1,ann,10\n2,bob,20\n3,cy,30\n4,dee\n"
+    tests := []struct {
+        name string
+        b    string
+        want map[string]any
+    }{
+        {"identical", a, map[string]any{"added": []string{}, "removed": []string{}, "changed": []string{}}},
+        {
+            "additions",
+            a + "6,fay,60\n5,eve,50\n",
+            map[string]any{"added": []string{"6", "5"}, "removed": []string{}, "changed": []string{}},
+        },
+        {
+            "removals",
+            "id,name,score\n3,cy,30\n",
+            map[string]any{"added": []string{}, "removed": []string{"1", "2", "4"}, "changed": []string{}},
+        },
+        {
+            // Row order does not matter, and a trailing empty cell matches a short row.
+            "changes",
+            "id,name,score\n4,dee,\n3,cy,31\n2,Bob,20\n1,ann,10\n",
+            map[string]any{"added": []string{}, "removed": []string{}, "changed": []string{"2", "3"}},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := diffCSV(a, tt.b, 0, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("diffCSV = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestDiffCSVErrors(t *testing.T) {
+    tests := []struct {
+        name   string
+        a, b   string
+        keyCol int
+        msg    string
+    }{
+        {"duplicate in first", "k\n1\n1\n", "k\n1\n", 0, `duplicate key "1" in first table`},
+        {"duplicate in second", "k\n1\n", "k\n2\n2\n", 0, `duplicate key "2" in second table`},
+        {"key out of range", "k,v\n1,a\n", "k\n1\n", 2, "column index 2 out of range (table has 2 columns)"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := diffCSV(tt.a, tt.b, tt.keyCol, csvOptions{HasHeader: true})
+            if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.msg {
+                t.Errorf("got %v; want bad_argument %q", m, tt.msg)
+            }
+        })
+    }
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+    "strings"
+    "unicode"
+    "unicode/utf8"
+)
+
+// isBlank reports whether a cell is empty or whitespace-only.
+func isBlank(value string) bool {
+    return strings.TrimSpace(value) == ""
+}
+
+// emptyTracker counts blank cells in one column along with how many rows reached it,
+// so cells missing from short rows can be counted as empty at the end.
+type emptyTracker struct {
+    blank   int
+    present int
+}
+
+// observe records one cell present in the column.
+func (t *emptyTracker) observe(value string) {
+    t.present++
+    if isBlank(value) {
+        t.blank++
+    }
+}
+
+// result returns the blank count for a column given the total number of data rows.
+func (t *emptyTracker) result(rows int) int {
+    return t.blank + rows - t.present
+}
+
+// emptyCounts returns, per column, how many cells of rows are empty or whitespace-only.
+// Rows shorter than the widest row count their missing trailing cells as empty. Callers
+// pass data rows only, so a header is excluded by leaving it out of rows.
+func emptyCounts(rows [][]string) []int {
+    var trackers []emptyTracker
+    for _, row := range rows {
+        for len(trackers) < len(row) {
+            trackers = append(trackers, emptyTracker{})
+        }
+        for i, value := range row {
+            trackers[i].observe(value)
+        }
+    }
+    counts := make([]int, len(trackers))
+    for i := range trackers {
+        counts[i] = trackers[i].result(len(rows))
+    }
+    return counts
+}
+
+// fillRate is the share, from 0 to 1, of a column's rows cells that are not among its
+// empty blank ones. A table with no data rows has a rate of 0 rather than NaN.
+func fillRate(empty, rows int) float64 {
+    if rows == 0 {
+        return 0
+    }
+    return float64(rows-empty) / float64(rows)
+}
+
+// fillRates returns, per column, the share of the cells of rows that are non-empty,
+// counting missing trailing cells as empty like emptyCounts. No rows means no columns,
+// so the result is empty rather than a row of NaNs.
+func fillRates(rows [][]string) []float64 {
+    counts := emptyCounts(rows)
+    rates := make([]float64, len(counts))
+    for i, n := range counts {
+        rates[i] = fillRate(n, len(rows))
+    }
+    return rates
+}
+
+// hasStrayWhitespace reports whether value starts or ends with a Unicode space.
+func hasStrayWhitespace(value string) bool {
+    first, _ := utf8.DecodeRuneInString(value)
+    last, _ := utf8.DecodeLastRuneInString(value)
+    return value != "" && (unicode.IsSpace(first) || unicode.IsSpace(last))
+}
+
+// whitespaceReport returns, per column, how many cells of rows have leading or trailing
+// whitespace, whitespace-only cells included. Missing trailing cells count as clean.
+func whitespaceReport(rows [][]string) []int {
+    counts := make([]int, tableWidth(rows))
+    for _, row := range rows {
+        for i, value := range row {
+            if hasStrayWhitespace(value) {
+                counts[i]++
+            }
+        }
+    }
+    return counts
+}
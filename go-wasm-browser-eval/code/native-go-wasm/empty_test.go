@@ -0,0 +1,116 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestSummaryEmptyCounts(t *testing.T) {
+    // The second column is half empty across the six data rows: one blank, one
+    // whitespace-only and one missing from a short row.
+    text := "id,note\n1,\n2,  \n3\n4,x\n5,y\n6,z\n"
+    tests := []struct {
+        name   string
+        header bool
+        empty  []int
+        rates  []float64
+    }{
+        {"header excluded", true, []int{0, 3}, []float64{1, 0.5}},
+        {"header counted as data", false, []int{0, 3}, []float64{1, 4.0 / 7}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            summary, err := summaryFromCSV(text, csvOptions{HasHeader: tt.header})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got := summary["emptyCounts"]; !reflect.DeepEqual(got, tt.empty) {
+                t.Errorf("emptyCounts = %v; want %v", got, tt.empty)
+            }
+            if got := summary["fillRates"]; !reflect.DeepEqual(got, tt.rates) {
+                t.Errorf("fillRates = %v; want %v", got, tt.rates)
+            }
+        })
+    }
+}
+
+func TestSummaryEmptyHeaderCell(t *testing.T) {
+    summary, err := summaryFromCSV(",b\n1,2\n", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got, want := summary["emptyCounts"], []int{0, 0}; !reflect.DeepEqual(got, want) {
+        t.Errorf("emptyCounts = %v; want %v with the blank header cell excluded", got, want)
+    }
+}
+
+func TestEmptyCounts(t *testing.T) {
+    // The second column is half empty: one blank, one whitespace-only and one missing
+    // from a short row.
+    rows := [][]string{{"1", ""}, {"2", "  "}, {"3"}, {"4", "x"}, {"5", "y"}, {"6", "z"}}
+    if got, want := emptyCounts(rows), []int{0, 3}; !reflect.DeepEqual(got, want) {
+        t.Errorf("emptyCounts = %v; want %v", got, want)
+    }
+    // A column only one ragged row reaches counts every other row as empty.
+    ragged := [][]string{{"a"}, {"b", "c", "d"}, {""}}
+    if got, want := emptyCounts(ragged), []int{1, 2, 2}; !reflect.DeepEqual(got, want) {
+        t.Errorf("emptyCounts(ragged) = %v; want %v", got, want)
+    }
+    if got := emptyCounts(nil); len(got) != 0 {
+        t.Errorf("emptyCounts(nil) = %v; want empty", got)
+    }
+}
+
+func TestFillRate(t *testing.T) {
+    tests := []struct {
+        empty, rows int
+        want        float64
+    }{
+        {0, 4, 1},
+        {2, 4, 0.5},
+        {4, 4, 0},
+        {0, 0, 0},
+    }
+    for _, tt := range tests {
+        if got := fillRate(tt.empty, tt.rows); got != tt.want {
+            t.Errorf("fillRate(%d, %d) = %v; want %v", tt.empty, tt.rows, got, tt.want)
+        }
+    }
+    summary, err := summaryFromCSV("a,b\n", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got, want := summary["fillRates"], []float64{0, 0}; !reflect.DeepEqual(got, want) {
+        t.Errorf("fillRates with no data rows = %v; want %v", got, want)
+    }
+}
+
+func TestFillRates(t *testing.T) {
+    rows := [][]string{{"1", ""}, {"2", "  "}, {"3"}, {"4", "x"}, {"5", "y"}, {"6", "z"}}
+    if got, want := fillRates(rows), []float64{1, 0.5}; !reflect.DeepEqual(got, want) {
+        t.Errorf("fillRates = %v; want %v", got, want)
+    }
+    for _, rows := range [][][]string{nil, {}} {
+        if got := fillRates(rows); len(got) != 0 {
+            t.Errorf("fillRates(%v) = %v; want empty", rows, got)
+        }
+    }
+}
+
+func TestWhitespaceReport(t *testing.T) {
+    rows := [][]string{
+        {"ann ", "oslo"},
+        {"bob", " rome"},
+        {"cy\t", "bern"},
+        {" ", ""},
+        {"dee"},
+        {"eve", "a b"},
+    }
+    // Inner spaces and empty cells are clean; a whitespace-only cell is not.
+    if got, want := whitespaceReport(rows), []int{3, 1}; !reflect.DeepEqual(got, want) {
+        t.Errorf("whitespaceReport = %v; want %v", got, want)
+    }
+    if got := whitespaceReport(nil); len(got) != 0 {
+        t.Errorf("whitespaceReport(nil) = %v; want empty", got)
+    }
+}
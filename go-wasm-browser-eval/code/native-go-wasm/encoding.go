@@ -0,0 +1,130 @@
+package main
+
+import (
+    "bytes"
+    "unicode/utf8"
+)
+
+// firstInvalidUTF8 returns the byte offset of the first invalid UTF-8 sequence in data,
+// or -1 when data is valid. A multibyte sequence cut off by the end of data is invalid
+// at the offset where it starts.
+func firstInvalidUTF8(data []byte) int {
+    if utf8.Valid(data) {
+        return -1
+    }
+    for i := 0; i < len(data); {
+        r, size := utf8.DecodeRune(data[i:])
+        if r == utf8.RuneError && size == 1 {
+            return i
+        }
+        i += size
+    }
+    return -1
+}
+
+// checkEncoding reports whether data is valid UTF-8 and where the first bad byte is.
+func checkEncoding(data []byte) map[string]any {
+    offset := firstInvalidUTF8(data)
+    return map[string]any{
+        "valid":          offset < 0,
+        "firstBadOffset": offset,
+    }
+}
+
+// charsetGuess builds guessCharset's result.
+func charsetGuess(charset string, confidence float64, bom bool) map[string]any {
+    return map[string]any{"charset": charset, "confidence": confidence, "bom": bom}
+}
+
+// guessCharset makes a best-effort guess at the character set of data from its leading
+// detectSampleBytes. A byte-order mark is conclusive (confidence 1). Otherwise NUL bytes
+// concentrated in odd or even positions suggest BOM-less UTF-16, valid UTF-8 with
+// multibyte sequences suggests UTF-8, and invalid UTF-8 suggests a single-byte charset:
+// windows-1252 when C1-range bytes (0x80-0x9f) appear, since those are printable there
+// and control codes in latin-1. Pure ASCII, and anything else, is reported as "utf-8"
+// with low confidence because every candidate decodes it the same way.
+func guessCharset(data []byte) map[string]any {
+    switch {
+    case bytes.HasPrefix(data, []byte(utf8BOM)):
+        return charsetGuess("utf-8", 1, true)
+    case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+        return charsetGuess("utf-16le", 1, true)
+    case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+        return charsetGuess("utf-16be", 1, true)
+    }
+    sample := data
+    truncated := len(sample) > detectSampleBytes
+    if truncated {
+        sample = sample[:detectSampleBytes]
+    }
+    var evenNUL, oddNUL, high, c1 int
+    for i, b := range sample {
+        switch {
+        case b == 0 && i%2 == 0:
+            evenNUL++
+        case b == 0:
+            oddNUL++
+        case b >= 0x80:
+            high++
+            if b <= 0x9f {
+                c1++
+            }
+        }
+    }
+    // In UTF-16 text that is mostly ASCII, every other byte is NUL.
+    if pairs := len(sample) / 2; pairs > 0 {
+        switch {
+        case float64(oddNUL) > 0.3*float64(pairs) && evenNUL*10 < oddNUL:
+            return charsetGuess("utf-16le", 0.8, false)
+        case float64(evenNUL) > 0.3*float64(pairs) && oddNUL*10 < evenNUL:
+            return charsetGuess("utf-16be", 0.8, false)
+        }
+    }
+    if high == 0 {
+        return charsetGuess("utf-8", 0.5, false)
+    }
+    offset := firstInvalidUTF8(sample)
+    // A multibyte sequence cut by the sample boundary is not evidence against UTF-8.
+    if offset < 0 || truncated && offset >= len(sample)-utf8.UTFMax+1 {
+        return charsetGuess("utf-8", 0.9, false)
+    }
+    if c1 > 0 {
+        return charsetGuess("windows-1252", 0.6, false)
+    }
+    return charsetGuess("latin-1", 0.6, false)
+}
+
+// latin1ToUTF8 decodes ISO-8859-1 bytes, where every byte is the code point of the same
+// value, into a UTF-8 string. ASCII input comes back unchanged.
+func latin1ToUTF8(data []byte) string {
+    runes := make([]rune, len(data))
+    for i, b := range data {
+        runes[i] = rune(b)
+    }
+    return string(runes)
+}
+
+// lineEndingReport counts the line endings in raw bytes, before any CSV parsing: "lf"
+// for a bare "\n", "crlf" for "\r\n" and "cr" for a "\r" not followed by "\n". "mixed"
+// is set when more than one kind occurs. Line breaks inside quoted fields count too.
+func lineEndingReport(data []byte) map[string]any {
+    lf, crlf, cr := 0, 0, 0
+    for i := 0; i < len(data); i++ {
+        switch {
+        case data[i] == '\n':
+            lf++
+        case data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n':
+            crlf++
+            i++
+        case data[i] == '\r':
+            cr++
+        }
+    }
+    kinds := 0
+    for _, n := range []int{lf, crlf, cr} {
+        if n > 0 {
+            kinds++
+        }
+    }
+    return map[string]any{"lf": lf, "crlf": crlf, "cr": cr, "mixed": kinds > 1}
+}
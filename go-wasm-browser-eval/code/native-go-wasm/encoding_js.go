@@ -0,0 +1,42 @@
+//go:build js && wasm
+
+package main
+
+import "syscall/js"
+
+// wrapCheckEncoding exposes checkEncoding to JavaScript as wasmCheckEncoding(uint8array).
+func wrapCheckEncoding(this js.Value, args []js.Value) any {
+    data, err := bytesArg(args, 0)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    return checkEncoding(data)
+}
+
+// wrapGuessCharset exposes guessCharset to JavaScript as wasmGuessCharset(uint8array),
+// returning {charset, confidence, bom}.
+func wrapGuessCharset(this js.Value, args []js.Value) any {
+    data, err := bytesArg(args, 0)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    return guessCharset(data)
+}
+
+// wrapLatin1ToUTF8 exposes latin1ToUTF8 to JavaScript as wasmLatin1ToUTF8(uint8array).
+func wrapLatin1ToUTF8(this js.Value, args []js.Value) any {
+    data, err := bytesArg(args, 0)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    return latin1ToUTF8(data)
+}
+
+// wrapLineEndings exposes lineEndingReport to JavaScript as wasmLineEndings(uint8array).
+func wrapLineEndings(this js.Value, args []js.Value) any {
+    data, err := bytesArg(args, 0)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    return lineEndingReport(data)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+    "bytes"
+    "reflect"
+    "testing"
+)
+
+func TestCheckEncoding(t *testing.T) {
+    tests := []struct {
+        name   string
+        data   []byte
+        offset int
+    }{
+        {"ascii", []byte("a,b\n1,2\n"), -1},
+        {"multibyte", []byte("Zoë,東京,🙂\n"), -1},
+        {"empty", nil, -1},
+        {"lone continuation byte", []byte("ab\x80cd"), 2},
+        {"latin-1 e acute", []byte("caf\xe9\n"), 3},
+        {"truncated sequence at EOF", []byte("ok\xe6\x9d"), 2},
+        {"overlong encoding", []byte("x\xc0\xafy"), 1},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            want := map[string]any{"valid": tt.offset < 0, "firstBadOffset": tt.offset}
+            if got := checkEncoding(tt.data); !reflect.DeepEqual(got, want) {
+                t.Errorf("checkEncoding(%q) = %v; want %v", tt.data, got, want)
+            }
+        })
+    }
+}
+
+func TestLatin1ToUTF8(t *testing.T) {
+    if got := latin1ToUTF8([]byte{'c', 'a', 'f', 0xe9}); got != "café" {
+        t.Errorf("latin1ToUTF8(caf\\xe9) = %q; want café", got)
+    }
+    ascii := make([]byte, 128)
+    for i := range ascii {
+        ascii[i] = byte(i)
+    }
+    if got := latin1ToUTF8(ascii); got != string(ascii) {
+        t.Errorf("ASCII changed: %q", got)
+    }
+    high := latin1ToUTF8([]byte{0xa0, 0xff})
+    if high != "\u00a0\u00ff" || checkEncoding([]byte(high))["valid"] != true {
+        t.Errorf("latin1ToUTF8(a0 ff) = %q; want valid UTF-8 U+00A0 U+00FF", high)
+    }
+}
+
+func TestLineEndingReport(t *testing.T) {
+    tests := []struct {
+        name         string
+        data         string
+        lf, crlf, cr int
+        mixed        bool
+    }{
+        {"pure LF", "a,b\n1,2\n", 2, 0, 0, false},
+        {"pure CRLF", "a,b\r\n1,2\r\n", 0, 2, 0, false},
+        {"lone CR", "a,b\r1,2\r", 0, 0, 2, false},
+        {"mixed", "a,b\r\n1,2\n3,4\r\n5,6", 1, 2, 0, true},
+        // A CR just before another CR is lone; the second pairs with the LF.
+        {"CR CR LF", "a\r\r\nb", 0, 1, 1, true},
+        {"no line break", "a,b", 0, 0, 0, false},
+        {"empty", "", 0, 0, 0, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := lineEndingReport([]byte(tt.data))
+            want := map[string]any{"lf": tt.lf, "crlf": tt.crlf, "cr": tt.cr, "mixed": tt.mixed}
+            if !reflect.DeepEqual(got, want) {
+                t.Errorf("lineEndingReport = %v; want %v", got, want)
+            }
+        })
+    }
+}
+
+func TestGuessCharset(t *testing.T) {
+    tests := []struct {
+        name string
+        data []byte
+        want map[string]any
+    }{
+        {"utf-16le BOM", []byte{0xff, 0xfe, 'a', 0, ',', 0}, charsetGuess("utf-16le", 1, true)},
+        {"utf-16be BOM", []byte{0xfe, 0xff, 0, 'a'}, charsetGuess("utf-16be", 1, true)},
+        {"utf-8 BOM", []byte("\ufeffa,b\n"), charsetGuess("utf-8", 1, true)},
+        {"plain ascii", []byte("a,b\n1,2\n"), charsetGuess("utf-8", 0.5, false)},
+        {"empty", nil, charsetGuess("utf-8", 0.5, false)},
+        {"utf-8 multibyte", []byte("café,東京\n"), charsetGuess("utf-8", 0.9, false)},
+        {"latin-1", []byte("caf\xe9\n"), charsetGuess("latin-1", 0.6, false)},
+        {"windows-1252 quotes", []byte("\x93hi\x94 caf\xe9\n"), charsetGuess("windows-1252", 0.6, false)},
+        {"BOM-less utf-16le", []byte("a\x00,\x00b\x00\n\x00"), charsetGuess("utf-16le", 0.8, false)},
+        {"BOM-less utf-16be", []byte("\x00a\x00,\x00b\x00\n"), charsetGuess("utf-16be", 0.8, false)},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := guessCharset(tt.data); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("guessCharset = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestGuessCharsetSplitSequence(t *testing.T) {
+    // A two-byte character straddling the end of the sample is not taken for latin-1.
+    data := append(bytes.Repeat([]byte("a"), detectSampleBytes-1), "é\n"...)
+    if got := guessCharset(data)["charset"]; got != "utf-8" {
+        t.Errorf("charset = %v; want utf-8", got)
+    }
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+    "encoding/csv"
+    "errors"
+    "fmt"
+)
+
+// Error codes returned to JavaScript under "code" so callers can branch on the kind of failure.
+const (
+    codeParseError  = "parse_error"
+    codeBadArgument = "bad_argument"
+    codeUnsupported = "unsupported"
+    codeInternal    = "internal"
+    codePanic       = "panic"
+    codeCancelled   = "cancelled"
+)
+
+// errorResult builds the error map every wrapper returns. "error" repeats the message
+// for callers written before codes existed; wrappers may add a "detail" field.
+func errorResult(code, msg string) map[string]any {
+    return map[string]any{
+        "error":   msg,
+        "code":    code,
+        "message": msg,
+    }
+}
+
+// codedError tags an error from a core helper with the code it should surface as.
+type codedError struct {
+    code string
+    err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+
+func (e *codedError) Unwrap() error { return e.err }
+
+// badArgument returns an error reported to JavaScript with the bad_argument code.
+func badArgument(format string, args ...any) error {
+    return &codedError{code: codeBadArgument, err: fmt.Errorf(format, args...)}
+}
+
+// errorMap renders err as the error map returned to JavaScript. CSV parse errors
+// carry the 1-based line and column of the problem so callers can highlight it, with
+// "message" holding the bare reason; coded errors keep their code.
+func errorMap(err error) map[string]any {
+    var parseErr *csv.ParseError
+    if errors.As(err, &parseErr) {
+        result := errorResult(codeParseError, err.Error())
+        result["message"] = parseErr.Err.Error()
+        result["errorLine"] = parseErr.Line
+        result["errorColumn"] = parseErr.Column
+        result["detail"] = map[string]any{"line": parseErr.Line, "column": parseErr.Column}
+        return result
+    }
+    var coded *codedError
+    if errors.As(err, &coded) {
+        return errorResult(coded.code, err.Error())
+    }
+    return errorResult(codeInternal, err.Error())
+}
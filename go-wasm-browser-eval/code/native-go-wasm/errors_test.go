@@ -0,0 +1,29 @@
+package main
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestErrorMapParseErrorLocation(t *testing.T) {
+    _, err := summaryFromCSV("a,b\n1,2\n3,4,5\n", csvOptions{Strict: true})
+    if err == nil {
+        t.Fatal("want an error for a row with an extra field")
+    }
+    m := errorMap(err)
+    if m["code"] != codeParseError || m["errorLine"] != 3 || m["errorColumn"] != 1 {
+        t.Errorf("got code %v line %v column %v; want parse_error at 3:1", m["code"], m["errorLine"], m["errorColumn"])
+    }
+    if m["message"] != "wrong number of fields" {
+        t.Errorf("message = %q; want the bare reason", m["message"])
+    }
+}
+
+func TestErrorMapCodes(t *testing.T) {
+    if got := errorMap(badArgument("bad %d", 1)); got["code"] != codeBadArgument || got["message"] != "bad 1" {
+        t.Errorf("badArgument maps to %v", got)
+    }
+    if got := errorMap(errors.New("boom")); got["code"] != codeInternal || got["error"] != "boom" {
+        t.Errorf("plain error maps to %v", got)
+    }
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+    "encoding/csv"
+    "errors"
+    "io"
+    "unicode/utf8"
+)
+
+// errFieldTooLarge is wrapped in the *csv.ParseError reported when a field exceeds
+// csvOptions.MaxFieldBytes.
+var errFieldTooLarge = errors.New("field exceeds maxFieldBytes")
+
+// fieldLimitReader sits between the payload and csv.Reader and fails the read as soon as
+// one field grows past limit bytes, so a single huge quoted field is rejected before
+// csv.Reader buffers all of it. It tracks quoting itself rather than relying on csv
+// internals: a doubled "" toggles the state twice and so needs no special case.
+type fieldLimitReader struct {
+    r     io.Reader
+    limit int
+    delim []byte
+    // matched counts how many bytes of a multibyte delimiter have been seen in a row.
+    matched int
+    quoted  bool
+    field   int
+    // line is the current physical line, start the line the current record began on,
+    // and column the 1-based byte offset within line.
+    line, start, column int
+}
+
+// newFieldLimitReader wraps r so no field may exceed limit bytes.
+func newFieldLimitReader(r io.Reader, limit int, delimiter rune) *fieldLimitReader {
+    if delimiter == 0 {
+        delimiter = ','
+    }
+    return &fieldLimitReader{r: r, limit: limit, delim: utf8.AppendRune(nil, delimiter), line: 1, start: 1}
+}
+
+// Read implements io.Reader, scanning each chunk for field and record boundaries.
+func (f *fieldLimitReader) Read(p []byte) (int, error) {
+    n, err := f.r.Read(p)
+    for _, b := range p[:n] {
+        f.column++
+        switch {
+        case b == '"':
+            f.quoted = !f.quoted
+            f.field++
+        case b == '\n':
+            f.line++
+            f.column = 0
+            if f.quoted {
+                f.field++
+            } else {
+                f.field = 0
+                f.start = f.line
+            }
+        case !f.quoted && b == f.delim[f.matched]:
+            f.matched++
+            if f.matched == len(f.delim) {
+                f.matched = 0
+                f.field = 0
+            }
+            continue
+        default:
+            f.field++
+        }
+        f.matched = 0
+        if f.field > f.limit {
+            return 0, &csv.ParseError{StartLine: f.start, Line: f.line, Column: f.column, Err: errFieldTooLarge}
+        }
+    }
+    return n, err
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+    "errors"
+    "strings"
+    "testing"
+)
+
+func TestSummaryMaxFieldBytes(t *testing.T) {
+    tests := []struct {
+        name  string
+        field string
+        fails bool
+    }{
+        {"just under", strings.Repeat("x", 9), false},
+        {"at the limit", strings.Repeat("x", 10), false},
+        {"one over", strings.Repeat("x", 11), true},
+        {"quoted with delimiters inside", "\"" + strings.Repeat(",", 20) + "\"", true},
+        {"quoted under", "\"a,b\nc\"", false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            text := "a,b\n1,2\n3," + tt.field + "\n"
+            _, err := summaryFromCSV(text, csvOptions{MaxFieldBytes: 10})
+            if !tt.fails {
+                if err != nil {
+                    t.Fatalf("unexpected error: %v", err)
+                }
+                return
+            }
+            if !errors.Is(err, errFieldTooLarge) {
+                t.Fatalf("err = %v; want errFieldTooLarge", err)
+            }
+            if m := errorMap(err); m["code"] != codeParseError || m["errorLine"] != 3 {
+                t.Errorf("got %v; want a parse_error on line 3", m)
+            }
+        })
+    }
+}
+
+func TestSummaryMaxFieldBytesDisabled(t *testing.T) {
+    text := "a\n" + strings.Repeat("x", 1<<16) + "\n"
+    if _, err := summaryFromCSV(text, csvOptions{}); err != nil {
+        t.Errorf("zero maxFieldBytes should not limit fields: %v", err)
+    }
+}
+
+func TestFieldLimitReaderMultibyteDelimiter(t *testing.T) {
+    // With a multibyte delimiter, each field is measured on its own.
+    text := "aaaa§bbbb§cccc\n"
+    if _, err := summaryFromCSV(text, csvOptions{Delimiter: '§', MaxFieldBytes: 4}); err != nil {
+        t.Errorf("fields of 4 bytes rejected: %v", err)
+    }
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+    "regexp"
+    "slices"
+    "strings"
+)
+
+// cellMatcher reports whether a single cell satisfies a predicate.
+type cellMatcher func(value string) bool
+
+// newCellMatcher builds the predicate for op ("equals", "contains", "startsWith" or
+// "regex") against value. With ignoreCase the comparison folds case, and regex patterns
+// are compiled with the (?i) flag.
+func newCellMatcher(op, value string, ignoreCase bool) (cellMatcher, error) {
+    if op == "regex" {
+        pattern := value
+        if ignoreCase {
+            pattern = "(?i)" + pattern
+        }
+        re, err := regexp.Compile(pattern)
+        if err != nil {
+            return nil, badArgument("invalid regex: %v", err)
+        }
+        return re.MatchString, nil
+    }
+    fold := func(s string) string { return s }
+    if ignoreCase {
+        fold = strings.ToLower
+    }
+    want := fold(value)
+    switch op {
+    case "equals":
+        if ignoreCase {
+            return func(s string) bool { return strings.EqualFold(s, value) }, nil
+        }
+        return func(s string) bool { return s == value }, nil
+    case "contains":
+        return func(s string) bool { return strings.Contains(fold(s), want) }, nil
+    case "startsWith":
+        return func(s string) bool { return strings.HasPrefix(fold(s), want) }, nil
+    }
+    return nil, badArgument("unknown operator %q (want equals, contains, startsWith or regex)", op)
+}
+
+// countWhere counts the rows whose cell in col satisfies op against value. Rows too short
+// to reach col are tested as an empty cell.
+func countWhere(rows [][]string, col int, op, value string, ignoreCase bool) (int, error) {
+    match, err := newCellMatcher(op, value, ignoreCase)
+    if err != nil {
+        return 0, err
+    }
+    count := 0
+    for _, row := range rows {
+        if match(cell(row, col)) {
+            count++
+        }
+    }
+    return count, nil
+}
+
+// filterRows re-encodes csvText keeping only the data rows whose cell in col satisfies op
+// against value, or those that do not when opts.Negate is set. The header row (when
+// opts.HasHeader is set) is always kept, even if no rows match.
+func filterRows(csvText string, col int, op, value string, opts csvOptions) (string, error) {
+    match, err := newCellMatcher(op, value, opts.IgnoreCase)
+    if err != nil {
+        return "", err
+    }
+    header, rows, lines, err := splitHeaderLines(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return "", err
+    }
+    return keepRows(header, rows, lines, func(row []string) bool {
+        return match(cell(row, col)) != opts.Negate
+    }, opts)
+}
+
+// keepRows encodes header (when not nil) and the rows keep accepts. With
+// opts.LineNumbers each kept row is prefixed with its physical line from lines, and the
+// header with lineColumn, so filtered rows can be traced back to the input.
+func keepRows(header []string, rows [][]string, lines []int, keep func(row []string) bool, opts csvOptions) (string, error) {
+    kept := [][]string{}
+    if header != nil {
+        if opts.LineNumbers {
+            header = append([]string{lineColumn}, header...)
+        }
+        kept = append(kept, header)
+    }
+    for r, row := range rows {
+        if !keep(row) {
+            continue
+        }
+        if opts.LineNumbers {
+            row = withLine(row, lines[r])
+        }
+        kept = append(kept, row)
+    }
+    return encodeCSV(kept, false)
+}
+
+// searchAnyColumn re-encodes csvText keeping only the data rows with at least one cell
+// containing query, or matching it as a regular expression when regex is set. With
+// caseInsensitive the comparison folds case. The header row (when opts.HasHeader is set)
+// is always kept, so a search with no hits returns just the header.
+func searchAnyColumn(csvText, query string, caseInsensitive, regex bool, opts csvOptions) (string, error) {
+    op := "contains"
+    if regex {
+        op = "regex"
+    }
+    match, err := newCellMatcher(op, query, caseInsensitive)
+    if err != nil {
+        return "", err
+    }
+    header, rows, lines, err := splitHeaderLines(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    return keepRows(header, rows, lines, func(row []string) bool {
+        return slices.ContainsFunc(row, match)
+    }, opts)
+}
@@ -0,0 +1,143 @@
+package main
+
+import "testing"
+
+func TestCountWhere(t *testing.T) {
+    rows := [][]string{{"active"}, {"Active"}, {"inactive"}, {"archived"}, {}}
+    tests := []struct {
+        op, value  string
+        ignoreCase bool
+        want       int
+    }{
+        {"equals", "active", false, 1},
+        {"equals", "active", true, 2},
+        {"equals", "", false, 1},
+        {"contains", "active", false, 2},
+        {"contains", "ACT", true, 3},
+        {"startsWith", "a", false, 2},
+        {"startsWith", "A", true, 3},
+        {"regex", "^(in)?active$", false, 2},
+        {"regex", "^a", true, 3},
+    }
+    for _, tt := range tests {
+        got, err := countWhere(rows, 0, tt.op, tt.value, tt.ignoreCase)
+        if err != nil {
+            t.Fatalf("%s %q: %v", tt.op, tt.value, err)
+        }
+        if got != tt.want {
+            t.Errorf("%s %q (ignoreCase %v) = %d; want %d", tt.op, tt.value, tt.ignoreCase, got, tt.want)
+        }
+    }
+}
+
+func TestCountWhereErrors(t *testing.T) {
+    tests := []struct {
+        op, value, message string
+    }{
+        {"regex", "(", "invalid regex: error parsing regexp: missing closing ): `(`"},
+        {"like", "x", `unknown operator "like" (want equals, contains, startsWith or regex)`},
+    }
+    for _, tt := range tests {
+        _, err := countWhere([][]string{{"x"}}, 0, tt.op, tt.value, false)
+        if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.message {
+            t.Errorf("%s %q: got %v; want bad_argument %q", tt.op, tt.value, m, tt.message)
+        }
+    }
+}
+
+func TestFilterRows(t *testing.T) {
+    const text = "name,status\nann,active\nbob,inactive\ncy,Active\n"
+    tests := []struct {
+        name      string
+        col       int
+        op, value string
+        opts      csvOptions
+        want      string
+    }{
+        {"keep matches", 1, "equals", "active", csvOptions{HasHeader: true}, "name,status\nann,active\n"},
+        {"negate drops matches", 1, "equals", "active", csvOptions{HasHeader: true, Negate: true}, "name,status\nbob,inactive\ncy,Active\n"},
+        {"ignore case", 1, "equals", "ACTIVE", csvOptions{HasHeader: true, IgnoreCase: true}, "name,status\nann,active\ncy,Active\n"},
+        {"header kept with no matches", 1, "equals", "zzz", csvOptions{HasHeader: true}, "name,status\n"},
+        {"without a header the first row is data", 0, "startsWith", "n", csvOptions{}, "name,status\n"},
+        {"negate without a header", 0, "startsWith", "n", csvOptions{Negate: true}, "ann,active\nbob,inactive\ncy,Active\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := filterRows(text, tt.col, tt.op, tt.value, tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("filterRows = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestFilterRowsErrors(t *testing.T) {
+    if _, err := filterRows("a\n1\n", 1, "equals", "1", csvOptions{}); errorMap(err)["code"] != codeBadArgument {
+        t.Errorf("out-of-range column: %v; want bad_argument", err)
+    }
+    if _, err := filterRows("a\n1\n", 0, "regex", "[", csvOptions{}); errorMap(err)["code"] != codeBadArgument {
+        t.Errorf("bad regex: %v; want bad_argument", err)
+    }
+}
+
+func TestSearchAnyColumn(t *testing.T) {
+    const text = "level,msg\ninfo,ok\nERROR,disk full\nwarn,an error occurred\ninfo,done\n"
+    tests := []struct {
+        name            string
+        query           string
+        caseInsensitive bool
+        regex           bool
+        want            string
+    }{
+        {"substring", "error", false, false, "level,msg\nwarn,an error occurred\n"},
+        {"case-insensitive", "error", true, false, "level,msg\nERROR,disk full\nwarn,an error occurred\n"},
+        {"no match keeps the header", "panic", true, false, "level,msg\n"},
+        {"regex", `^(ok|done)$`, false, true, "level,msg\ninfo,ok\ninfo,done\n"},
+        {"case-insensitive regex", `^error$`, true, true, "level,msg\nERROR,disk full\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := searchAnyColumn(text, tt.query, tt.caseInsensitive, tt.regex, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("searchAnyColumn(%q) = %q; want %q", tt.query, got, tt.want)
+            }
+        })
+    }
+    if _, err := searchAnyColumn(text, "(", false, true, csvOptions{HasHeader: true}); err == nil {
+        t.Error("invalid regex accepted")
+    }
+}
+
+func TestFilterRowsLineNumbers(t *testing.T) {
+    // The comment and the two-line quoted field push the matching rows to lines 5 and 6.
+    const text = "# export\nlevel,msg\ninfo,\"a\nb\"\nerror,x\nerror,y\n"
+    opts := csvOptions{HasHeader: true, Comment: '#', LineNumbers: true}
+    got, err := filterRows(text, 0, "equals", "error", opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "__line__,level,msg\n5,error,x\n6,error,y\n"; got != want {
+        t.Errorf("filterRows = %q; want %q", got, want)
+    }
+    got, err = searchAnyColumn(text, "b", false, false, opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "__line__,level,msg\n3,info,\"a\nb\"\n"; got != want {
+        t.Errorf("searchAnyColumn = %q; want %q", got, want)
+    }
+    opts.LineNumbers = false
+    got, err = filterRows(text, 0, "equals", "error", opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "level,msg\nerror,x\nerror,y\n"; got != want {
+        t.Errorf("filterRows without lineNumbers = %q; want %q", got, want)
+    }
+}
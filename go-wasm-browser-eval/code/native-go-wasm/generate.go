@@ -0,0 +1,48 @@
+package main
+
+import (
+    "math/rand/v2"
+    "strconv"
+    "strings"
+)
+
+// generatedWords is the vocabulary for the text columns of generateCSV.
+var generatedWords = []string{
+    "alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel",
+    "india", "juliett", "kilo", "lima", "mike", "november", "oscar", "papa",
+}
+
+// generateCSV emits a header col_0..col_{cols-1} and rows of pseudo-random data for demos
+// and load tests. Columns cycle through integers, two-decimal floats and words, and the
+// same seed always produces byte-identical output.
+func generateCSV(rows, cols int, seed int64) (string, error) {
+    if rows < 0 || cols < 1 {
+        return "", badArgument("need a non-negative row count and at least one column, got %d rows and %d columns", rows, cols)
+    }
+    rng := rand.New(rand.NewPCG(uint64(seed), 0))
+    var out strings.Builder
+    for c := range cols {
+        if c > 0 {
+            out.WriteByte(',')
+        }
+        out.WriteString("col_" + strconv.Itoa(c))
+    }
+    out.WriteByte('\n')
+    for range rows {
+        for c := range cols {
+            if c > 0 {
+                out.WriteByte(',')
+            }
+            switch c % 3 {
+            case 0:
+                out.WriteString(strconv.Itoa(rng.IntN(1_000_000)))
+            case 1:
+                out.WriteString(strconv.FormatFloat(rng.Float64()*1000, 'f', 2, 64))
+            default:
+                out.WriteString(generatedWords[rng.IntN(len(generatedWords))])
+            }
+        }
+        out.WriteByte('\n')
+    }
+    return out.String(), nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestGenerateCSVDeterministic(t *testing.T) {
+    a, err := generateCSV(1000, 5, 42)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if b, _ := generateCSV(1000, 5, 42); b != a {
+        t.Error("same seed produced different output")
+    }
+    if c, _ := generateCSV(1000, 5, 43); c == a {
+        t.Error("different seeds produced identical output")
+    }
+    rows, err := readAllRecords(a, csvOptions{Strict: true})
+    if err != nil {
+        t.Fatalf("generated CSV does not parse as rectangular: %v", err)
+    }
+    if len(rows) != 1001 || len(rows[0]) != 5 {
+        t.Errorf("got %d records of %d fields; want 1001 of 5", len(rows), len(rows[0]))
+    }
+    if want := []string{"col_0", "col_1", "col_2", "col_3", "col_4"}; !reflect.DeepEqual(rows[0], want) {
+        t.Errorf("header = %v; want %v", rows[0], want)
+    }
+    // Columns cycle integer, float, word.
+    types := inferColumnTypes(rows[1:])
+    if want := []string{typeInteger, typeFloat, typeString, typeInteger, typeFloat}; !reflect.DeepEqual(types, want) {
+        t.Errorf("column types = %v; want %v", types, want)
+    }
+}
+
+func TestGenerateCSVBounds(t *testing.T) {
+    text, err := generateCSV(0, 2, 1)
+    if err != nil || text != "col_0,col_1\n" {
+        t.Errorf("zero rows = %q, %v; want the header alone", text, err)
+    }
+    for _, size := range [][2]int{{-1, 2}, {3, 0}} {
+        if _, err := generateCSV(size[0], size[1], 1); err == nil {
+            t.Errorf("generateCSV(%d, %d) accepted", size[0], size[1])
+        }
+    }
+}
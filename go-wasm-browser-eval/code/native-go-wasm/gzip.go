@@ -0,0 +1,18 @@
+package main
+
+import (
+    "bytes"
+    "compress/gzip"
+    "fmt"
+)
+
+// summaryFromGzipCSV decompresses a gzipped CSV payload and summarizes it through the
+// streaming path, so the inflated text is never held in memory as a whole.
+func summaryFromGzipCSV(data []byte, opts csvOptions) (map[string]any, error) {
+    zr, err := gzip.NewReader(bytes.NewReader(data))
+    if err != nil {
+        return nil, &codedError{code: codeParseError, err: fmt.Errorf("invalid gzip data: %w", err)}
+    }
+    defer zr.Close()
+    return summarizeStream(zr, opts)
+}
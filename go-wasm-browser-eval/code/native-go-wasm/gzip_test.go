@@ -0,0 +1,49 @@
+package main
+
+import (
+    "bytes"
+    "compress/gzip"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// gzipped compresses text the way a browser's CompressionStream("gzip") would.
+func gzipped(t *testing.T, text string) []byte {
+    t.Helper()
+    var buf bytes.Buffer
+    zw := gzip.NewWriter(&buf)
+    if _, err := zw.Write([]byte(text)); err != nil {
+        t.Fatal(err)
+    }
+    if err := zw.Close(); err != nil {
+        t.Fatal(err)
+    }
+    return buf.Bytes()
+}
+
+func TestSummaryFromGzipCSV(t *testing.T) {
+    text := "name,score\nann,3\nbob,4.5\n" + strings.Repeat("cy,1\n", 100)
+    opts := csvOptions{HasHeader: true}
+    got, err := summaryFromGzipCSV(gzipped(t, text), opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    want, err := summaryFromCSV(text, opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("gzip summary %v differs from plain summary %v", got, want)
+    }
+}
+
+func TestSummaryFromGzipCSVInvalidHeader(t *testing.T) {
+    _, err := summaryFromGzipCSV([]byte("name,score\n"), csvOptions{})
+    if err == nil {
+        t.Fatal("plain text accepted as gzip")
+    }
+    if m := errorMap(err); m["code"] != codeParseError || !strings.HasPrefix(m["message"].(string), "invalid gzip data") {
+        t.Errorf("got %v; want a parse_error about invalid gzip data", m)
+    }
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+    "crypto/sha256"
+    "fmt"
+    "hash"
+    "hash/crc32"
+    "hash/fnv"
+    "math"
+    "strings"
+)
+
+// newChecksum returns the incremental hash behind a checksum option name: "crc32"
+// (IEEE) or "sha256". An empty name means no checksum and yields nil.
+func newChecksum(name string) hash.Hash {
+    switch name {
+    case "crc32":
+        return crc32.NewIEEE()
+    case "sha256":
+        return sha256.New()
+    default:
+        return nil
+    }
+}
+
+// maxMinhashes bounds the signature length wasmMinhash will compute.
+const maxMinhashes = 1024
+
+// mix64 is the splitmix64 finalizer, used to derive one independent hash per seed from
+// a single FNV-1a hash of the value.
+func mix64(x uint64) uint64 {
+    x ^= x >> 30
+    x *= 0xbf58476d1ce4e5b9
+    x ^= x >> 27
+    x *= 0x94d049bb133111eb
+    return x ^ x>>31
+}
+
+// minhashColumn returns the minhash signature of the distinct non-empty values of col:
+// for each of numHashes seeded hash functions, the smallest hash over the values. The
+// share of positions where two signatures agree estimates the Jaccard similarity of the
+// two value sets, with error around 1/sqrt(numHashes). Seeds are fixed, so signatures
+// from different files and sessions are comparable.
+func minhashColumn(rows [][]string, col, numHashes int) ([]uint64, error) {
+    if numHashes < 1 || numHashes > maxMinhashes {
+        return nil, badArgument("numHashes must be between 1 and %d, got %d", maxMinhashes, numHashes)
+    }
+    signature := make([]uint64, numHashes)
+    for i := range signature {
+        signature[i] = math.MaxUint64
+    }
+    empty := true
+    for _, row := range rows {
+        value := cell(row, col)
+        if value == "" {
+            continue
+        }
+        empty = false
+        h := fnv.New64a()
+        h.Write([]byte(value))
+        base := h.Sum64()
+        for i := range signature {
+            signature[i] = min(signature[i], mix64(base^mix64(uint64(i)+1)))
+        }
+    }
+    if empty {
+        return nil, badArgument("column %d has no values", col)
+    }
+    return signature, nil
+}
+
+// rowHashes maps the key of each data row (its keyCols cells, written as a CSV record
+// without the line ending so multi-column keys stay unambiguous) to the 16-digit hex
+// FNV-1a hash of the whole row, so a client can tell which rows changed between two
+// versions of a file. The header (when opts.HasHeader is set) is skipped. Keys must be
+// unique: a repeated key is a bad_argument error.
+func rowHashes(csvText string, keyCols []int, opts csvOptions) (map[string]string, error) {
+    if len(keyCols) == 0 {
+        return nil, badArgument("expected at least one key column")
+    }
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return nil, err
+    }
+    width := max(len(header), tableWidth(rows))
+    for _, col := range keyCols {
+        if err := checkColumn(col, width); err != nil {
+            return nil, err
+        }
+    }
+    hashes := make(map[string]string, len(rows))
+    keyCells := make([]string, len(keyCols))
+    for _, row := range rows {
+        for i, col := range keyCols {
+            keyCells[i] = cell(row, col)
+        }
+        key, err := encodeRecord(keyCells, opts)
+        if err != nil {
+            return nil, err
+        }
+        key = strings.TrimSuffix(key, "\n")
+        if _, dup := hashes[key]; dup {
+            return nil, badArgument("key %q appears on more than one row", key)
+        }
+        h := fnv.New64a()
+        h.Write([]byte(rowKey(row, nil)))
+        hashes[key] = fmt.Sprintf("%016x", h.Sum64())
+    }
+    return hashes, nil
+}
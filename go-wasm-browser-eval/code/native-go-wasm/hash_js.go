@@ -0,0 +1,79 @@
+//go:build js && wasm
+
+package main
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "syscall/js"
+)
+
+// wrapSHA256 returns the lowercase hex SHA-256 digest of a string (hashed as UTF-8) or
+// a Uint8Array: wasmSHA256(value).
+func wrapSHA256(this js.Value, args []js.Value) any {
+    if isMissing(args, 0) {
+        return errorResult(codeBadArgument, "expected a string or Uint8Array")
+    }
+    var data []byte
+    if args[0].Type() == js.TypeString {
+        data = []byte(args[0].String())
+    } else {
+        var err error
+        if data, err = bytesArg(args, 0); err != nil {
+            return errorResult(codeBadArgument, "expected a string or Uint8Array")
+        }
+    }
+    sum := sha256.Sum256(data)
+    return hex.EncodeToString(sum[:])
+}
+
+// wrapMinhash exposes minhashColumn to JavaScript as wasmMinhash(text, col, numHashes,
+// options?). JS numbers cannot hold a uint64, so each position is a 16-digit hex string.
+func wrapMinhash(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column and a hash count")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    col := args[1].Int()
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    signature, err := minhashColumn(rows, col, args[2].Int())
+    if err != nil {
+        return errorMap(err)
+    }
+    out := make([]string, len(signature))
+    for i, h := range signature {
+        out[i] = fmt.Sprintf("%016x", h)
+    }
+    return toJS(out)
+}
+
+// wrapRowHashes exposes rowHashes to JavaScript as wasmRowHashes(text, keyCols, options?),
+// returning {key: hash}.
+func wrapRowHashes(this js.Value, args []js.Value) any {
+    if len(args) < 2 || args[1].Type() != js.TypeObject {
+        return errorResult(codeBadArgument, "expected a CSV string and an array of key columns")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    hashes, err := rowHashes(args[0].String(), intsArg(args, 1), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    out := make(map[string]any, len(hashes))
+    for key, h := range hashes {
+        out[key] = h
+    }
+    return out
+}
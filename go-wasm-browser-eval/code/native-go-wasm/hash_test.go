@@ -0,0 +1,119 @@
+package main
+
+import (
+    "fmt"
+    "reflect"
+    "testing"
+)
+
+// idColumn returns n rows holding prefix0 through prefix<n-1> in a single column.
+func idColumn(prefix string, n int) [][]string {
+    rows := make([][]string, n)
+    for i := range rows {
+        rows[i] = []string{fmt.Sprint(prefix, i)}
+    }
+    return rows
+}
+
+func TestMinhashColumn(t *testing.T) {
+    const hashes = 128
+    base, err := minhashColumn(idColumn("user-", 100), 0, hashes)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // The same set in another order, with a repeat and a blank, is the same set.
+    shuffled := idColumn("user-", 100)
+    for i, j := 0, len(shuffled)-1; i < j; i, j = i+1, j-1 {
+        shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+    }
+    shuffled = append(shuffled, []string{"user-7"}, []string{""})
+    same, err := minhashColumn(shuffled, 0, hashes)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(same, base) {
+        t.Error("identical value sets produced different signatures")
+    }
+    // Swapping one value out of 100 leaves a Jaccard similarity of 99/101, so only a
+    // handful of the 128 positions should move.
+    edited := idColumn("user-", 100)
+    edited[42][0] = "someone-else"
+    other, err := minhashColumn(edited, 0, hashes)
+    if err != nil {
+        t.Fatal(err)
+    }
+    changed := 0
+    for i := range base {
+        if base[i] != other[i] {
+            changed++
+        }
+    }
+    if changed == 0 || changed > hashes/8 {
+        t.Errorf("a one-value edit changed %d of %d positions", changed, hashes)
+    }
+}
+
+func TestMinhashColumnErrors(t *testing.T) {
+    tests := []struct {
+        rows  [][]string
+        count int
+        msg   string
+    }{
+        {idColumn("x", 3), 0, "numHashes must be between 1 and 1024, got 0"},
+        {idColumn("x", 3), maxMinhashes + 1, "numHashes must be between 1 and 1024, got 1025"},
+        {[][]string{{""}, {}}, 8, "column 0 has no values"},
+    }
+    for _, tt := range tests {
+        _, err := minhashColumn(tt.rows, 0, tt.count)
+        if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.msg {
+            t.Errorf("minhashColumn(%d hashes): got %v; want %q", tt.count, m, tt.msg)
+        }
+    }
+}
+
+func TestRowHashes(t *testing.T) {
+    before, err := rowHashes("id,name,city\n1,ann,oslo\n2,bob,rome\n3,cy,bern\n", []int{0}, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    after, err := rowHashes("id,name,city\n1,ann,oslo\n2,bob,ROME\n3,cy,bern\n", []int{0}, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(before) != 3 || len(after) != 3 {
+        t.Fatalf("hashes = %v and %v; want one per row", before, after)
+    }
+    for key := range before {
+        if changed := before[key] != after[key]; changed != (key == "2") {
+            t.Errorf("row %s: hash changed = %v", key, changed)
+        }
+    }
+    if h := before["1"]; len(h) != 16 {
+        t.Errorf("hash %q; want 16 hex digits", h)
+    }
+    // Multi-column keys are written as a CSV record, so a comma in a cell stays unambiguous.
+    got, err := rowHashes("a,b\n\"x,y\",z\nx,\"y,z\"\n", []int{0, 1}, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, ok := got[`"x,y",z`]; !ok || len(got) != 2 {
+        t.Errorf("multi-column keys = %v", got)
+    }
+}
+
+func TestRowHashesErrors(t *testing.T) {
+    tests := []struct {
+        key []int
+        msg string
+    }{
+        {nil, "expected at least one key column"},
+        {[]int{0}, `key "1" appears on more than one row`},
+        {[]int{2}, "column index 2 out of range (table has 2 columns)"},
+    }
+    for _, tt := range tests {
+        _, err := rowHashes("id,v\n1,a\n1,b\n", tt.key, csvOptions{HasHeader: true})
+        if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.msg {
+            t.Errorf("rowHashes(%v): got %v; want %q", tt.key, m, tt.msg)
+        }
+    }
+}
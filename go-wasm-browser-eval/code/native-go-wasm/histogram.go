@@ -0,0 +1,59 @@
+package main
+
+import (
+    "math"
+    "strconv"
+)
+
+// Bucket is one equal-width bin of a histogram, covering [Lo, Hi); the last bucket also
+// includes Hi so the column maximum is counted.
+type Bucket struct {
+    Lo    float64
+    Hi    float64
+    Count int
+}
+
+// toMap renders b for JavaScript.
+func (b Bucket) toMap() map[string]any {
+    return map[string]any{"lo": b.Lo, "hi": b.Hi, "count": b.Count}
+}
+
+// histogram bins the numeric cells of col into buckets equal-width ranges between the
+// column's min and max. Cells that are blank, non-numeric or non-finite are left out and
+// counted in skipped. When every value is the same a single bucket holds them all.
+func histogram(rows [][]string, col, buckets int) (bins []Bucket, skipped int, err error) {
+    if buckets <= 0 {
+        return nil, 0, badArgument("bucket count must be positive, got %d", buckets)
+    }
+    values := make([]float64, 0, len(rows))
+    for _, row := range rows {
+        x, err := strconv.ParseFloat(cell(row, col), 64)
+        if err != nil || math.IsInf(x, 0) || math.IsNaN(x) {
+            skipped++
+            continue
+        }
+        values = append(values, x)
+    }
+    if len(values) == 0 {
+        return []Bucket{}, skipped, nil
+    }
+    lo, hi := values[0], values[0]
+    for _, x := range values[1:] {
+        lo, hi = min(lo, x), max(hi, x)
+    }
+    if lo == hi {
+        return []Bucket{{Lo: lo, Hi: hi, Count: len(values)}}, skipped, nil
+    }
+    width := (hi - lo) / float64(buckets)
+    bins = make([]Bucket, buckets)
+    for i := range bins {
+        bins[i].Lo = lo + float64(i)*width
+        bins[i].Hi = lo + float64(i+1)*width
+    }
+    bins[buckets-1].Hi = hi
+    for _, x := range values {
+        i := min(int((x-lo)/width), buckets-1)
+        bins[i].Count++
+    }
+    return bins, skipped, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+    "reflect"
+    "strconv"
+    "testing"
+)
+
+func TestHistogramEven(t *testing.T) {
+    var rows [][]string
+    for i := 0; i <= 99; i++ {
+        rows = append(rows, []string{strconv.Itoa(i)})
+    }
+    rows = append(rows, []string{""}, []string{"n/a"}, []string{"NaN"})
+    bins, skipped, err := histogram(rows, 0, 4)
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []Bucket{
+        {Lo: 0, Hi: 24.75, Count: 25},
+        {Lo: 24.75, Hi: 49.5, Count: 25},
+        {Lo: 49.5, Hi: 74.25, Count: 25},
+        {Lo: 74.25, Hi: 99, Count: 25},
+    }
+    if !reflect.DeepEqual(bins, want) || skipped != 3 {
+        t.Errorf("histogram = %+v, skipped %d; want %+v, skipped 3", bins, skipped, want)
+    }
+}
+
+func TestHistogramEdgeCases(t *testing.T) {
+    tests := []struct {
+        name    string
+        rows    [][]string
+        want    []Bucket
+        skipped int
+    }{
+        {"single repeated value", [][]string{{"7"}, {"7"}, {"7"}}, []Bucket{{Lo: 7, Hi: 7, Count: 3}}, 0},
+        {"max lands in the last bucket", [][]string{{"0"}, {"1"}, {"2"}}, []Bucket{{Lo: 0, Hi: 1, Count: 1}, {Lo: 1, Hi: 2, Count: 2}}, 0},
+        {"no numbers", [][]string{{"x"}, {}}, []Bucket{}, 2},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            bins, skipped, err := histogram(tt.rows, 0, 2)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(bins, tt.want) || skipped != tt.skipped {
+                t.Errorf("histogram = %+v, skipped %d; want %+v, skipped %d", bins, skipped, tt.want, tt.skipped)
+            }
+        })
+    }
+    if _, _, err := histogram(nil, 0, 0); errorMap(err)["code"] != codeBadArgument {
+        t.Errorf("zero buckets: %v; want a bad_argument error", err)
+    }
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+    "strings"
+    "unicode"
+)
+
+// defaultMatchThreshold is the similarity below which matchHeaders leaves a header
+// unmatched.
+const defaultMatchThreshold = 0.6
+
+// joinCSV joins rightText into leftText where the leftKey and rightKey cells are equal.
+// The first record of each input is its header. Output rows are the left row followed by
+// the right row minus its key column; a left row matching several right rows is emitted
+// once per match. how is "inner" (drop unmatched left rows) or "left" (keep them with
+// empty right fields). Header names repeated across the two tables are made unique.
+func joinCSV(leftText, rightText string, leftKey, rightKey int, how string, opts csvOptions) (string, error) {
+    if how != "inner" && how != "left" {
+        return "", badArgument("unknown join %q (want inner or left)", how)
+    }
+    left, err := readAllRecords(leftText, opts)
+    if err != nil {
+        return "", err
+    }
+    right, err := readAllRecords(rightText, opts)
+    if err != nil {
+        return "", err
+    }
+    if len(left) == 0 || len(right) == 0 {
+        return "", badArgument("both tables need a header row")
+    }
+    leftWidth, rightWidth := tableWidth(left), tableWidth(right)
+    if err := checkColumn(leftKey, leftWidth); err != nil {
+        return "", err
+    }
+    if err := checkColumn(rightKey, rightWidth); err != nil {
+        return "", err
+    }
+
+    // withoutKey pads a right row to the table width and drops its key column.
+    withoutKey := func(row []string) []string {
+        out := make([]string, 0, rightWidth-1)
+        for i := range rightWidth {
+            if i != rightKey {
+                out = append(out, cell(row, i))
+            }
+        }
+        return out
+    }
+    padded := func(row []string) []string {
+        out := make([]string, leftWidth)
+        copy(out, row)
+        return out
+    }
+
+    matches := map[string][][]string{}
+    for _, row := range right[1:] {
+        key := cell(row, rightKey)
+        matches[key] = append(matches[key], withoutKey(row))
+    }
+    header := uniqueHeaders(append(padded(left[0]), withoutKey(right[0])...))
+    out := [][]string{header}
+    empty := make([]string, rightWidth-1)
+    for _, row := range left[1:] {
+        found := matches[cell(row, leftKey)]
+        if len(found) == 0 && how == "left" {
+            out = append(out, append(padded(row), empty...))
+        }
+        for _, extra := range found {
+            out = append(out, append(padded(row), extra...))
+        }
+    }
+    return encodeCSV(out, false)
+}
+
+// normalizeHeaderName lower-cases name and drops everything but letters and digits, so
+// "firstName", "first_name" and "First Name" compare equal.
+func normalizeHeaderName(name string) []rune {
+    var out []rune
+    for _, r := range strings.ToLower(name) {
+        if unicode.IsLetter(r) || unicode.IsDigit(r) {
+            out = append(out, r)
+        }
+    }
+    return out
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b []rune) int {
+    prev := make([]int, len(b)+1)
+    curr := make([]int, len(b)+1)
+    for j := range prev {
+        prev[j] = j
+    }
+    for i := 1; i <= len(a); i++ {
+        curr[0] = i
+        for j := 1; j <= len(b); j++ {
+            cost := 1
+            if a[i-1] == b[j-1] {
+                cost = 0
+            }
+            curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+        }
+        prev, curr = curr, prev
+    }
+    return prev[len(b)]
+}
+
+// headerSimilarity scores two header names from 0 to 1 as one minus their Levenshtein
+// distance over the longer length, after normalizeHeaderName. Two names that normalize to
+// nothing score 0.
+func headerSimilarity(a, b string) float64 {
+    na, nb := normalizeHeaderName(a), normalizeHeaderName(b)
+    longest := max(len(na), len(nb))
+    if longest == 0 {
+        return 0
+    }
+    return 1 - float64(levenshtein(na, nb))/float64(longest)
+}
+
+// matchHeaders pairs each header in a with its most similar header in b, preferring the
+// earlier one on a tie. Matches scoring below threshold are reported with a nil match and
+// the best score found. Several headers in a may match the same header in b.
+func matchHeaders(a, b []string, threshold float64) ([]map[string]any, error) {
+    if threshold < 0 || threshold > 1 {
+        return nil, badArgument("threshold must be between 0 and 1, got %v", threshold)
+    }
+    out := make([]map[string]any, len(a))
+    for i, name := range a {
+        best, score := -1, 0.0
+        for j, candidate := range b {
+            if s := headerSimilarity(name, candidate); best < 0 || s > score {
+                best, score = j, s
+            }
+        }
+        var match any
+        if best >= 0 && score >= threshold {
+            match = b[best]
+        }
+        out[i] = map[string]any{"header": name, "match": match, "score": score}
+    }
+    return out, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestJoinCSV(t *testing.T) {
+    const left = "id,name\n1,ann\n2,bob\n3,cy\n"
+    const right = "uid,city,name\n1,oslo,A\n1,rome,B\n3,paris,C\n"
+    tests := []struct {
+        how  string
+        want string
+    }{
+        {"inner", "id,name,city,name_2\n1,ann,oslo,A\n1,ann,rome,B\n3,cy,paris,C\n"},
+        {"left", "id,name,city,name_2\n1,ann,oslo,A\n1,ann,rome,B\n2,bob,,\n3,cy,paris,C\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.how, func(t *testing.T) {
+            got, err := joinCSV(left, right, 0, 0, tt.how, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("joinCSV = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestJoinCSVErrors(t *testing.T) {
+    if _, err := joinCSV("a\n1\n", "a\n1\n", 0, 0, "outer", csvOptions{}); errorMap(err)["message"] != `unknown join "outer" (want inner or left)` {
+        t.Errorf("outer join: %v", err)
+    }
+    if _, err := joinCSV("a\n1\n", "a\n1\n", 0, 4, "inner", csvOptions{}); errorMap(err)["code"] != codeBadArgument {
+        t.Errorf("right key out of range: %v; want bad_argument", err)
+    }
+}
+
+func TestMatchHeaders(t *testing.T) {
+    a := []string{"id", "firstName", "customerId", "zzz"}
+    b := []string{"first_name", "ID", "customer_no", "email"}
+    got, err := matchHeaders(a, b, 0.75)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // customerid and customerno differ in two of ten letters.
+    want := []map[string]any{
+        {"header": "id", "match": "ID", "score": 1.0},
+        {"header": "firstName", "match": "first_name", "score": 1.0},
+        {"header": "customerId", "match": "customer_no", "score": 0.8},
+        {"header": "zzz", "match": nil, "score": 0.0},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("matchHeaders = %v; want %v", got, want)
+    }
+    got, err = matchHeaders([]string{"customerId"}, b, 0.9)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got[0]["match"] != nil || got[0]["score"] != 0.8 {
+        t.Errorf("below the threshold = %v; want no match with score 0.8", got[0])
+    }
+    if _, err := matchHeaders(a, b, 1.5); err == nil {
+        t.Error("threshold 1.5 accepted")
+    }
+}
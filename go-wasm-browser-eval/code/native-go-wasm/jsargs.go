@@ -0,0 +1,383 @@
+//go:build js && wasm
+
+package main
+
+import (
+    "errors"
+    "fmt"
+    "strconv"
+    "syscall/js"
+    "unicode/utf8"
+)
+
+// errBadDelimiter is returned when a caller passes more (or less) than one character as a delimiter.
+var errBadDelimiter = errors.New("delimiter must be a single character")
+
+// errBadComment rejects a comment option that is not exactly one character.
+var errBadComment = errors.New("comment must be a single character")
+
+// errBadDecimal rejects a decimalSeparator option that is not exactly one character.
+var errBadDecimal = errors.New("decimal separator must be a single character")
+
+// errBadPad rejects a wasmCSVToFixedWidth pad argument that is not exactly one character.
+var errBadPad = errors.New("pad must be a single character")
+
+// errBadMask rejects a wasmRedactColumns mask argument that is not exactly one character.
+var errBadMask = errors.New("mask must be a single character")
+
+// toJS converts Go values that js.ValueOf cannot handle (typed slices) into []any,
+// recursing into maps so nested results marshal cleanly.
+func toJS(v any) any {
+    switch value := v.(type) {
+    case []string:
+        out := make([]any, len(value))
+        for i, s := range value {
+            out[i] = s
+        }
+        return out
+    case []int:
+        out := make([]any, len(value))
+        for i, n := range value {
+            out[i] = n
+        }
+        return out
+    case []float64:
+        out := make([]any, len(value))
+        for i, x := range value {
+            out[i] = x
+        }
+        return out
+    case map[string]int:
+        out := make(map[string]any, len(value))
+        for k, n := range value {
+            out[k] = n
+        }
+        return out
+    case map[string][]int:
+        out := make(map[string]any, len(value))
+        for k, cols := range value {
+            out[k] = toJS(cols)
+        }
+        return out
+    case map[string][]string:
+        out := make(map[string]any, len(value))
+        for k, values := range value {
+            out[k] = toJS(values)
+        }
+        return out
+    case [][]string:
+        out := make([]any, len(value))
+        for i, row := range value {
+            out[i] = toJS(row)
+        }
+        return out
+    case []map[string]string:
+        out := make([]any, len(value))
+        for i, record := range value {
+            obj := make(map[string]any, len(record))
+            for k, v := range record {
+                obj[k] = v
+            }
+            out[i] = obj
+        }
+        return out
+    case []map[string]any:
+        out := make([]any, len(value))
+        for i, record := range value {
+            out[i] = toJS(record)
+        }
+        return out
+    case []ColumnSchema:
+        out := make([]any, len(value))
+        for i, c := range value {
+            out[i] = c.toMap()
+        }
+        return out
+    case []RowError:
+        out := make([]any, len(value))
+        for i, e := range value {
+            out[i] = e.toMap()
+        }
+        return out
+    case []WordCount:
+        out := make([]any, len(value))
+        for i, w := range value {
+            out[i] = w.toMap()
+        }
+        return out
+    case []ValueCount:
+        out := make([]any, len(value))
+        for i, v := range value {
+            out[i] = v.toMap()
+        }
+        return out
+    case []Bucket:
+        out := make([]any, len(value))
+        for i, b := range value {
+            out[i] = b.toMap()
+        }
+        return out
+    case map[string]GroupSum:
+        out := make(map[string]any, len(value))
+        for k, g := range value {
+            out[k] = g.toMap()
+        }
+        return out
+    case map[int]Stats:
+        out := make(map[string]any, len(value))
+        for col, stats := range value {
+            out[strconv.Itoa(col)] = stats.toMap()
+        }
+        return out
+    case map[int]int:
+        out := make(map[string]any, len(value))
+        for k, n := range value {
+            out[strconv.Itoa(k)] = n
+        }
+        return out
+    case map[string]any:
+        out := make(map[string]any, len(value))
+        for k, item := range value {
+            out[k] = toJS(item)
+        }
+        return out
+    default:
+        return v
+    }
+}
+
+// fromJS converts a JS value into plain Go data: numbers become float64, arrays []any
+// and objects map[string]any. null and undefined become nil.
+func fromJS(v js.Value) any {
+    switch v.Type() {
+    case js.TypeBoolean:
+        return v.Bool()
+    case js.TypeNumber:
+        return v.Float()
+    case js.TypeString:
+        return v.String()
+    case js.TypeObject:
+        if js.Global().Get("Array").Call("isArray", v).Bool() {
+            out := make([]any, v.Length())
+            for i := range out {
+                out[i] = fromJS(v.Index(i))
+            }
+            return out
+        }
+        keys := js.Global().Get("Object").Call("keys", v)
+        out := make(map[string]any, keys.Length())
+        for i := 0; i < keys.Length(); i++ {
+            key := keys.Index(i).String()
+            out[key] = fromJS(v.Get(key))
+        }
+        return out
+    default:
+        return nil
+    }
+}
+
+// stringsArg reads a JS array of strings from args[i]; a missing argument yields nil.
+func stringsArg(args []js.Value, i int) []string {
+    if isMissing(args, i) {
+        return nil
+    }
+    return stringsValue(args[i])
+}
+
+// stringsValue reads a JS array as strings.
+func stringsValue(v js.Value) []string {
+    out := make([]string, v.Length())
+    for j := range out {
+        out[j] = v.Index(j).String()
+    }
+    return out
+}
+
+// stringMapArg reads a JS object of string values from args[i], such as a rename or
+// remap table.
+func stringMapArg(args []js.Value, i int) (map[string]string, error) {
+    raw, ok := fromJS(args[i]).(map[string]any)
+    if !ok {
+        return nil, errors.New("mapping must be an object of strings")
+    }
+    mapping := make(map[string]string, len(raw))
+    for from, to := range raw {
+        s, ok := to.(string)
+        if !ok {
+            return nil, fmt.Errorf("mapping for %q is not a string", from)
+        }
+        mapping[from] = s
+    }
+    return mapping, nil
+}
+
+// intsValue reads a JS array of numbers as ints.
+func intsValue(v js.Value) []int {
+    out := make([]int, v.Length())
+    for j := range out {
+        out[j] = v.Index(j).Int()
+    }
+    return out
+}
+
+// floatsArg reads a JS array of numbers from args[i]; a missing argument yields nil.
+func floatsArg(args []js.Value, i int) []float64 {
+    if isMissing(args, i) {
+        return nil
+    }
+    out := make([]float64, args[i].Length())
+    for j := range out {
+        out[j] = args[i].Index(j).Float()
+    }
+    return out
+}
+
+// bytesArg copies a Uint8Array at args[i] into a Go byte slice.
+func bytesArg(args []js.Value, i int) ([]byte, error) {
+    if isMissing(args, i) || !args[i].InstanceOf(js.Global().Get("Uint8Array")) {
+        return nil, errors.New("expected a Uint8Array")
+    }
+    data := make([]byte, args[i].Length())
+    js.CopyBytesToGo(data, args[i])
+    return data, nil
+}
+
+// intsArg reads a JS array of numbers from args[i]; a missing argument yields nil.
+func intsArg(args []js.Value, i int) []int {
+    if isMissing(args, i) {
+        return nil
+    }
+    return intsValue(args[i])
+}
+
+// isMissing reports whether args[i] was omitted, undefined or null.
+func isMissing(args []js.Value, i int) bool {
+    return len(args) <= i || args[i].IsUndefined() || args[i].IsNull()
+}
+
+// runeValue parses text as a single optional rune; empty text yields 0 and anything
+// longer than one rune yields errMulti.
+func runeValue(text string, errMulti error) (rune, error) {
+    if text == "" {
+        return 0, nil
+    }
+    if utf8.RuneCountInString(text) != 1 {
+        return 0, errMulti
+    }
+    r, _ := utf8.DecodeRuneInString(text)
+    return r, nil
+}
+
+// delimiterArg reads an optional delimiter from args[i]. A missing, null or empty
+// argument yields 0 so callers fall back to comma.
+func delimiterArg(args []js.Value, i int) (rune, error) {
+    if isMissing(args, i) {
+        return 0, nil
+    }
+    return runeValue(args[i].String(), errBadDelimiter)
+}
+
+// boolArg reads an optional boolean from args[i] using JS truthiness; missing means false.
+func boolArg(args []js.Value, i int) bool {
+    return len(args) > i && args[i].Truthy()
+}
+
+// optionsArg reads csvOptions starting at args[i]. Callers may pass either an options
+// object (keys are listed on csvOptions) or the positional form (delimiter, hasHeader).
+func optionsArg(args []js.Value, i int) (csvOptions, error) {
+    if isMissing(args, i) || args[i].Type() != js.TypeObject {
+        delimiter, err := delimiterArg(args, i)
+        if err != nil {
+            return csvOptions{}, err
+        }
+        return csvOptions{Delimiter: delimiter, HasHeader: boolArg(args, i+1), HeaderUnset: isMissing(args, i+1)}, nil
+    }
+    obj := args[i]
+    var opts csvOptions
+    if v := obj.Get("delimiter"); !v.IsUndefined() && !v.IsNull() {
+        delimiter, err := runeValue(v.String(), errBadDelimiter)
+        if err != nil {
+            return csvOptions{}, err
+        }
+        opts.Delimiter = delimiter
+    }
+    if v := obj.Get("comment"); !v.IsUndefined() && !v.IsNull() {
+        comment, err := runeValue(v.String(), errBadComment)
+        if err != nil {
+            return csvOptions{}, err
+        }
+        opts.Comment = comment
+    }
+    if v := obj.Get("decimalSeparator"); !v.IsUndefined() && !v.IsNull() {
+        separator, err := runeValue(v.String(), errBadDecimal)
+        if err != nil {
+            return csvOptions{}, err
+        }
+        opts.DecimalSeparator = separator
+    }
+    opts.HasHeader = obj.Get("header").Truthy()
+    opts.HeaderUnset = obj.Get("header").IsUndefined() || obj.Get("header").IsNull()
+    opts.Strict = obj.Get("strict").Truthy()
+    opts.Stats = obj.Get("stats").Truthy()
+    if v := obj.Get("precision"); v.Type() == js.TypeNumber {
+        precision := v.Int()
+        opts.Precision = &precision
+    }
+    opts.TrimSpace = obj.Get("trimSpace").Truthy()
+    opts.Dedupe = obj.Get("dedupe").Truthy()
+    if v := obj.Get("dedupeKey"); !v.IsUndefined() && !v.IsNull() {
+        opts.DedupeKey = intsValue(v)
+        opts.Dedupe = true
+    }
+    if v := obj.Get("maxFieldBytes"); v.Type() == js.TypeNumber {
+        opts.MaxFieldBytes = v.Int()
+    }
+    if v := obj.Get("nullTokens"); !v.IsUndefined() && !v.IsNull() {
+        opts.NullTokens = stringsValue(v)
+    }
+    opts.NullIgnoreCase = obj.Get("nullIgnoreCase").Truthy()
+    opts.ProfileCSV = obj.Get("profileCSV").Truthy()
+    opts.LineNumbers = obj.Get("lineNumbers").Truthy()
+    opts.FlagEmpty = obj.Get("flagEmpty").Truthy()
+    if v := obj.Get("sampleFraction"); v.Type() == js.TypeNumber {
+        opts.SampleFraction = v.Float()
+    }
+    if v := obj.Get("sampleSeed"); v.Type() == js.TypeNumber {
+        opts.SampleSeed = int64(v.Int())
+    }
+    opts.SkipBlankRows = obj.Get("skipBlankRows").Truthy()
+    opts.DropTrailingEmpty = obj.Get("dropTrailingEmpty").Truthy()
+    opts.Cardinality = obj.Get("cardinality").Truthy()
+    opts.CandidateKeys = obj.Get("candidateKeys").Truthy()
+    if v := obj.Get("cardinalityCap"); v.Type() == js.TypeNumber {
+        opts.CardinalityCap = v.Int()
+    }
+    if v := obj.Get("columns"); !v.IsUndefined() && !v.IsNull() {
+        opts.Columns = intsValue(v)
+    }
+    opts.IgnoreCase = obj.Get("ignoreCase").Truthy()
+    opts.Negate = obj.Get("negate").Truthy()
+    opts.IncludeTiming = obj.Get("includeTiming").Truthy()
+    if v := obj.Get("checksum"); v.Type() == js.TypeString {
+        opts.Checksum = v.String()
+    }
+    if v := obj.Get("maxRows"); v.Type() == js.TypeNumber {
+        opts.MaxRows = v.Int()
+    }
+    if v := obj.Get("onProgress"); v.Type() == js.TypeFunction {
+        opts.Progress = func(processed int) { v.Invoke(processed) }
+    }
+    if v := obj.Get("onChunk"); v.Type() == js.TypeFunction {
+        opts.Chunk = func(chunk string) { v.Invoke(chunk) }
+    }
+    if v := obj.Get("cancelToken"); v.Type() == js.TypeNumber {
+        opts.CancelToken = v.Int()
+    }
+    if v := obj.Get("chunkRows"); v.Type() == js.TypeNumber {
+        opts.ChunkRows = v.Int()
+    }
+    if err := opts.validate(); err != nil {
+        return csvOptions{}, err
+    }
+    return opts, nil
+}
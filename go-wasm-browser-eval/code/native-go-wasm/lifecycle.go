@@ -0,0 +1,113 @@
+//go:build js && wasm
+
+package main
+
+import (
+    "fmt"
+    "runtime/debug"
+    "sync"
+    "syscall/js"
+)
+
+// stoppedSource is the body of the plain JS function left behind on globalThis after
+// shutdown. It lives entirely in JS so it keeps working once the Go runtime has exited.
+const stoppedSource = `return {"error": "runtime stopped", "code": "stopped", "message": "runtime stopped"};`
+
+var (
+    // done is closed by wasmShutdown to let main return.
+    done = make(chan struct{})
+    // exported records every registered callback so shutdown can release it.
+    exported     = map[string]js.Func{}
+    shutdownOnce sync.Once
+    // namespace is the globalThis property holding the exports after wasmInit;
+    // empty while they are still flat globals.
+    namespace string
+)
+
+// safeCall wraps fn so a panic inside it, such as an index bug in a helper, returns an
+// error map with code "panic" instead of killing the Go runtime and every other export.
+// The recovered stack always goes to console.error, whatever the log level, and every
+// call is traced at debug level.
+func safeCall(name string, fn func(this js.Value, args []js.Value) any) func(this js.Value, args []js.Value) any {
+    return func(this js.Value, args []js.Value) (result any) {
+        defer func() {
+            if r := recover(); r != nil {
+                js.Global().Get("console").Call("error", fmt.Sprintf("%s panicked: %v\n%s", name, r, debug.Stack()))
+                failure := errorResult(codePanic, fmt.Sprintf("%s panicked: %v", name, r))
+                failure["error"] = codeInternal
+                failure["detail"] = fmt.Sprint(r)
+                result = failure
+            }
+        }()
+        logf(levelDebug, "%s called with %d arguments", name, len(args))
+        return fn(this, args)
+    }
+}
+
+// exportFunc registers fn on globalThis under name, behind safeCall, and remembers it
+// for shutdown.
+func exportFunc(name string, fn func(this js.Value, args []js.Value) any) {
+    f := js.FuncOf(safeCall(name, fn))
+    exported[name] = f
+    js.Global().Set(name, f)
+}
+
+// exportTarget returns the JS object currently holding the exports.
+func exportTarget() js.Value {
+    if namespace == "" {
+        return js.Global()
+    }
+    return js.Global().Get(namespace)
+}
+
+// initNamespace moves every export from its current holder onto a single object at
+// globalThis[name]. The object is built with js.ValueOf so the js.Func values are
+// stored as callable properties rather than copied.
+func initNamespace(name string) {
+    holder := exportTarget()
+    props := make(map[string]any, len(exported))
+    for fnName, f := range exported {
+        props[fnName] = f
+        holder.Delete(fnName)
+    }
+    if namespace != "" {
+        js.Global().Delete(namespace)
+    }
+    js.Global().Set(name, js.ValueOf(props))
+    namespace = name
+}
+
+// wrapInit exposes initNamespace to JavaScript as wasmInit(namespace?). Without a
+// namespace the exports stay as flat globals for backward compatibility.
+func wrapInit(this js.Value, args []js.Value) any {
+    if isMissing(args, 0) || args[0].String() == "" {
+        return nil
+    }
+    initNamespace(args[0].String())
+    return nil
+}
+
+// shutdown replaces every export with a JS stub returning {"error": "runtime stopped"},
+// releases the underlying js.Func values, parsed tables and open streams, and only then closes
+// done. Releasing before the channel closes guarantees no callback can re-enter Go after
+// main has begun returning.
+func shutdown() {
+    shutdownOnce.Do(func() {
+        holder := exportTarget()
+        stopped := js.Global().Get("Function").New(stoppedSource)
+        for name, f := range exported {
+            holder.Set(name, stopped)
+            f.Release()
+        }
+        clear(tables)
+        clear(streams)
+        logf(levelInfo, "runtime stopped; %d exports released", len(exported))
+        close(done)
+    })
+}
+
+// wrapShutdown exposes shutdown to JavaScript as wasmShutdown().
+func wrapShutdown(this js.Value, args []js.Value) any {
+    shutdown()
+    return nil
+}
@@ -0,0 +1,101 @@
+//go:build js && wasm
+
+package main
+
+import (
+    "strings"
+    "sync"
+    "syscall/js"
+    "testing"
+)
+
+// isolateExports gives a test its own export registry and shutdown state, restoring the
+// package's afterwards so later tests see a live runtime.
+func isolateExports(t *testing.T) {
+    t.Helper()
+    savedExported, savedDone, savedNamespace := exported, done, namespace
+    exported, done, namespace = map[string]js.Func{}, make(chan struct{}), ""
+    shutdownOnce = sync.Once{}
+    t.Cleanup(func() {
+        for name := range exported {
+            js.Global().Delete(name)
+        }
+        exported, done, namespace = savedExported, savedDone, savedNamespace
+        shutdownOnce = sync.Once{}
+    })
+}
+
+func TestShutdownStubsExports(t *testing.T) {
+    isolateExports(t)
+    exportFunc("testUpper", wrapUppercase)
+    if got := js.Global().Call("testUpper", "abc").String(); got != "ABC" {
+        t.Fatalf("before shutdown: got %q; want ABC", got)
+    }
+    shutdown()
+    select {
+    case <-done:
+    default:
+        t.Fatal("done is still open after shutdown")
+    }
+    result := fromJS(js.Global().Call("testUpper", "abc"))
+    wantError(t, result, "stopped", "runtime stopped")
+    // A second shutdown is a no-op rather than a double close.
+    shutdown()
+}
+
+func TestInitNamespace(t *testing.T) {
+    isolateExports(t)
+    t.Cleanup(func() { js.Global().Delete("testNS") })
+    exportFunc("testUpper", wrapUppercase)
+    wrapInit(js.Undefined(), nil)
+    if got := js.Global().Call("testUpper", "abc").String(); got != "ABC" {
+        t.Fatalf("without a namespace: got %q; want ABC", got)
+    }
+    wrapInit(js.Undefined(), []js.Value{js.ValueOf("testNS")})
+    if got := js.Global().Get("testNS").Call("testUpper", "abc").String(); got != "ABC" {
+        t.Errorf("testNS.testUpper: got %q; want ABC", got)
+    }
+    if !js.Global().Get("testUpper").IsUndefined() {
+        t.Error("flat global testUpper still set after wasmInit")
+    }
+}
+
+func TestSafeCallRecoversPanic(t *testing.T) {
+    console := js.Global().Get("console")
+    saved := console.Get("error")
+    var logged []string
+    capture := js.FuncOf(func(this js.Value, args []js.Value) any {
+        logged = append(logged, args[0].String())
+        return nil
+    })
+    console.Set("error", capture)
+    t.Cleanup(func() {
+        console.Set("error", saved)
+        capture.Release()
+    })
+    savedLevel := currentLogLevel.Load()
+    currentLogLevel.Store(int32(levelSilent))
+    t.Cleanup(func() { currentLogLevel.Store(savedLevel) })
+
+    fn := safeCall("testIndex", func(this js.Value, args []js.Value) any {
+        var rows [][]string
+        return rows[3]
+    })
+    result, ok := fn(js.Undefined(), nil).(map[string]any)
+    if !ok {
+        t.Fatalf("safeCall returned %T; want an error map", result)
+    }
+    if result["error"] != codeInternal || result["code"] != codePanic {
+        t.Errorf("error, code = %v, %v; want %s, %s", result["error"], result["code"], codeInternal, codePanic)
+    }
+    if detail, _ := result["detail"].(string); !strings.Contains(detail, "index out of range") {
+        t.Errorf("detail = %q; want the recovered value", detail)
+    }
+    if len(logged) != 1 || !strings.Contains(logged[0], "testIndex panicked") || !strings.Contains(logged[0], "goroutine") {
+        t.Errorf("console.error got %q; want the panic and its stack", logged)
+    }
+    // The runtime survives: an ordinary export still works afterwards.
+    if got := safeCall("testUpper", wrapUppercase)(js.Undefined(), []js.Value{js.ValueOf("ok")}); got != "OK" {
+        t.Errorf("after a panic: got %v; want OK", got)
+    }
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "sync/atomic"
+)
+
+// logLevel orders log messages; a message is written when its level is at or above the
+// current one.
+type logLevel int32
+
+const (
+    levelDebug logLevel = iota
+    levelInfo
+    levelWarn
+    levelError
+    // levelSilent is above every message level, so nothing is written.
+    levelSilent
+)
+
+// logLevelNames are the names wasmSetLogLevel accepts, indexed by level. The message
+// levels double as the console method each is routed to.
+var logLevelNames = [...]string{"debug", "info", "warn", "error", "silent"}
+
+// currentLogLevel is read and written atomically so JS can change it mid-session, even
+// while a long-running export is logging.
+var currentLogLevel atomic.Int32
+
+func init() {
+    currentLogLevel.Store(int32(levelWarn))
+}
+
+// logSink writes one message with the given console method. It is a variable so the
+// output can be redirected, for instance to a buffer while debugging the gate itself.
+// Outside JavaScript, as under go test, messages go to stderr; logging_js.go points it at
+// the console.
+var logSink = func(method, message string) {
+    fmt.Fprintf(os.Stderr, "%s: %s\n", method, message)
+}
+
+// logf formats and writes a message at level unless the current level suppresses it.
+func logf(level logLevel, format string, args ...any) {
+    if level < logLevel(currentLogLevel.Load()) {
+        return
+    }
+    logSink(logLevelNames[level], fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,31 @@
+//go:build js && wasm
+
+package main
+
+import (
+    "fmt"
+    "syscall/js"
+)
+
+// init routes log messages to the browser console.
+func init() {
+    logSink = func(method, message string) {
+        js.Global().Get("console").Call(method, message)
+    }
+}
+
+// wrapSetLogLevel sets the level below which Go-side log messages are dropped:
+// wasmSetLogLevel(level) with "debug", "info", "warn" (the default), "error" or "silent".
+// It returns the previous level's name.
+func wrapSetLogLevel(this js.Value, args []js.Value) any {
+    if len(args) < 1 || args[0].Type() != js.TypeString {
+        return errorResult(codeBadArgument, "expected a log level name")
+    }
+    for level, name := range logLevelNames {
+        if name == args[0].String() {
+            previous := currentLogLevel.Swap(int32(level))
+            return logLevelNames[previous]
+        }
+    }
+    return errorResult(codeBadArgument, fmt.Sprintf("unknown log level %q (want debug, info, warn, error or silent)", args[0].String()))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+    "reflect"
+    "sync"
+    "testing"
+)
+
+// captureLogs points logSink at a slice for the rest of the test and restores the sink
+// and level afterwards.
+func captureLogs(t *testing.T) *[]string {
+    t.Helper()
+    var mu sync.Mutex
+    var got []string
+    savedSink, savedLevel := logSink, currentLogLevel.Load()
+    logSink = func(method, message string) {
+        mu.Lock()
+        defer mu.Unlock()
+        got = append(got, method+": "+message)
+    }
+    t.Cleanup(func() {
+        logSink = savedSink
+        currentLogLevel.Store(savedLevel)
+    })
+    return &got
+}
+
+// logEveryLevel writes one message at each level.
+func logEveryLevel() {
+    logf(levelDebug, "d %d", 1)
+    logf(levelInfo, "i")
+    logf(levelWarn, "w")
+    logf(levelError, "e")
+}
+
+func TestLogLevelSuppresses(t *testing.T) {
+    tests := []struct {
+        level logLevel
+        want  []string
+    }{
+        {levelDebug, []string{"debug: d 1", "info: i", "warn: w", "error: e"}},
+        {levelInfo, []string{"info: i", "warn: w", "error: e"}},
+        {levelWarn, []string{"warn: w", "error: e"}},
+        {levelError, []string{"error: e"}},
+        {levelSilent, nil},
+    }
+    for _, tt := range tests {
+        t.Run(logLevelNames[tt.level], func(t *testing.T) {
+            got := captureLogs(t)
+            currentLogLevel.Store(int32(tt.level))
+            logEveryLevel()
+            if !reflect.DeepEqual(*got, tt.want) {
+                t.Errorf("at %s logged %q; want %q", logLevelNames[tt.level], *got, tt.want)
+            }
+        })
+    }
+}
+
+func TestLogLevelDefault(t *testing.T) {
+    if level := logLevel(currentLogLevel.Load()); level != levelWarn {
+        t.Errorf("default level = %s; want warn", logLevelNames[level])
+    }
+}
+
+func TestLogLevelChangedMidSession(t *testing.T) {
+    captureLogs(t)
+    // Under -race this shows the level is safe to change while another goroutine logs.
+    var wg sync.WaitGroup
+    wg.Add(1)
+    go func() {
+        defer wg.Done()
+        for i := range 1000 {
+            logf(levelInfo, "tick %d", i)
+        }
+    }()
+    for i := range 1000 {
+        currentLogLevel.Store(int32(i % len(logLevelNames)))
+    }
+    wg.Wait()
+}
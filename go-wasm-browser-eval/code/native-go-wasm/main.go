@@ -1,40 +1,1554 @@
+//go:build js && wasm
+
 package main
 
 import (
-    "encoding/csv"
+    "bytes"
     "fmt"
     "strings"
     "syscall/js"
 )
 
-// summaryFromCSV returns the number of records and columns from a CSV payload.
-// It is intentionally simple to mirror a subset of CLI-oriented processing in a browser.
-func summaryFromCSV(csvText string) (map[string]any, error) {
-    reader := csv.NewReader(strings.NewReader(csvText))
-    rows, err := reader.ReadAll()
+// wrapCSVSummary exposes summaryFromCSV to JavaScript as wasmCSVSummary(text, options?).
+// options is either an options object or the positional pair (delimiter, hasHeader).
+// Without a delimiter one is detected from the text and reported as "detectedDelimiter";
+// without a header flag one is guessed and reported as "headerDetected".
+func wrapCSVSummary(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 1)
     if err != nil {
-        return nil, fmt.Errorf("failed to parse csv: %w", err)
+        return errorResult(codeBadArgument, err.Error())
     }
-    columnCount := 0
-    if len(rows) > 0 {
-        columnCount = len(rows[0])
+    text := args[0].String()
+    detected := detectUnsetDelimiter(&opts, sampleOf(text))
+    guessedHeader := detectUnsetHeader(&opts, sampleOf(text))
+    result, err := summarizeStream(strings.NewReader(text), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    if detected {
+        result["detectedDelimiter"] = string(opts.Delimiter)
     }
-    return map[string]any{
-        "rows":    len(rows),
-        "columns": columnCount,
-    }, nil
+    if guessedHeader {
+        result["headerDetected"] = opts.HasHeader
+    }
+    return toJS(result)
 }
 
-// wrapCSVSummary exposes summaryFromCSV to JavaScript.
-func wrapCSVSummary(this js.Value, args []js.Value) any {
+// wrapCSVSummaryBytes is wasmCSVSummary for a Uint8Array of UTF-8 CSV, skipping the
+// UTF-16 to UTF-8 string conversion that dominates large inputs: wasmCSVSummaryBytes(uint8array, options?).
+func wrapCSVSummaryBytes(this js.Value, args []js.Value) any {
+    data, err := bytesArg(args, 0)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    sample := string(data[:min(len(data), detectSampleBytes)])
+    detected := detectUnsetDelimiter(&opts, sample)
+    guessedHeader := detectUnsetHeader(&opts, sample)
+    result, err := summarizeStream(bytes.NewReader(data), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    if detected {
+        result["detectedDelimiter"] = string(opts.Delimiter)
+    }
+    if guessedHeader {
+        result["headerDetected"] = opts.HasHeader
+    }
+    return toJS(result)
+}
+
+// wrapSniffFormat exposes sniffFormat to JavaScript as wasmSniffFormat(sample),
+// returning {format, confidence}.
+func wrapSniffFormat(this js.Value, args []js.Value) any {
     if len(args) < 1 {
-        return map[string]any{"error": "expected a CSV string"}
+        return errorResult(codeBadArgument, "expected a text sample")
+    }
+    format, confidence := sniffFormat(args[0].String())
+    return map[string]any{"format": format, "confidence": confidence}
+}
+
+// wrapGzipCSVSummary exposes summaryFromGzipCSV to JavaScript as
+// wasmGzipCSVSummary(uint8array, options?).
+func wrapGzipCSVSummary(this js.Value, args []js.Value) any {
+    data, err := bytesArg(args, 0)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
     }
-    result, err := summaryFromCSV(args[0].String())
+    opts, err := optionsArg(args, 1)
     if err != nil {
-        return map[string]any{"error": err.Error()}
+        return errorResult(codeBadArgument, err.Error())
     }
-    return result
+    result, err := summaryFromGzipCSV(data, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(result)
+}
+
+// wrapNDJSONSummary exposes summaryFromNDJSON to JavaScript as wasmNDJSONSummary(text).
+func wrapNDJSONSummary(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected an NDJSON string")
+    }
+    return toJS(summaryFromNDJSON(args[0].String()))
+}
+
+// wrapCSVPreview exposes previewCSV to JavaScript as wasmCSVPreview(text, n, options?).
+func wrapCSVPreview(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a row count")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    result, err := previewCSV(args[0].String(), args[1].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(result)
+}
+
+// wrapSampleRows exposes sampleRows to JavaScript as wasmSampleRows(text, k, seed, options?).
+func wrapSampleRows(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a sample size and a seed")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    result, err := sampleRows(args[0].String(), args[1].Int(), int64(args[2].Float()), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(result)
+}
+
+// wrapColumnSamples exposes sampleValuesPerColumn to JavaScript as
+// wasmColumnSamples(text, perColumn, options?), returning one array of values per column.
+func wrapColumnSamples(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a count per column")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    samples, err := sampleValuesPerColumn(args[0].String(), args[1].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(samples)
+}
+
+// wrapGenerateCSV exposes generateCSV to JavaScript as wasmGenerateCSV(rows, cols, seed).
+func wrapGenerateCSV(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a row count, a column count and a seed")
+    }
+    text, err := generateCSV(args[0].Int(), args[1].Int(), int64(args[2].Float()))
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapCSVToJSON exposes csvToJSON to JavaScript as wasmCSVToJSON(text, coerceTypes?).
+// With coerceTypes, csvToTypedJSON is used instead so numeric and boolean columns come
+// back as JS numbers and booleans.
+func wrapCSVToJSON(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    if boolArg(args, 1) {
+        records, err := csvToTypedJSON(args[0].String())
+        if err != nil {
+            return errorMap(err)
+        }
+        return toJS(records)
+    }
+    records, err := csvToJSON(args[0].String())
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(records)
+}
+
+// wrapCSVToColumns exposes csvToColumns to JavaScript as wasmCSVToColumns(text), returning
+// {header: [values...]}.
+func wrapCSVToColumns(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    columns, err := csvToColumns(args[0].String())
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(columns)
+}
+
+// wrapJSONToCSV exposes jsonToCSV to JavaScript as wasmJSONToCSV(arrayOfObjects, crlf?).
+func wrapJSONToCSV(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected an array of objects")
+    }
+    items, ok := fromJS(args[0]).([]any)
+    if !ok {
+        return errorResult(codeBadArgument, "expected an array of objects")
+    }
+    data := make([]map[string]any, len(items))
+    for i, item := range items {
+        record, ok := item.(map[string]any)
+        if !ok {
+            return errorResult(codeBadArgument, fmt.Sprintf("element %d is not an object", i))
+        }
+        data[i] = record
+    }
+    text, err := jsonToCSV(data, boolArg(args, 1))
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapValidateCSV exposes validateCSV to JavaScript as wasmValidateCSV(text, schema, options?).
+func wrapValidateCSV(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a schema array")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    problems, err := validateCSV(args[0].String(), stringsArg(args, 1), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(problems)
+}
+
+// wrapValidateEmails exposes validateEmailColumn to JavaScript as
+// wasmValidateEmails(text, col, options?), returning the line numbers of bad cells.
+func wrapValidateEmails(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a column")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    lines, err := validateEmailColumn(args[0].String(), args[1].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(lines)
+}
+
+// wrapWhitespaceReport exposes whitespaceReport to JavaScript as
+// wasmWhitespaceReport(text, options?), skipping the header row when header is set.
+func wrapWhitespaceReport(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    counts := whitespaceReport(rows)
+    for len(counts) < len(header) {
+        counts = append(counts, 0)
+    }
+    return toJS(counts)
+}
+
+// wrapTypeConfidence exposes typeConfidence to JavaScript as
+// wasmTypeConfidence(text, options?), skipping the header row when header is set.
+func wrapTypeConfidence(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    confidence := typeConfidence(rows)
+    for len(confidence) < len(header) {
+        confidence = append(confidence, typeShares(nil, 0))
+    }
+    return toJS(confidence)
+}
+
+// wrapProfile exposes profile to JavaScript as wasmProfile(text, options?), returning an
+// array of column descriptors.
+func wrapProfile(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    columns, err := profile(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(columns)
+}
+
+// wrapIsRectangular exposes isRectangular to JavaScript as wasmIsRectangular(text, options?),
+// returning {rectangular, line}.
+func wrapIsRectangular(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    ok, line, err := isRectangular(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return map[string]any{"rectangular": ok, "line": line}
+}
+
+// wrapRectangularize exposes rectangularize to JavaScript as
+// wasmRectangularize(text, fill?, errorOnLong?, options?).
+func wrapRectangularize(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    fill := ""
+    if !isMissing(args, 1) {
+        fill = args[1].String()
+    }
+    text, err := rectangularize(args[0].String(), fill, boolArg(args, 2), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapInferSchema exposes inferSchema to JavaScript as wasmInferSchema(text, options?),
+// or inferProfile when the profileCSV option is set.
+func wrapInferSchema(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    if opts.ProfileCSV {
+        profile, err := inferProfile(args[0].String(), opts)
+        if err != nil {
+            return errorMap(err)
+        }
+        return profile
+    }
+    schema, err := inferSchema(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(schema)
+}
+
+// wrapSelectColumns exposes selectColumns to JavaScript as wasmSelectColumns(text, indices).
+func wrapSelectColumns(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and an array of column indices")
+    }
+    rows, err := selectColumns(args[0].String(), intsArg(args, 1))
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(rows)
+}
+
+// wrapGroupByCount exposes groupByCount to JavaScript as wasmGroupByCount(text, keyCol, options?).
+func wrapGroupByCount(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a key column")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    keyCol := args[1].Int()
+    if err := checkColumn(keyCol, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    return toJS(groupByCount(rows, keyCol))
+}
+
+// wrapGroupBySum exposes groupBySum to JavaScript as wasmGroupBySum(text, keyCol, valueCol, options?).
+func wrapGroupBySum(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a key column and a value column")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    keyCol, valueCol := args[1].Int(), args[2].Int()
+    width := max(len(header), tableWidth(rows))
+    for _, col := range []int{keyCol, valueCol} {
+        if err := checkColumn(col, width); err != nil {
+            return errorResult(codeBadArgument, err.Error())
+        }
+    }
+    return toJS(groupBySum(rows, keyCol, valueCol))
+}
+
+// wrapGroupByShare exposes groupByShare to JavaScript as
+// wasmGroupByShare(text, keyCol, valueCol, options?), returning {groups, total, skipped}.
+func wrapGroupByShare(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a key column and a value column")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    keyCol, valueCol := args[1].Int(), args[2].Int()
+    width := max(len(header), tableWidth(rows))
+    for _, col := range []int{keyCol, valueCol} {
+        if err := checkColumn(col, width); err != nil {
+            return errorResult(codeBadArgument, err.Error())
+        }
+    }
+    return toJS(groupByShare(rows, keyCol, valueCol))
+}
+
+// wrapSortByColumn exposes sortByColumn to JavaScript as
+// wasmSortByColumn(text, col, numeric, descending, options?).
+func wrapSortByColumn(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a column")
+    }
+    opts, err := optionsArg(args, 4)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := sortByColumn(args[0].String(), args[1].Int(), boolArg(args, 2), boolArg(args, 3), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapCountWhere exposes countWhere to JavaScript as
+// wasmCountWhere(text, col, op, value, options?); options.ignoreCase folds case.
+func wrapCountWhere(this js.Value, args []js.Value) any {
+    if len(args) < 4 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column, an operator and a value")
+    }
+    opts, err := optionsArg(args, 4)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    col := args[1].Int()
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    count, err := countWhere(rows, col, args[2].String(), args[3].String(), opts.IgnoreCase)
+    if err != nil {
+        return errorMap(err)
+    }
+    return count
+}
+
+// wrapChunkCSV exposes chunkCSV to JavaScript as wasmChunkCSV(text, maxBytes, options?).
+func wrapChunkCSV(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a maximum chunk size in bytes")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    chunks, err := chunkCSV(args[0].String(), args[1].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(chunks)
+}
+
+// wrapUniqueRows exposes uniqueRows to JavaScript as wasmUniqueRows(text, keyCols?, options?).
+func wrapUniqueRows(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := uniqueRows(args[0].String(), intsArg(args, 1), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapFilterRows exposes filterRows to JavaScript as
+// wasmFilterRows(text, col, op, value, options?); options.negate inverts the match.
+func wrapFilterRows(this js.Value, args []js.Value) any {
+    if len(args) < 4 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column, an operator and a value")
+    }
+    opts, err := optionsArg(args, 4)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := filterRows(args[0].String(), args[1].Int(), args[2].String(), args[3].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapSearchAny exposes searchAnyColumn to JavaScript as
+// wasmSearchAny(text, query, caseInsensitive?, regex?, options?).
+func wrapSearchAny(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a query")
+    }
+    opts, err := optionsArg(args, 4)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := searchAnyColumn(args[0].String(), args[1].String(), boolArg(args, 2), boolArg(args, 3), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapJoinCSV exposes joinCSV to JavaScript as
+// wasmJoinCSV(leftText, rightText, leftKey, rightKey, how, options?).
+func wrapJoinCSV(this js.Value, args []js.Value) any {
+    if len(args) < 5 {
+        return errorResult(codeBadArgument, "expected two CSV strings, two key columns and a join type")
+    }
+    opts, err := optionsArg(args, 5)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := joinCSV(args[0].String(), args[1].String(), args[2].Int(), args[3].Int(), args[4].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapMatchHeaders exposes matchHeaders to JavaScript as
+// wasmMatchHeaders(headersA, headersB, threshold?), returning [{header, match, score}].
+func wrapMatchHeaders(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected two arrays of header names")
+    }
+    threshold := defaultMatchThreshold
+    if !isMissing(args, 2) {
+        threshold = args[2].Float()
+    }
+    matches, err := matchHeaders(stringsArg(args, 0), stringsArg(args, 1), threshold)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(matches)
+}
+
+// wrapPivot exposes pivot to JavaScript as
+// wasmPivot(text, indexCol, columnsCol, valueCol, agg, options?).
+func wrapPivot(this js.Value, args []js.Value) any {
+    if len(args) < 5 {
+        return errorResult(codeBadArgument, "expected a CSV string, index, columns and value columns and an aggregation")
+    }
+    opts, err := optionsArg(args, 5)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := pivot(args[0].String(), args[1].Int(), args[2].Int(), args[3].Int(), args[4].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapCrosstab exposes crosstab to JavaScript as wasmCrosstab(text, rowCol, colCol, options?),
+// returning {table, rowTotals, columnTotals, total}.
+func wrapCrosstab(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string and two columns")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    width := max(len(header), tableWidth(rows))
+    rowCol, colCol := args[1].Int(), args[2].Int()
+    for _, col := range []int{rowCol, colCol} {
+        if err := checkColumn(col, width); err != nil {
+            return errorResult(codeBadArgument, err.Error())
+        }
+    }
+    return toJS(crosstab(rows, rowCol, colCol))
+}
+
+// wrapDiffCSV exposes diffCSV to JavaScript as wasmDiffCSV(aText, bText, keyCol, options?),
+// returning {added, removed, changed} arrays of keys.
+func wrapDiffCSV(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected two CSV strings and a key column")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    result, err := diffCSV(args[0].String(), args[1].String(), args[2].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(result)
+}
+
+// wrapPartitionByColumn exposes partitionByColumn to JavaScript as
+// wasmPartitionByColumn(text, col, maxPartitions?, options?), returning
+// {partitions, truncated, droppedRows}.
+func wrapPartitionByColumn(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a column")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    maxParts := 0
+    if !isMissing(args, 2) {
+        maxParts = args[2].Int()
+    }
+    result, err := partitionByColumn(args[0].String(), args[1].Int(), maxParts, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(result)
+}
+
+// wrapMergeCSV exposes mergeCSV to JavaScript as wasmMergeCSV(arrayOfChunks, options?).
+func wrapMergeCSV(this js.Value, args []js.Value) any {
+    if len(args) < 1 || args[0].Type() != js.TypeObject {
+        return errorResult(codeBadArgument, "expected an array of CSV strings")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := mergeCSV(stringsArg(args, 0), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapConcatAligned exposes concatAligned to JavaScript as
+// wasmConcatAligned(arrayOfTexts, options?).
+func wrapConcatAligned(this js.Value, args []js.Value) any {
+    if len(args) < 1 || args[0].Type() != js.TypeObject {
+        return errorResult(codeBadArgument, "expected an array of CSV strings")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := concatAligned(stringsArg(args, 0), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapRemapColumn exposes remapColumn to JavaScript as
+// wasmRemapColumn(text, col, {from: to, ...}, options?).
+func wrapRemapColumn(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column and a mapping object")
+    }
+    mapping, err := stringMapArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := remapColumn(args[0].String(), args[1].Int(), mapping, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapCoalesceColumns exposes coalesceColumns to JavaScript as
+// wasmCoalesceColumns(text, cols, sep, newHeader, drop?, options?).
+func wrapCoalesceColumns(this js.Value, args []js.Value) any {
+    if len(args) < 4 {
+        return errorResult(codeBadArgument, "expected a CSV string, an array of columns, a separator and a header")
+    }
+    opts, err := optionsArg(args, 5)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := coalesceColumns(args[0].String(), intsArg(args, 1), args[2].String(), args[3].String(), boolArg(args, 4), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapSplitColumn exposes splitColumn to JavaScript as
+// wasmSplitColumn(text, col, sep, maxParts, options?).
+func wrapSplitColumn(this js.Value, args []js.Value) any {
+    if len(args) < 4 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column, a separator and a part count")
+    }
+    opts, err := optionsArg(args, 4)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := splitColumn(args[0].String(), args[1].Int(), args[2].String(), args[3].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapRedactColumns exposes redactColumns to JavaScript as
+// wasmRedactColumns(text, cols, mask?, options?). mask defaults to "*".
+func wrapRedactColumns(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and an array of columns")
+    }
+    mask := '*'
+    if !isMissing(args, 2) {
+        r, err := runeValue(args[2].String(), errBadMask)
+        if err != nil {
+            return errorResult(codeBadArgument, err.Error())
+        }
+        if r != 0 {
+            mask = r
+        }
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := redactColumns(args[0].String(), intsArg(args, 1), mask, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapFillDown exposes fillDown to JavaScript as wasmFillDown(text, cols, options?).
+func wrapFillDown(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and an array of columns")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := fillDown(args[0].String(), intsArg(args, 1), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapCleanControlChars exposes cleanControlChars to JavaScript as
+// wasmCleanControlChars(text, replacement?, options?). replacement defaults to "".
+func wrapCleanControlChars(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    replacement := ""
+    if !isMissing(args, 1) {
+        replacement = args[1].String()
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := cleanControlChars(args[0].String(), replacement, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// jsCellFunc adapts a JS callback to the cell function mapColumn expects. A callback that
+// throws surfaces as an error instead of a panic, and non-string results are converted
+// with JS String().
+func jsCellFunc(fn js.Value) func(string) (string, error) {
+    return func(value string) (mapped string, err error) {
+        defer func() {
+            if r := recover(); r != nil {
+                if jsErr, ok := r.(js.Error); ok {
+                    err = jsErr
+                    return
+                }
+                panic(r)
+            }
+        }()
+        result := fn.Invoke(value)
+        if result.Type() != js.TypeString {
+            result = js.Global().Get("String").Invoke(result)
+        }
+        return result.String(), nil
+    }
+}
+
+// wrapMapColumn exposes mapColumn to JavaScript as wasmMapColumn(text, col, fn, options?).
+func wrapMapColumn(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column index and a callback")
+    }
+    if args[2].Type() != js.TypeFunction {
+        return errorResult(codeBadArgument, "callback must be a function")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := mapColumn(args[0].String(), args[1].Int(), jsCellFunc(args[2]), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapRenameHeaders exposes renameHeaders to JavaScript as
+// wasmRenameHeaders(text, {old: new, ...}, options?).
+func wrapRenameHeaders(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a mapping object")
+    }
+    mapping, err := stringMapArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := renameHeaders(args[0].String(), mapping, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapNormalizeDates exposes normalizeDates to JavaScript as
+// wasmNormalizeDates(text, col, layouts, options?), returning {csv, unparsed}.
+func wrapNormalizeDates(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column and an array of date layouts")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    result, err := normalizeDates(args[0].String(), args[1].Int(), stringsArg(args, 2), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return result
+}
+
+// wrapCountInDateRange exposes countInDateRange to JavaScript as
+// wasmCountInDateRange(text, col, layout, from, to, options?), returning {count, unparsed}.
+func wrapCountInDateRange(this js.Value, args []js.Value) any {
+    if len(args) < 5 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column, a date layout, from and to")
+    }
+    opts, err := optionsArg(args, 5)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    col := args[1].Int()
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    count, unparsed, err := countInDateRange(rows, col, args[2].String(), args[3].String(), args[4].String())
+    if err != nil {
+        return errorMap(err)
+    }
+    return map[string]any{"count": count, "unparsed": unparsed}
+}
+
+// wrapApplyExpr exposes applyExpr to JavaScript as
+// wasmApplyExpr(text, col, op, operand, options?), returning {csv, skipped}.
+func wrapApplyExpr(this js.Value, args []js.Value) any {
+    if len(args) < 4 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column, an operation and an operand")
+    }
+    opts, err := optionsArg(args, 4)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    result, err := applyExpr(args[0].String(), args[1].Int(), args[2].String(), args[3].Float(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return result
+}
+
+// wrapImputeMean exposes imputeMean to JavaScript as wasmImputeMean(text, col, options?),
+// returning {csv, filled}.
+func wrapImputeMean(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a column")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    result, err := imputeMean(args[0].String(), args[1].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return result
+}
+
+// wrapRowDeltas exposes rowDeltas to JavaScript as wasmRowDeltas(text, col, options?).
+func wrapRowDeltas(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a column")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := rowDeltas(args[0].String(), args[1].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapColumnDiff exposes columnDiff to JavaScript as
+// wasmColumnDiff(text, colA, colB, newHeader?, options?).
+func wrapColumnDiff(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string and two column indices")
+    }
+    opts, err := optionsArg(args, 4)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    newHeader := "delta"
+    if !isMissing(args, 3) {
+        newHeader = args[3].String()
+    }
+    text, err := columnDiff(args[0].String(), args[1].Int(), args[2].Int(), newHeader, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapMovingAverage exposes movingAverage to JavaScript as
+// wasmMovingAverage(text, col, window, options?).
+func wrapMovingAverage(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column and a window")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := movingAverage(args[0].String(), args[1].Int(), args[2].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapSliceCSV exposes sliceCSV to JavaScript as
+// wasmSliceCSV(text, startRow, endRow, startCol, endCol, options?).
+func wrapSliceCSV(this js.Value, args []js.Value) any {
+    if len(args) < 5 {
+        return errorResult(codeBadArgument, "expected a CSV string and startRow, endRow, startCol, endCol")
+    }
+    opts, err := optionsArg(args, 5)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := sliceCSV(args[0].String(), args[1].Int(), args[2].Int(), args[3].Int(), args[4].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapReverseRows exposes reverseRows to JavaScript as wasmReverseRows(text, options?).
+func wrapReverseRows(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := reverseRows(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapCanonicalize exposes canonicalizeCSV to JavaScript as wasmCanonicalize(text, options?).
+func wrapCanonicalize(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := canonicalizeCSV(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    if opts.Chunk != nil {
+        return js.Undefined()
+    }
+    return text
+}
+
+// wrapRequote exposes requote to JavaScript as wasmRequote(text, policy, options?).
+func wrapRequote(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a quoting policy")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := requote(args[0].String(), args[1].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    if opts.Chunk != nil {
+        return js.Undefined()
+    }
+    return text
+}
+
+// wrapTransposeCSV exposes transposeCSV to JavaScript as wasmTransposeCSV(text, crlf?).
+func wrapTransposeCSV(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    text, err := transposeCSV(args[0].String(), boolArg(args, 1))
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapTermFrequency exposes termFrequency to JavaScript as
+// wasmTermFrequency(text, col, terms, options?).
+func wrapTermFrequency(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column and an array of terms")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    col := args[1].Int()
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    return toJS(termFrequency(rows, col, stringsArg(args, 2)))
+}
+
+// wrapWordCounts exposes wordCounts to JavaScript as
+// wasmWordCounts(text, col, topN, stopwords?, options?), returning [{word, count}].
+func wrapWordCounts(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column and a word limit")
+    }
+    opts, err := optionsArg(args, 4)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    col := args[1].Int()
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    return toJS(wordCounts(rows, col, args[2].Int(), stringsArg(args, 3)))
+}
+
+// wrapTopValues exposes topValues to JavaScript as wasmTopValues(text, col, k, options?),
+// returning [{value, count}].
+func wrapTopValues(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column and k")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    col := args[1].Int()
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    values, err := topValues(rows, col, args[2].Int())
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(values)
+}
+
+// wrapDistinctValues exposes distinctValues to JavaScript as
+// wasmDistinctValues(text, col, limit?, options?), returning {values, truncated}.
+func wrapDistinctValues(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a column")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    col := args[1].Int()
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    limit := 0
+    if !isMissing(args, 2) {
+        limit = args[2].Int()
+    }
+    values, truncated := distinctValues(rows, col, limit)
+    return toJS(map[string]any{"values": values, "truncated": truncated})
+}
+
+// wrapCorrelation exposes correlation to JavaScript as wasmCorrelation(text, colA, colB, options?).
+func wrapCorrelation(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string and two columns")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    colA, colB := args[1].Int(), args[2].Int()
+    width := max(len(header), tableWidth(rows))
+    for _, col := range []int{colA, colB} {
+        if err := checkColumn(col, width); err != nil {
+            return errorResult(codeBadArgument, err.Error())
+        }
+    }
+    r, err := correlation(rows, colA, colB)
+    if err != nil {
+        return errorMap(err)
+    }
+    return r
+}
+
+// wrapColumnMatch exposes columnMatchCount to JavaScript as
+// wasmColumnMatch(text, colA, colB, options?), returning {matches, mismatches}. The
+// ignoreCase option compares case-insensitively.
+func wrapColumnMatch(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string and two columns")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    colA, colB := args[1].Int(), args[2].Int()
+    width := max(len(header), tableWidth(rows))
+    for _, col := range []int{colA, colB} {
+        if err := checkColumn(col, width); err != nil {
+            return errorResult(codeBadArgument, err.Error())
+        }
+    }
+    matches, mismatches := columnMatchCount(rows, colA, colB, opts.IgnoreCase)
+    return map[string]any{"matches": matches, "mismatches": mismatches}
+}
+
+// wrapRegexValidate exposes regexValidateColumn to JavaScript as
+// wasmRegexValidate(text, col, pattern, options?), returning the line numbers of bad cells.
+func wrapRegexValidate(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column and a pattern")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    lines, err := regexValidateColumn(args[0].String(), args[1].Int(), args[2].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(lines)
+}
+
+// wrapDuplicateHeaders exposes duplicateHeaders to JavaScript as
+// wasmDuplicateHeaders(text, options?), returning {name: [indices]}.
+func wrapDuplicateHeaders(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    dups, err := duplicateHeaders(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(dups)
+}
+
+// wrapEnumValidate exposes enumValidateColumn to JavaScript as
+// wasmEnumValidate(text, col, allowed, options?), returning the line numbers of bad cells.
+func wrapEnumValidate(this js.Value, args []js.Value) any {
+    if len(args) < 3 || args[2].Type() != js.TypeObject {
+        return errorResult(codeBadArgument, "expected a CSV string, a column and an array of allowed values")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    lines, err := enumValidateColumn(args[0].String(), args[1].Int(), stringsArg(args, 2), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(lines)
+}
+
+// wrapRangeCheck exposes rangeCheck to JavaScript as
+// wasmRangeCheck(text, col, min, max, options?).
+func wrapRangeCheck(this js.Value, args []js.Value) any {
+    if len(args) < 4 || args[2].Type() != js.TypeNumber || args[3].Type() != js.TypeNumber {
+        return errorResult(codeBadArgument, "expected a CSV string, a column, a minimum and a maximum")
+    }
+    opts, err := optionsArg(args, 4)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    report, err := rangeCheck(args[0].String(), args[1].Int(), args[2].Float(), args[3].Float(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(report)
+}
+
+// wrapCheckMonotonic exposes checkMonotonic to JavaScript as
+// wasmCheckMonotonic(text, col, strictlyIncreasing?, options?), returning
+// {breakLine, nonNumeric}.
+func wrapCheckMonotonic(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a column")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    report, err := checkMonotonic(args[0].String(), args[1].Int(), boolArg(args, 2), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return report
+}
+
+// wrapColumnEntropy exposes columnEntropy to JavaScript as wasmColumnEntropy(text, col, options?).
+func wrapColumnEntropy(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a column")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    col := args[1].Int()
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    return columnEntropy(rows, col)
+}
+
+// wrapQuantiles exposes quantiles to JavaScript as wasmQuantiles(text, col, qs, options?),
+// returning one value per entry of qs.
+func wrapQuantiles(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column and an array of quantiles")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    col := args[1].Int()
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    values, err := quantiles(rows, col, floatsArg(args, 2))
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(values)
+}
+
+// wrapDetectOutliers exposes detectOutliers to JavaScript as
+// wasmDetectOutliers(text, col, options?), returning {q1, q3, iqr, lower, upper, outliers}.
+func wrapDetectOutliers(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a column")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    result, err := detectOutliers(args[0].String(), args[1].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(result)
+}
+
+// wrapWeightedMean exposes weightedMean to JavaScript as
+// wasmWeightedMean(text, valueCol, weightCol, options?).
+func wrapWeightedMean(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a value column and a weight column")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    valueCol, weightCol := args[1].Int(), args[2].Int()
+    for _, col := range []int{valueCol, weightCol} {
+        if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+            return errorResult(codeBadArgument, err.Error())
+        }
+    }
+    mean, err := weightedMean(rows, valueCol, weightCol, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return mean
+}
+
+// wrapColumnMode exposes columnMode to JavaScript as wasmColumnMode(text, col, options?),
+// returning {value, count}.
+func wrapColumnMode(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a column")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    col := args[1].Int()
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    value, count, err := columnMode(rows, col)
+    if err != nil {
+        return errorMap(err)
+    }
+    return ValueCount{Value: value, Count: count}.toMap()
+}
+
+// wrapHistogram exposes histogram to JavaScript as wasmHistogram(text, col, buckets, options?),
+// returning {buckets: [{lo, hi, count}], skipped}.
+func wrapHistogram(this js.Value, args []js.Value) any {
+    if len(args) < 3 {
+        return errorResult(codeBadArgument, "expected a CSV string, a column and a bucket count")
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    header, rows, err := splitHeader(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    col := args[1].Int()
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    bins, skipped, err := histogram(rows, col, args[2].Int())
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(map[string]any{"buckets": bins, "skipped": skipped})
+}
+
+// wrapRenderTable exposes renderTable to JavaScript as wasmRenderTable(text, maxColWidth, options?).
+func wrapRenderTable(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    maxColWidth := 0
+    if !isMissing(args, 1) {
+        maxColWidth = args[1].Int()
+    }
+    text, err := renderTable(args[0].String(), maxColWidth, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapCSVToMarkdown exposes csvToMarkdown to JavaScript as wasmCSVToMarkdown(text, options?).
+func wrapCSVToMarkdown(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := csvToMarkdown(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapWrapCells exposes wrapCells to JavaScript as wasmWrapCells(text, width, options?).
+func wrapWrapCells(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and a width")
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := wrapCells(args[0].String(), args[1].Int(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
+}
+
+// wrapCSVToFixedWidth exposes csvToFixedWidth as
+// wasmCSVToFixedWidth(text, widths, pad?, options?). pad defaults to a space.
+func wrapCSVToFixedWidth(this js.Value, args []js.Value) any {
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a CSV string and an array of widths")
+    }
+    pad := ' '
+    if !isMissing(args, 2) {
+        r, err := runeValue(args[2].String(), errBadPad)
+        if err != nil {
+            return errorResult(codeBadArgument, err.Error())
+        }
+        if r != 0 {
+            pad = r
+        }
+    }
+    opts, err := optionsArg(args, 3)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    text, err := csvToFixedWidth(args[0].String(), intsArg(args, 1), pad, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return text
 }
 
 // wrapUppercase exposes a basic string helper to demonstrate data flow between JS and Go.
@@ -46,9 +1560,122 @@ func wrapUppercase(this js.Value, args []js.Value) any {
 }
 
 func main() {
-    js.Global().Set("wasmCSVSummary", js.FuncOf(wrapCSVSummary))
-    js.Global().Set("wasmUppercase", js.FuncOf(wrapUppercase))
+    exportFunc("wasmCSVSummary", wrapCSVSummary)
+    exportFunc("wasmCSVSummaryBytes", wrapCSVSummaryBytes)
+    exportFunc("wasmQuickStats", wrapQuickStats)
+    exportFunc("wasmEstimateRows", wrapEstimateRows)
+    exportFunc("wasmGzipCSVSummary", wrapGzipCSVSummary)
+    exportFunc("wasmNDJSONSummary", wrapNDJSONSummary)
+    exportFunc("wasmStreamStart", wrapStreamStart)
+    exportFunc("wasmStreamPush", wrapStreamPush)
+    exportFunc("wasmStreamFinish", wrapStreamFinish)
+    exportFunc("wasmSniffFormat", wrapSniffFormat)
+    exportFunc("wasmCSVPreview", wrapCSVPreview)
+    exportFunc("wasmSampleRows", wrapSampleRows)
+    exportFunc("wasmColumnSamples", wrapColumnSamples)
+    exportFunc("wasmGenerateCSV", wrapGenerateCSV)
+    exportFunc("wasmCSVToJSON", wrapCSVToJSON)
+    exportFunc("wasmCSVToColumns", wrapCSVToColumns)
+    exportFunc("wasmJSONToCSV", wrapJSONToCSV)
+    exportFunc("wasmValidateCSV", wrapValidateCSV)
+    exportFunc("wasmValidateEmails", wrapValidateEmails)
+    exportFunc("wasmRegexValidate", wrapRegexValidate)
+    exportFunc("wasmEnumValidate", wrapEnumValidate)
+    exportFunc("wasmRangeCheck", wrapRangeCheck)
+    exportFunc("wasmCheckMonotonic", wrapCheckMonotonic)
+    exportFunc("wasmDuplicateHeaders", wrapDuplicateHeaders)
+    exportFunc("wasmInferSchema", wrapInferSchema)
+    exportFunc("wasmProfile", wrapProfile)
+    exportFunc("wasmTypeConfidence", wrapTypeConfidence)
+    exportFunc("wasmWhitespaceReport", wrapWhitespaceReport)
+    exportFunc("wasmIsRectangular", wrapIsRectangular)
+    exportFunc("wasmRectangularize", wrapRectangularize)
+    exportFunc("wasmSelectColumns", wrapSelectColumns)
+    exportFunc("wasmGroupByCount", wrapGroupByCount)
+    exportFunc("wasmGroupBySum", wrapGroupBySum)
+    exportFunc("wasmGroupByShare", wrapGroupByShare)
+    exportFunc("wasmCountWhere", wrapCountWhere)
+    exportFunc("wasmUniqueRows", wrapUniqueRows)
+    exportFunc("wasmFilterRows", wrapFilterRows)
+    exportFunc("wasmSearchAny", wrapSearchAny)
+    exportFunc("wasmSortByColumn", wrapSortByColumn)
+    exportFunc("wasmRemapColumn", wrapRemapColumn)
+    exportFunc("wasmMapColumn", wrapMapColumn)
+    exportFunc("wasmRenameHeaders", wrapRenameHeaders)
+    exportFunc("wasmRedactColumns", wrapRedactColumns)
+    exportFunc("wasmCoalesceColumns", wrapCoalesceColumns)
+    exportFunc("wasmSplitColumn", wrapSplitColumn)
+    exportFunc("wasmFillDown", wrapFillDown)
+    exportFunc("wasmCleanControlChars", wrapCleanControlChars)
+    exportFunc("wasmNormalizeDates", wrapNormalizeDates)
+    exportFunc("wasmCountInDateRange", wrapCountInDateRange)
+    exportFunc("wasmApplyExpr", wrapApplyExpr)
+    exportFunc("wasmImputeMean", wrapImputeMean)
+    exportFunc("wasmRowDeltas", wrapRowDeltas)
+    exportFunc("wasmColumnDiff", wrapColumnDiff)
+    exportFunc("wasmMovingAverage", wrapMovingAverage)
+    exportFunc("wasmJoinCSV", wrapJoinCSV)
+    exportFunc("wasmMatchHeaders", wrapMatchHeaders)
+    exportFunc("wasmDiffCSV", wrapDiffCSV)
+    exportFunc("wasmPivot", wrapPivot)
+    exportFunc("wasmCrosstab", wrapCrosstab)
+    exportFunc("wasmSliceCSV", wrapSliceCSV)
+    exportFunc("wasmTransposeCSV", wrapTransposeCSV)
+    exportFunc("wasmCanonicalize", wrapCanonicalize)
+    exportFunc("wasmRequote", wrapRequote)
+    exportFunc("wasmReverseRows", wrapReverseRows)
+    exportFunc("wasmChunkCSV", wrapChunkCSV)
+    exportFunc("wasmPartitionByColumn", wrapPartitionByColumn)
+    exportFunc("wasmMergeCSV", wrapMergeCSV)
+    exportFunc("wasmConcatAligned", wrapConcatAligned)
+    exportFunc("wasmDistinctValues", wrapDistinctValues)
+    exportFunc("wasmTermFrequency", wrapTermFrequency)
+    exportFunc("wasmWordCounts", wrapWordCounts)
+    exportFunc("wasmTopValues", wrapTopValues)
+    exportFunc("wasmColumnMode", wrapColumnMode)
+    exportFunc("wasmWeightedMean", wrapWeightedMean)
+    exportFunc("wasmHistogram", wrapHistogram)
+    exportFunc("wasmCorrelation", wrapCorrelation)
+    exportFunc("wasmQuantiles", wrapQuantiles)
+    exportFunc("wasmDetectOutliers", wrapDetectOutliers)
+    exportFunc("wasmColumnEntropy", wrapColumnEntropy)
+    exportFunc("wasmColumnMatch", wrapColumnMatch)
+    exportFunc("wasmRenderTable", wrapRenderTable)
+    exportFunc("wasmCSVToMarkdown", wrapCSVToMarkdown)
+    exportFunc("wasmCSVToFixedWidth", wrapCSVToFixedWidth)
+    exportFunc("wasmWrapCells", wrapWrapCells)
+    exportFunc("wasmParse", wrapParse)
+    exportFunc("wasmTableStats", wrapTableStats)
+    exportFunc("wasmTablePreview", wrapTablePreview)
+    exportFunc("wasmTableFree", wrapTableFree)
+    exportFunc("wasmNewToken", wrapNewToken)
+    exportFunc("wasmCancel", wrapCancel)
+    exportFunc("wasmFreeToken", wrapFreeToken)
+    exportFunc("wasmUppercase", wrapUppercase)
+    exportFunc("wasmLowercase", wrapLowercase)
+    exportFunc("wasmTitlecase", wrapTitlecase)
+    exportFunc("wasmNormalizeNFC", wrapNormalizeNFC)
+    exportFunc("wasmNormalizeNFD", wrapNormalizeNFD)
+    exportFunc("wasmStringInfo", wrapStringInfo)
+    exportFunc("wasmEscapeField", wrapEscapeField)
+    exportFunc("wasmUnescapeField", wrapUnescapeField)
+    exportFunc("wasmBase64Encode", wrapBase64Encode)
+    exportFunc("wasmBase64Decode", wrapBase64Decode)
+    exportFunc("wasmToDataURL", wrapToDataURL)
+    exportFunc("wasmSHA256", wrapSHA256)
+    exportFunc("wasmMinhash", wrapMinhash)
+    exportFunc("wasmRowHashes", wrapRowHashes)
+    exportFunc("wasmCheckEncoding", wrapCheckEncoding)
+    exportFunc("wasmGuessCharset", wrapGuessCharset)
+    exportFunc("wasmLatin1ToUTF8", wrapLatin1ToUTF8)
+    exportFunc("wasmLineEndings", wrapLineEndings)
+    exportFunc("wasmShutdown", wrapShutdown)
+    exportFunc("wasmInit", wrapInit)
+    exportFunc("wasmVersion", wrapVersion)
+    exportFunc("wasmCapabilities", wrapCapabilities)
+    exportFunc("wasmMemStats", wrapMemStats)
+    exportFunc("wasmSetLogLevel", wrapSetLogLevel)
 
-    // Block forever so that exported functions remain available to JS.
-    <-make(chan struct{})
+    // Block until wasmShutdown so that exported functions remain available to JS.
+    <-done
 }
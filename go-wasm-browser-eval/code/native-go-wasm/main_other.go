@@ -0,0 +1,15 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+    "fmt"
+    "os"
+)
+
+// main only explains itself outside WebAssembly: the exports need syscall/js, so this
+// build exists for go test and go vet over the pure helpers.
+func main() {
+    fmt.Fprintln(os.Stderr, "native-go-wasm runs as WebAssembly; build it with GOOS=js GOARCH=wasm (see build_native.sh)")
+    os.Exit(1)
+}
@@ -0,0 +1,953 @@
+//go:build js && wasm
+
+package main
+
+import (
+    "fmt"
+    "math"
+    "reflect"
+    "strings"
+    "syscall/js"
+    "testing"
+)
+
+// call invokes a wrapper the way wasm_exec would, converting Go arguments with
+// js.ValueOf and the result back to plain Go data with fromJS, so numbers come back as
+// float64 and objects as map[string]any.
+func call(fn func(this js.Value, args []js.Value) any, args ...any) any {
+    values := make([]js.Value, len(args))
+    for i, arg := range args {
+        values[i] = js.ValueOf(arg)
+    }
+    return fromJS(js.ValueOf(fn(js.Undefined(), values)))
+}
+
+// callMap is call for wrappers that return an object.
+func callMap(t *testing.T, fn func(this js.Value, args []js.Value) any, args ...any) map[string]any {
+    t.Helper()
+    result, ok := call(fn, args...).(map[string]any)
+    if !ok {
+        t.Fatalf("result %v is not an object", call(fn, args...))
+    }
+    return result
+}
+
+// wantError fails unless result is an error map with the given code and message. An
+// empty message matches any.
+func wantError(t *testing.T, result any, code, message string) {
+    t.Helper()
+    m, ok := result.(map[string]any)
+    if !ok || m["code"] != code || message != "" && m["message"] != message {
+        t.Fatalf("got %v; want a %s error %q", result, code, message)
+    }
+}
+
+// uint8Array copies data into a new JS Uint8Array.
+func uint8Array(data []byte) js.Value {
+    array := js.Global().Get("Uint8Array").New(len(data))
+    js.CopyBytesToJS(array, data)
+    return array
+}
+
+// wantEqual fails when got and want differ under reflect.DeepEqual.
+func wantEqual(t *testing.T, got, want any) {
+    t.Helper()
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("got %#v; want %#v", got, want)
+    }
+}
+
+func TestWrapCSVSummaryDelimiter(t *testing.T) {
+    for _, delimiter := range []string{"\t", ";", "|"} {
+        text := "a" + delimiter + "b\n1" + delimiter + "2\n"
+        result := callMap(t, wrapCSVSummary, text, delimiter, false)
+        if result["columns"] != 2.0 || result["rows"] != 2.0 {
+            t.Errorf("%q: columns, rows = %v, %v; want 2, 2", delimiter, result["columns"], result["rows"])
+        }
+    }
+    result := callMap(t, wrapCSVSummary, "a,b\n1,2\n", "", false)
+    if result["columns"] != 2.0 {
+        t.Errorf("empty delimiter: columns = %v; want the comma default's 2", result["columns"])
+    }
+    wantError(t, call(wrapCSVSummary, "a;;b\n", ";;"), codeBadArgument, "delimiter must be a single character")
+}
+
+func TestWrapCSVSummaryHeaders(t *testing.T) {
+    result := callMap(t, wrapCSVSummary, "a,b\n1,2\n", ",", true)
+    wantEqual(t, result["headers"], []any{"a", "b"})
+    wantEqual(t, result["rows"], 1.0)
+    result = callMap(t, wrapCSVSummary, "", ",", true)
+    wantEqual(t, result["headers"], []any{})
+}
+
+func TestWrapCSVSummaryParseError(t *testing.T) {
+    options := map[string]any{"strict": true, "delimiter": ","}
+    result := callMap(t, wrapCSVSummary, "a,b\n1,2\n3,4,5\n", options)
+    wantError(t, result, codeParseError, "wrong number of fields")
+    wantEqual(t, result["errorLine"], 3.0)
+    wantEqual(t, result["errorColumn"], 1.0)
+}
+
+func TestWrapCSVPreview(t *testing.T) {
+    result := callMap(t, wrapCSVPreview, "a,b\n1,2\n3,4\n", 1, ",", true)
+    wantEqual(t, result["preview"], []any{[]any{"1", "2"}})
+    wantError(t, call(wrapCSVPreview, "a,b\n"), codeBadArgument, "expected a CSV string and a row count")
+}
+
+func TestWrapCSVToJSON(t *testing.T) {
+    got := call(wrapCSVToJSON, "a,a\n1,2\n")
+    wantEqual(t, got, []any{map[string]any{"a": "1", "a_2": "2"}})
+    got = call(wrapCSVToJSON, "n,s\n1,a\n2,3\n", true)
+    wantEqual(t, got, []any{map[string]any{"n": 1.0, "s": "a"}, map[string]any{"n": 2.0, "s": "3"}})
+}
+
+func TestWrapJSONToCSV(t *testing.T) {
+    got := call(wrapJSONToCSV, []any{map[string]any{"b": 2, "a": "x,y"}})
+    wantEqual(t, got, "a,b\n\"x,y\",2\n")
+    wantError(t, call(wrapJSONToCSV, []any{"nope"}), codeBadArgument, "element 0 is not an object")
+}
+
+func TestWrapCSVSummaryTrimSpace(t *testing.T) {
+    options := map[string]any{"delimiter": ",", "header": true, "trimSpace": true}
+    result := callMap(t, wrapCSVSummary, "n\n 42 \n", options)
+    wantEqual(t, result["types"], []any{"integer"})
+    delete(options, "trimSpace")
+    result = callMap(t, wrapCSVSummary, "n\n 42 \n", options)
+    wantEqual(t, result["types"], []any{"string"})
+}
+
+func TestWrapVersion(t *testing.T) {
+    result := callMap(t, wrapVersion)
+    if result["compiler"] == "" || result["compiler"] == nil {
+        t.Errorf("compiler = %v; want a toolchain name", result["compiler"])
+    }
+    if result["goVersion"] == "" {
+        t.Error("goVersion is empty")
+    }
+}
+
+func TestSingleCharacterOptions(t *testing.T) {
+    tests := []struct {
+        name    string
+        result  any
+        message string
+    }{
+        {"delimiter", call(wrapCSVSummary, "a\n", map[string]any{"delimiter": "::"}), errBadDelimiter.Error()},
+        {"comment", call(wrapCSVSummary, "a\n", map[string]any{"comment": "//"}), errBadComment.Error()},
+        {"decimalSeparator", call(wrapCSVSummary, "a\n", map[string]any{"decimalSeparator": ",,"}), errBadDecimal.Error()},
+        {"pad", call(wrapCSVToFixedWidth, "a\n", []any{3}, "--"), errBadPad.Error()},
+        {"mask", call(wrapRedactColumns, "a\n", []any{0}, "##"), errBadMask.Error()},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            wantError(t, tt.result, codeBadArgument, tt.message)
+        })
+    }
+}
+
+func TestWrapCaseHelpers(t *testing.T) {
+    tests := []struct {
+        name string
+        fn   func(this js.Value, args []js.Value) any
+        in   string
+        want string
+    }{
+        {"lower ascii", wrapLowercase, "ISTANBUL", "istanbul"},
+        {"lower dotted capital I", wrapLowercase, "İSTANBUL", "istanbul"},
+        {"lower greek", wrapLowercase, "ΑΘΗΝΑ", "αθηνα"},
+        {"title", wrapTitlecase, "istanbul straße", "Istanbul Straße"},
+        {"title lowers the rest", wrapTitlecase, "éCOLE ÉTÉ", "École Été"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            wantEqual(t, call(tt.fn, tt.in), tt.want)
+        })
+    }
+    for _, fn := range []func(this js.Value, args []js.Value) any{wrapLowercase, wrapTitlecase, wrapUppercase} {
+        wantEqual(t, call(fn), "")
+    }
+}
+
+func TestWrapValidateCSV(t *testing.T) {
+    options := map[string]any{"header": true}
+    wantEqual(t, call(wrapValidateCSV, "a\n1\n", []any{"int"}, options), []any{})
+    got := call(wrapValidateCSV, "a\nx\n", []any{"int"}, options)
+    wantEqual(t, got, []any{map[string]any{"line": 2.0, "column": 0.0, "value": "x", "message": "expected int"}})
+    wantError(t, call(wrapValidateCSV, "a\n"), codeBadArgument, "")
+}
+
+func TestWrapCSVSummaryComment(t *testing.T) {
+    result := callMap(t, wrapCSVSummary, "a,b\n#x\n1,2\n", map[string]any{"header": true, "comment": "#"})
+    wantEqual(t, result["rows"], 1.0)
+    wantError(t, call(wrapCSVSummary, "a,b\n", map[string]any{"comment": ","}), codeBadArgument, "comment character must differ from the delimiter")
+}
+
+func TestWrapGzipCSVSummary(t *testing.T) {
+    result := callMap(t, wrapGzipCSVSummary, uint8Array(gzipped(t, "a,b\n1,2\n")), map[string]any{"header": true})
+    wantEqual(t, result["rows"], 1.0)
+    wantError(t, call(wrapGzipCSVSummary, uint8Array([]byte("a,b\n"))), codeParseError, "")
+    wantError(t, call(wrapGzipCSVSummary, "a,b\n"), codeBadArgument, "expected a Uint8Array")
+}
+
+// summaryInput is a mixed-type CSV, with quoting and multibyte text, used to compare the
+// string and Uint8Array entry points.
+var summaryInput = "id,name,score\n" + strings.Repeat("1,\"Zoë, A\",3.5\n2,bob,\n", 500)
+
+func TestWrapCSVSummaryBytesMatchesString(t *testing.T) {
+    options := map[string]any{"header": true, "stats": true}
+    fromString := callMap(t, wrapCSVSummary, summaryInput, options)
+    fromBytes := callMap(t, wrapCSVSummaryBytes, uint8Array([]byte(summaryInput)), options)
+    wantEqual(t, fromBytes, fromString)
+}
+
+func BenchmarkWrapCSVSummaryString(b *testing.B) {
+    for b.Loop() {
+        call(wrapCSVSummary, summaryInput, ",", true)
+    }
+}
+
+func BenchmarkWrapCSVSummaryBytes(b *testing.B) {
+    data := uint8Array([]byte(summaryInput))
+    for b.Loop() {
+        call(wrapCSVSummaryBytes, data, ",", true)
+    }
+}
+
+func TestWrapSelectColumns(t *testing.T) {
+    wantEqual(t, call(wrapSelectColumns, "a,b\n1,2\n", []any{1, 0}), []any{[]any{"b", "a"}, []any{"2", "1"}})
+    wantError(t, call(wrapSelectColumns, "a,b\n1,2\n", []any{5}), codeBadArgument, "column index 5 out of range (table has 2 columns)")
+}
+
+func TestWrapGroupByCount(t *testing.T) {
+    text := "team,n\nred,1\n,2\nred,3\n"
+    got := call(wrapGroupByCount, text, 0, map[string]any{"header": true})
+    wantEqual(t, got, map[string]any{"red": 2.0, "": 1.0})
+    wantError(t, call(wrapGroupByCount, text, 3, map[string]any{"header": true}), codeBadArgument, "column index 3 out of range (table has 2 columns)")
+}
+
+func TestWrapCSVSummaryProgress(t *testing.T) {
+    calls := 0
+    onProgress := js.FuncOf(func(this js.Value, args []js.Value) any {
+        calls++
+        return nil
+    })
+    defer onProgress.Release()
+    text := "n\n" + strings.Repeat("1\n", 2*progressEvery)
+    result := callMap(t, wrapCSVSummary, text, map[string]any{"header": true, "onProgress": onProgress})
+    wantEqual(t, result["rows"], float64(2*progressEvery))
+    if calls == 0 {
+        t.Error("onProgress was never called")
+    }
+}
+
+// argumentWrappers are the exports that need at least one argument; called with none
+// they must return a bad_argument error map rather than panic.
+var argumentWrappers = map[string]func(this js.Value, args []js.Value) any{
+    "wrapCancel":            wrapCancel,
+    "wrapFreeToken":         wrapFreeToken,
+    "wrapCheckEncoding":     wrapCheckEncoding,
+    "wrapGuessCharset":      wrapGuessCharset,
+    "wrapLatin1ToUTF8":      wrapLatin1ToUTF8,
+    "wrapLineEndings":       wrapLineEndings,
+    "wrapSHA256":            wrapSHA256,
+    "wrapMinhash":           wrapMinhash,
+    "wrapRowHashes":         wrapRowHashes,
+    "wrapSetLogLevel":       wrapSetLogLevel,
+    "wrapQuickStats":        wrapQuickStats,
+    "wrapEstimateRows":      wrapEstimateRows,
+    "wrapParse":             wrapParse,
+    "wrapTableStats":        wrapTableStats,
+    "wrapTablePreview":      wrapTablePreview,
+    "wrapTableFree":         wrapTableFree,
+    "wrapStreamPush":        wrapStreamPush,
+    "wrapStreamFinish":      wrapStreamFinish,
+    "wrapToDataURL":         wrapToDataURL,
+    "wrapCSVSummary":        wrapCSVSummary,
+    "wrapCSVSummaryBytes":   wrapCSVSummaryBytes,
+    "wrapSniffFormat":       wrapSniffFormat,
+    "wrapGzipCSVSummary":    wrapGzipCSVSummary,
+    "wrapNDJSONSummary":     wrapNDJSONSummary,
+    "wrapCSVPreview":        wrapCSVPreview,
+    "wrapSampleRows":        wrapSampleRows,
+    "wrapColumnSamples":     wrapColumnSamples,
+    "wrapGenerateCSV":       wrapGenerateCSV,
+    "wrapCSVToJSON":         wrapCSVToJSON,
+    "wrapCSVToColumns":      wrapCSVToColumns,
+    "wrapJSONToCSV":         wrapJSONToCSV,
+    "wrapValidateCSV":       wrapValidateCSV,
+    "wrapValidateEmails":    wrapValidateEmails,
+    "wrapWhitespaceReport":  wrapWhitespaceReport,
+    "wrapTypeConfidence":    wrapTypeConfidence,
+    "wrapProfile":           wrapProfile,
+    "wrapIsRectangular":     wrapIsRectangular,
+    "wrapRectangularize":    wrapRectangularize,
+    "wrapInferSchema":       wrapInferSchema,
+    "wrapSelectColumns":     wrapSelectColumns,
+    "wrapGroupByCount":      wrapGroupByCount,
+    "wrapGroupBySum":        wrapGroupBySum,
+    "wrapGroupByShare":      wrapGroupByShare,
+    "wrapSortByColumn":      wrapSortByColumn,
+    "wrapCountWhere":        wrapCountWhere,
+    "wrapChunkCSV":          wrapChunkCSV,
+    "wrapUniqueRows":        wrapUniqueRows,
+    "wrapFilterRows":        wrapFilterRows,
+    "wrapSearchAny":         wrapSearchAny,
+    "wrapJoinCSV":           wrapJoinCSV,
+    "wrapMatchHeaders":      wrapMatchHeaders,
+    "wrapPivot":             wrapPivot,
+    "wrapCrosstab":          wrapCrosstab,
+    "wrapDiffCSV":           wrapDiffCSV,
+    "wrapPartitionByColumn": wrapPartitionByColumn,
+    "wrapMergeCSV":          wrapMergeCSV,
+    "wrapConcatAligned":     wrapConcatAligned,
+    "wrapRemapColumn":       wrapRemapColumn,
+    "wrapCoalesceColumns":   wrapCoalesceColumns,
+    "wrapSplitColumn":       wrapSplitColumn,
+    "wrapRedactColumns":     wrapRedactColumns,
+    "wrapFillDown":          wrapFillDown,
+    "wrapCleanControlChars": wrapCleanControlChars,
+    "wrapMapColumn":         wrapMapColumn,
+    "wrapRenameHeaders":     wrapRenameHeaders,
+    "wrapNormalizeDates":    wrapNormalizeDates,
+    "wrapCountInDateRange":  wrapCountInDateRange,
+    "wrapApplyExpr":         wrapApplyExpr,
+    "wrapImputeMean":        wrapImputeMean,
+    "wrapRowDeltas":         wrapRowDeltas,
+    "wrapColumnDiff":        wrapColumnDiff,
+    "wrapMovingAverage":     wrapMovingAverage,
+    "wrapSliceCSV":          wrapSliceCSV,
+    "wrapReverseRows":       wrapReverseRows,
+    "wrapCanonicalize":      wrapCanonicalize,
+    "wrapRequote":           wrapRequote,
+    "wrapTransposeCSV":      wrapTransposeCSV,
+    "wrapTermFrequency":     wrapTermFrequency,
+    "wrapWordCounts":        wrapWordCounts,
+    "wrapTopValues":         wrapTopValues,
+    "wrapDistinctValues":    wrapDistinctValues,
+    "wrapCorrelation":       wrapCorrelation,
+    "wrapColumnMatch":       wrapColumnMatch,
+    "wrapRegexValidate":     wrapRegexValidate,
+    "wrapDuplicateHeaders":  wrapDuplicateHeaders,
+    "wrapEnumValidate":      wrapEnumValidate,
+    "wrapRangeCheck":        wrapRangeCheck,
+    "wrapCheckMonotonic":    wrapCheckMonotonic,
+    "wrapColumnEntropy":     wrapColumnEntropy,
+    "wrapQuantiles":         wrapQuantiles,
+    "wrapDetectOutliers":    wrapDetectOutliers,
+    "wrapWeightedMean":      wrapWeightedMean,
+    "wrapColumnMode":        wrapColumnMode,
+    "wrapHistogram":         wrapHistogram,
+    "wrapRenderTable":       wrapRenderTable,
+    "wrapCSVToMarkdown":     wrapCSVToMarkdown,
+    "wrapWrapCells":         wrapWrapCells,
+    "wrapCSVToFixedWidth":   wrapCSVToFixedWidth,
+}
+
+func TestWrappersRequireArguments(t *testing.T) {
+    for name, fn := range argumentWrappers {
+        t.Run(name, func(t *testing.T) {
+            result := call(fn)
+            wantError(t, result, codeBadArgument, "")
+            if m := result.(map[string]any); m["error"] != m["message"] {
+                t.Errorf("error %q and message %q differ", m["error"], m["message"])
+            }
+        })
+    }
+}
+
+func TestWrappersReportParseErrors(t *testing.T) {
+    const bad = "a,b\n\"unterminated,1\n"
+    tests := []struct {
+        name   string
+        result any
+    }{
+        {"wasmCSVSummary", call(wrapCSVSummary, bad)},
+        {"wasmCSVToJSON", call(wrapCSVToJSON, bad)},
+        {"wasmCSVPreview", call(wrapCSVPreview, bad, 5)},
+        {"wasmSelectColumns", call(wrapSelectColumns, bad, []any{0})},
+        {"wasmInferSchema", call(wrapInferSchema, bad)},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            wantError(t, tt.result, codeParseError, "")
+        })
+    }
+}
+
+func TestWrapCSVSummaryDetectsDelimiter(t *testing.T) {
+    result := callMap(t, wrapCSVSummary, "a\tb\n1\t2\n")
+    wantEqual(t, result["detectedDelimiter"], "\t")
+    wantEqual(t, result["columns"], 2.0)
+    result = callMap(t, wrapCSVSummary, "a\tb\n1\t2\n", ";")
+    if _, ok := result["detectedDelimiter"]; ok {
+        t.Error("detectedDelimiter reported for an explicit delimiter")
+    }
+}
+
+func TestWrapBase64(t *testing.T) {
+    for _, text := range []string{"", "hello", "Zoë 東京 🙂", "a\x00b"} {
+        encoded := call(wrapBase64Encode, text)
+        wantEqual(t, call(wrapBase64Decode, encoded), text)
+    }
+    wantEqual(t, call(wrapBase64Encode, "Zoë"), "Wm/Dqw==")
+    for _, bad := range []string{"Wm/Dqw=", "not base64!", "Wm/Dqw==="} {
+        result := call(wrapBase64Decode, bad)
+        wantError(t, result, codeBadArgument, "")
+        if msg := result.(map[string]any)["message"].(string); !strings.HasPrefix(msg, "invalid base64: ") {
+            t.Errorf("%q: message %q does not say the input is invalid base64", bad, msg)
+        }
+    }
+}
+
+func TestWrapSHA256(t *testing.T) {
+    const (
+        emptyDigest = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+        abcDigest   = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+    )
+    wantEqual(t, call(wrapSHA256, ""), emptyDigest)
+    wantEqual(t, call(wrapSHA256, "abc"), abcDigest)
+    wantEqual(t, call(wrapSHA256, uint8Array(nil)), emptyDigest)
+    wantEqual(t, call(wrapSHA256, uint8Array([]byte("abc"))), abcDigest)
+    wantError(t, call(wrapSHA256, 42), codeBadArgument, "expected a string or Uint8Array")
+}
+
+func TestWrapNormalize(t *testing.T) {
+    const decomposed, composed = "e\u0301", "\u00e9"
+    nfc := call(wrapNormalizeNFC, "caf"+decomposed).(string)
+    wantEqual(t, nfc, "caf"+composed)
+    if n := len([]rune(nfc)); n != 4 {
+        t.Errorf("NFC form has %d code points; want 4", n)
+    }
+    wantEqual(t, call(wrapNormalizeNFD, "caf"+composed), "caf"+decomposed)
+    wantEqual(t, call(wrapNormalizeNFC, "plain"), "plain")
+    wantEqual(t, call(wrapNormalizeNFC), "")
+    wantEqual(t, call(wrapNormalizeNFD), "")
+}
+
+func TestWrapTableLifecycle(t *testing.T) {
+    handle := call(wrapParse, "a,b\n1,2\n3,4\n", map[string]any{"header": true})
+    wantEqual(t, call(wrapTablePreview, handle, 1), []any{[]any{"1", "2"}})
+    wantEqual(t, callMap(t, wrapTableStats, handle)["rows"], 2.0)
+    wantEqual(t, call(wrapTableFree, handle), nil)
+    wantError(t, call(wrapTableFree, handle), codeBadArgument, "")
+    wantError(t, call(wrapTableStats, handle), codeBadArgument, "")
+    wantError(t, call(wrapTablePreview, "nope", 1), codeBadArgument, "expected a table handle")
+}
+
+func TestWrapCheckEncoding(t *testing.T) {
+    wantEqual(t, call(wrapCheckEncoding, uint8Array([]byte("ab\x80"))), map[string]any{"valid": false, "firstBadOffset": 2.0})
+    wantError(t, call(wrapCheckEncoding, "text"), codeBadArgument, "expected a Uint8Array")
+}
+
+func TestWrapDistinctValues(t *testing.T) {
+    text := "fruit\npear\napple\npear\n"
+    got := call(wrapDistinctValues, text, 0, 1, map[string]any{"header": true})
+    wantEqual(t, got, map[string]any{"values": []any{"apple"}, "truncated": true})
+    got = call(wrapDistinctValues, text, 0, nil, map[string]any{"header": true})
+    wantEqual(t, got, map[string]any{"values": []any{"apple", "pear"}, "truncated": false})
+}
+
+func TestWrapCSVSummaryDetectsHeader(t *testing.T) {
+    result := callMap(t, wrapCSVSummary, "name,age\nann,30\n")
+    wantEqual(t, result["headerDetected"], true)
+    wantEqual(t, result["headers"], []any{"name", "age"})
+    result = callMap(t, wrapCSVSummary, "1,2\n3,4\n")
+    wantEqual(t, result["headerDetected"], false)
+    result = callMap(t, wrapCSVSummary, "name,age\nann,30\n", ",", false)
+    if _, ok := result["headerDetected"]; ok {
+        t.Error("headerDetected reported for an explicit header flag")
+    }
+}
+
+func TestWrapUnescapeField(t *testing.T) {
+    wantEqual(t, call(wrapUnescapeField, call(wrapEscapeField, "a,\"b\"\r\n")), "a,\"b\"\r\n")
+    wantError(t, call(wrapUnescapeField, `"abc`), codeBadArgument, "quoted field is missing its closing quote")
+}
+
+func TestWrapRemapColumn(t *testing.T) {
+    got := call(wrapRemapColumn, "a\nY\nN\n", 0, map[string]any{"Y": "Yes"}, map[string]any{"header": true})
+    wantEqual(t, got, "a\nYes\nN\n")
+    wantError(t, call(wrapRemapColumn, "a\nY\n", 0, map[string]any{"Y": 1}), codeBadArgument, `mapping for "Y" is not a string`)
+}
+
+func TestWrapCorrelation(t *testing.T) {
+    got := call(wrapCorrelation, "x,y\n1,2\n2,4\n3,6\n", 0, 1, map[string]any{"header": true})
+    if r, ok := got.(float64); !ok || math.Abs(r-1) > 1e-9 {
+        t.Errorf("wasmCorrelation = %v; want 1", got)
+    }
+    wantError(t, call(wrapCorrelation, "x,y\n1,2\n", 0, 1, map[string]any{"header": true}), codeBadArgument, "correlation needs at least two rows where both columns are numeric, got 1")
+    wantError(t, call(wrapCorrelation, "x,y\n1,2\n", 0, 2), codeBadArgument, "")
+}
+
+func TestWrapTermFrequency(t *testing.T) {
+    got := call(wrapTermFrequency, "msg\nError\nerror x2 error\n", 0, []any{"ERROR", "none"}, map[string]any{"header": true})
+    wantEqual(t, got, map[string]any{"ERROR": 2.0, "none": 0.0})
+    wantError(t, call(wrapTermFrequency, "a\n1\n", 1, []any{"x"}), codeBadArgument, "column index 1 out of range (table has 1 columns)")
+}
+
+func TestWrapStringInfo(t *testing.T) {
+    tests := []struct {
+        name                    string
+        value                   string
+        bytes, runes, graphemes float64
+    }{
+        {"ascii", "abc", 3, 3, 3},
+        {"precomposed", "café", 5, 4, 4},
+        {"combining accent", "cafe\u0301", 6, 5, 4},
+        // man, woman, girl and boy joined by three zero-width joiners.
+        {"family emoji", "\U0001F468\u200d\U0001F469\u200d\U0001F467\u200d\U0001F466", 25, 7, 1},
+        {"flag", "\U0001F1EF\U0001F1F5", 8, 2, 1},
+        {"empty", "", 0, 0, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := call(wrapStringInfo, tt.value)
+            wantEqual(t, got, map[string]any{"bytes": tt.bytes, "runes": tt.runes, "graphemes": tt.graphemes})
+        })
+    }
+}
+
+func TestWrapIsRectangular(t *testing.T) {
+    wantEqual(t, call(wrapIsRectangular, "a;b\n1;2\n", ";"), map[string]any{"rectangular": true, "line": 0.0})
+    wantEqual(t, call(wrapIsRectangular, "a,b\n1\n"), map[string]any{"rectangular": false, "line": 2.0})
+}
+
+func TestWrapStreamStrict(t *testing.T) {
+    big := streamText()
+    handle := call(wrapStreamStart, map[string]any{"delimiter": ",", "header": true, "strict": true})
+    if result := call(wrapStreamPush, handle, uint8Array([]byte(big))); result != nil {
+        t.Fatalf("first push: %v", result)
+    }
+    wantError(t, call(wrapStreamPush, handle, uint8Array([]byte("1,2\n"))), codeParseError, "")
+    // A failed push releases the handle.
+    wantError(t, call(wrapStreamFinish, handle), codeBadArgument, fmt.Sprintf("unknown or finished stream handle %v", handle))
+}
+
+func TestWrapCSVToFixedWidth(t *testing.T) {
+    wantEqual(t, call(wrapCSVToFixedWidth, "a,b\n", []any{2, 3}), "a b  \n")
+    wantEqual(t, call(wrapCSVToFixedWidth, "a,b\n", []any{2, 3}, "0"), "a0b00\n")
+}
+
+func TestWrapQuantiles(t *testing.T) {
+    got := call(wrapQuantiles, "v\n1\n2\n3\n4\n", 0, []any{0.5, 1}, map[string]any{"header": true})
+    wantEqual(t, got, []any{2.5, 4.0})
+    wantError(t, call(wrapQuantiles, "v\n1\n", 0, []any{2}), codeBadArgument, "quantile 2 is outside [0, 1]")
+}
+
+func TestWrapWordCounts(t *testing.T) {
+    got := call(wrapWordCounts, "text\nb a b\n", 0, 1, []any{"x"}, map[string]any{"header": true})
+    wantEqual(t, got, []any{map[string]any{"word": "b", "count": 2.0}})
+}
+
+func TestWrapSniffFormat(t *testing.T) {
+    wantEqual(t, call(wrapSniffFormat, "a\tb\n1\t2\n"), map[string]any{"format": "tsv", "confidence": 1.0})
+}
+
+func TestWrapRedactColumns(t *testing.T) {
+    wantEqual(t, call(wrapRedactColumns, "a,b\nxy,é\n", []any{0, 1}), "*,*\n**,*\n")
+    wantEqual(t, call(wrapRedactColumns, "a,b\nxy,é\n", []any{1}, "•", map[string]any{"header": true}), "a,b\nxy,•\n")
+}
+
+func TestWrapDiffCSV(t *testing.T) {
+    got := call(wrapDiffCSV, "k,v\n1,a\n2,b\n", "k,v\n2,c\n3,d\n", 0, map[string]any{"header": true})
+    wantEqual(t, got, map[string]any{"added": []any{"3"}, "removed": []any{"1"}, "changed": []any{"2"}})
+}
+
+func TestWrapFillDown(t *testing.T) {
+    wantEqual(t, call(wrapFillDown, "g,v\na,1\n,2\n", []any{0}, map[string]any{"header": true}), "g,v\na,1\na,2\n")
+    wantError(t, call(wrapFillDown, "g,v\n", []any{4}), codeBadArgument, "column index 4 out of range (table has 2 columns)")
+}
+
+func TestWrapCSVSummaryDialect(t *testing.T) {
+    // Neither delimiter nor header is given, so the dialect reports what was detected.
+    result := callMap(t, wrapCSVSummary, "name;score\r\n\"ann\";10\r\nbob;20\r\n")
+    wantEqual(t, result["dialect"], map[string]any{"delimiter": ";", "quoted": true, "crlf": true, "header": true})
+    wantEqual(t, result["detectedDelimiter"], ";")
+    wantEqual(t, result["headerDetected"], true)
+}
+
+func TestWrapPivot(t *testing.T) {
+    got := call(wrapPivot, "k,c,v\na,x,1\na,y,2\na,x,3\n", 0, 1, 2, "sum", map[string]any{"header": true})
+    wantEqual(t, got, "k,x,y\na,4,2\n")
+    wantError(t, call(wrapPivot, "k,c,v\n", 0, 1, 2, "avg"), codeBadArgument, `unknown aggregation "avg" (want sum, first or count)`)
+}
+
+func TestWrapStreamChecksum(t *testing.T) {
+    handle := call(wrapStreamStart, map[string]any{"checksum": "sha256"})
+    for _, piece := range []string{"a,b\n1", ",2\n"} {
+        if result := call(wrapStreamPush, handle, uint8Array([]byte(piece))); result != nil {
+            t.Fatalf("push %q: %v", piece, result)
+        }
+    }
+    result, _ := call(wrapStreamFinish, handle).(map[string]any)
+    // sha256 of "a,b\n1,2\n".
+    wantEqual(t, result["checksum"], "492d5ea496056f1a6a6592241032fab764c321596317930b4fa0e1e8bc3b7470")
+    wantError(t, call(wrapStreamStart, map[string]any{"checksum": "md5"}), codeBadArgument, `unknown checksum "md5" (want crc32 or sha256)`)
+}
+
+func TestWrapColumnSamples(t *testing.T) {
+    got := call(wrapColumnSamples, "a,b\n1,x\n1,y\n", 2, map[string]any{"header": true})
+    wantEqual(t, got, []any{[]any{"1"}, []any{"x", "y"}})
+    wantError(t, call(wrapColumnSamples, "a\n", -1), codeBadArgument, "values per column must be positive, got -1")
+}
+
+func TestWrapCleanControlChars(t *testing.T) {
+    wantEqual(t, call(wrapCleanControlChars, "a\x00b\v\n", " "), "a b \n")
+}
+
+func TestWrapToDataURL(t *testing.T) {
+    wantEqual(t, call(wrapToDataURL, "a,b\n"), "data:text/csv;base64,YSxiCg==")
+    wantError(t, call(wrapToDataURL, "a", "text/"), codeBadArgument, "")
+}
+
+func TestWrapLineEndings(t *testing.T) {
+    wantEqual(t, call(wrapLineEndings, uint8Array([]byte("a\r\nb\n"))), map[string]any{"lf": 1.0, "crlf": 1.0, "cr": 0.0, "mixed": true})
+    wantError(t, call(wrapLineEndings, "a\n"), codeBadArgument, "expected a Uint8Array")
+}
+
+func TestWrapRenameHeaders(t *testing.T) {
+    wantEqual(t, call(wrapRenameHeaders, "old,x\n1,2\n", map[string]any{"old": "new"}), "new,x\n1,2\n")
+    wantError(t, call(wrapRenameHeaders, "a,b\n", "nope"), codeBadArgument, "mapping must be an object of strings")
+}
+
+func TestWrapColumnMatch(t *testing.T) {
+    const text = "a,b\nx,X\ny,y\n"
+    wantEqual(t, call(wrapColumnMatch, text, 0, 1, map[string]any{"header": true}), map[string]any{"matches": 1.0, "mismatches": 1.0})
+    wantEqual(t, call(wrapColumnMatch, text, 0, 1, map[string]any{"header": true, "ignoreCase": true}), map[string]any{"matches": 2.0, "mismatches": 0.0})
+    wantError(t, call(wrapColumnMatch, text, 0, 2), codeBadArgument, "column index 2 out of range (table has 2 columns)")
+}
+
+func TestWrapWrapCells(t *testing.T) {
+    wantEqual(t, call(wrapWrapCells, "aaa bbb\n", 3), "\"aaa\nbbb\"\n")
+    wantError(t, call(wrapWrapCells, "a\n", -2), codeBadArgument, "wrap width must be positive, got -2")
+}
+
+func TestWrapNormalizeDates(t *testing.T) {
+    got := call(wrapNormalizeDates, "d\n2024/1/31\nnope\n", 0, []any{"2006/1/2"}, map[string]any{"header": true})
+    wantEqual(t, got, map[string]any{"csv": "d\n2024-01-31\nnope\n", "unparsed": 1.0})
+}
+
+func TestWrapGenerateCSV(t *testing.T) {
+    first := call(wrapGenerateCSV, 3, 2, 7)
+    wantEqual(t, call(wrapGenerateCSV, 3, 2, 7), first)
+    if text, _ := first.(string); strings.Count(text, "\n") != 4 {
+        t.Errorf("wasmGenerateCSV(3, 2, 7) = %q; want a header and three rows", text)
+    }
+}
+
+func TestWrapTopValues(t *testing.T) {
+    got := call(wrapTopValues, "team\nred\nblue\nred\n", 0, 1, map[string]any{"header": true})
+    wantEqual(t, got, []any{map[string]any{"value": "red", "count": 2.0}})
+    wantError(t, call(wrapTopValues, "a\n", 0, 0), codeBadArgument, "k must be positive, got 0")
+}
+
+func TestWrapCoalesceColumns(t *testing.T) {
+    got := call(wrapCoalesceColumns, "f,l\nAda,Lovelace\n", []any{0, 1}, " ", "name", true, map[string]any{"header": true})
+    wantEqual(t, got, "name\nAda Lovelace\n")
+}
+
+func TestWrapValidateEmails(t *testing.T) {
+    wantEqual(t, call(wrapValidateEmails, "e\na@b.c\nnope\n", 0, map[string]any{"header": true}), []any{3.0})
+}
+
+func TestWrapColumnEntropy(t *testing.T) {
+    // With the header skipped the column is constant; counted as data it is not.
+    wantEqual(t, call(wrapColumnEntropy, "a\nx\nx\n", 0, map[string]any{"header": true}), 0.0)
+    if got, _ := call(wrapColumnEntropy, "a\nx\n", 0, map[string]any{"header": false}).(float64); !(got > 0.99 && got < 1.01) {
+        t.Errorf("entropy with the header as data = %v; want 1", got)
+    }
+}
+
+func TestWrapApplyExpr(t *testing.T) {
+    got := call(wrapApplyExpr, "c\n100\nx\n", 0, "mul", 1.8, map[string]any{"header": true})
+    wantEqual(t, got, map[string]any{"csv": "c\n180\nx\n", "skipped": 1.0})
+    wantError(t, call(wrapApplyExpr, "c\n1\n", 0, "div", 0), codeBadArgument, "division by zero")
+}
+
+func TestWrapSetLogLevel(t *testing.T) {
+    saved := currentLogLevel.Load()
+    t.Cleanup(func() { currentLogLevel.Store(saved) })
+    currentLogLevel.Store(int32(levelWarn))
+    wantEqual(t, call(wrapSetLogLevel, "error"), "warn")
+    wantEqual(t, call(wrapSetLogLevel, "silent"), "error")
+    wantError(t, call(wrapSetLogLevel, "loud"), codeBadArgument, `unknown log level "loud" (want debug, info, warn, error or silent)`)
+    wantError(t, call(wrapSetLogLevel, 3), codeBadArgument, "expected a log level name")
+    if level := logLevel(currentLogLevel.Load()); level != levelSilent {
+        t.Errorf("a rejected name changed the level to %s", logLevelNames[level])
+    }
+}
+
+func TestLogSinkRoutesToConsole(t *testing.T) {
+    console := js.Global().Get("console")
+    var got []string
+    for _, method := range []string{"info", "error"} {
+        saved := console.Get(method)
+        capture := js.FuncOf(func(this js.Value, args []js.Value) any {
+            got = append(got, method+": "+args[0].String())
+            return nil
+        })
+        console.Set(method, capture)
+        t.Cleanup(func() {
+            console.Set(method, saved)
+            capture.Release()
+        })
+    }
+    saved := currentLogLevel.Load()
+    t.Cleanup(func() { currentLogLevel.Store(saved) })
+    currentLogLevel.Store(int32(levelInfo))
+    logf(levelInfo, "loaded %d rows", 3)
+    logf(levelError, "bad")
+    wantEqual(t, got, []string{"info: loaded 3 rows", "error: bad"})
+}
+
+func TestWrapSplitColumn(t *testing.T) {
+    got := call(wrapSplitColumn, "kv\na:1\n", 0, ":", 2, map[string]any{"header": true})
+    wantEqual(t, got, "kv_1,kv_2\na,1\n")
+}
+
+func TestWrapCSVToColumns(t *testing.T) {
+    got := call(wrapCSVToColumns, "x,y\n1,2\n3\n")
+    wantEqual(t, got, map[string]any{"x": []any{"1", "3"}, "y": []any{"2", ""}})
+}
+
+func TestWrapConcatAligned(t *testing.T) {
+    wantEqual(t, call(wrapConcatAligned, []any{"a,b\n1,2\n", "b,c\n3,4\n"}), "a,b,c\n1,2,\n,3,4\n")
+    wantError(t, call(wrapConcatAligned, "a\n"), codeBadArgument, "expected an array of CSV strings")
+}
+
+func TestWrapRegexValidate(t *testing.T) {
+    wantEqual(t, call(wrapRegexValidate, "y\n2024\n24\n", 0, `^\d{4}$`, map[string]any{"header": true}), []any{3.0})
+    wantError(t, call(wrapRegexValidate, "y\n2024\n", 0, "("), codeBadArgument, "")
+}
+
+func TestWrapInferSchemaProfileCSV(t *testing.T) {
+    got := call(wrapInferSchema, "n\n1\n3\n", map[string]any{"header": true, "profileCSV": true})
+    wantEqual(t, got, "name,type,nullable,distinctCount,min,max,mean\nn,integer,false,2,1,3,2\n")
+}
+
+func TestWrapCountInDateRange(t *testing.T) {
+    got := call(wrapCountInDateRange, "d\n2024-01-01\n2024-02-01\nnope\n", 0, "2006-01-02", "2024-01-01", "2024-01-31", map[string]any{"header": true})
+    wantEqual(t, got, map[string]any{"count": 1.0, "unparsed": 1.0})
+    wantError(t, call(wrapCountInDateRange, "d\n", 0, "2006-01-02"), codeBadArgument, "")
+}
+
+func TestWrapCapabilities(t *testing.T) {
+    wantEqual(t, callMap(t, wrapCapabilities), map[string]any{"gzip": true, "sha256": true, "normalize": true, "parallel": true})
+}
+
+func TestWrapRowDeltas(t *testing.T) {
+    wantEqual(t, call(wrapRowDeltas, "v\n1\n4\n", 0, map[string]any{"header": true}), "v,v_delta\n1,\n4,3\n")
+}
+
+func TestWrapDetectOutliers(t *testing.T) {
+    got := callMap(t, wrapDetectOutliers, "v\n1\n2\n3\n4\n100\n", 0, map[string]any{"header": true})
+    wantEqual(t, got["outliers"], []any{6.0})
+}
+
+func TestWrapCanonicalize(t *testing.T) {
+    wantEqual(t, call(wrapCanonicalize, "\"a\";\"b\"\r\n", map[string]any{"delimiter": ";"}), "a,b\n")
+}
+
+func TestWrapMinhash(t *testing.T) {
+    got := call(wrapMinhash, "v\nx\ny\n", 0, 4, map[string]any{"header": true})
+    wantEqual(t, call(wrapMinhash, "v\ny\nx\nx\n", 0, 4, map[string]any{"header": true}), got)
+    if sig, _ := got.([]any); len(sig) != 4 || len(sig[0].(string)) != 16 {
+        t.Errorf("wasmMinhash = %v; want four 16-digit hex strings", got)
+    }
+    wantError(t, call(wrapMinhash, "v\nx\n", 1, 4), codeBadArgument, "column index 1 out of range (table has 1 columns)")
+}
+
+func TestWrapGuessCharset(t *testing.T) {
+    wantEqual(t, call(wrapGuessCharset, uint8Array([]byte{0xff, 0xfe, 'a', 0})), map[string]any{"charset": "utf-16le", "confidence": 1.0, "bom": true})
+    wantError(t, call(wrapGuessCharset, "a"), codeBadArgument, "expected a Uint8Array")
+}
+
+func TestWrapCSVSummarySampleFraction(t *testing.T) {
+    got := callMap(t, wrapCSVSummary, "n\n1\n2\n3\n4\n", map[string]any{"header": true, "sampleFraction": 0.5, "sampleSeed": 1})
+    if got["approximate"] != true {
+        t.Errorf("approximate = %v; want true", got["approximate"])
+    }
+    wantError(t, call(wrapCSVSummary, "n\n1\n", map[string]any{"sampleFraction": 2}), codeBadArgument, "sampleFraction must be between 0 and 1, got 2")
+}
+
+func TestWrapSearchAny(t *testing.T) {
+    wantEqual(t, call(wrapSearchAny, "m\nError\nok\n", "error", true, false, map[string]any{"header": true}), "m\nError\n")
+    wantEqual(t, call(wrapSearchAny, "m\nok\n", "error", true, false, map[string]any{"header": true}), "m\n")
+}
+
+func TestWrapCSVSummaryFillRates(t *testing.T) {
+    got := callMap(t, wrapCSVSummary, "a,b\n1,\n2,x\n", map[string]any{"header": true})
+    wantEqual(t, got["fillRates"], []any{1.0, 0.5})
+}
+
+func TestWrapRequote(t *testing.T) {
+    wantEqual(t, call(wrapRequote, "n,s\n1,x\n", "nonnumeric"), "\"n\",\"s\"\n1,\"x\"\n")
+    wantError(t, call(wrapRequote, "a\n", "none"), codeBadArgument, "")
+}
+
+func TestWrapDuplicateHeaders(t *testing.T) {
+    wantEqual(t, call(wrapDuplicateHeaders, "id,x,Id\n", map[string]any{"ignoreCase": true}), map[string]any{"id": []any{0.0, 2.0}})
+    wantEqual(t, call(wrapDuplicateHeaders, "id,x,Id\n"), map[string]any{})
+}
+
+func TestWrapCrosstab(t *testing.T) {
+    got := callMap(t, wrapCrosstab, "g,a\nm,y\nf,y\n", 0, 1, map[string]any{"header": true})
+    wantEqual(t, got["table"], map[string]any{"m": map[string]any{"y": 1.0}, "f": map[string]any{"y": 1.0}})
+    wantEqual(t, got["total"], 2.0)
+}
+
+func TestWrapUniqueRows(t *testing.T) {
+    wantEqual(t, call(wrapUniqueRows, "k,v\n1,a\n1,b\n", []any{0}, map[string]any{"header": true}), "k,v\n1,a\n")
+    wantEqual(t, call(wrapUniqueRows, "a\na\nb\n"), "a\nb\n")
+}
+
+func TestWrapFilterRowsLineNumbers(t *testing.T) {
+    got := call(wrapFilterRows, "l\ninfo\nerror\n", 0, "equals", "error", map[string]any{"header": true, "lineNumbers": true})
+    wantEqual(t, got, "__line__,l\n3,error\n")
+}
+
+func TestWrapMovingAverage(t *testing.T) {
+    wantEqual(t, call(wrapMovingAverage, "v\n1\n3\n", 0, 2, map[string]any{"header": true}), "v,v_ma2\n1,\n3,2\n")
+    wantError(t, call(wrapMovingAverage, "v\n1\n", 0, 0), codeBadArgument, "window must be positive, got 0")
+}
+
+func TestWrapQuickStats(t *testing.T) {
+    got := call(wrapQuickStats, uint8Array([]byte("a,b\n\"x\ny\",2\n")))
+    wantEqual(t, got, map[string]any{"records": 2.0, "columns": 2.0, "bytes": 12.0, "openQuote": false})
+    wantError(t, call(wrapQuickStats, "a,b\n"), codeBadArgument, "expected a Uint8Array")
+}
+
+func TestWrapCSVToMarkdown(t *testing.T) {
+    wantEqual(t, call(wrapCSVToMarkdown, "a\nx|y\n", map[string]any{"header": true}), "| a |\n| --- |\n| x\\|y |\n")
+}
+
+func TestWrapPartitionByColumn(t *testing.T) {
+    got := call(wrapPartitionByColumn, "k\na\nb\n", 0, 1, map[string]any{"header": true})
+    wantEqual(t, got, map[string]any{"partitions": map[string]any{"a": "k\na\n"}, "truncated": true, "droppedRows": 1.0})
+}
+
+func TestWrapGroupByShare(t *testing.T) {
+    got := callMap(t, wrapGroupByShare, "k,v\na,1\nb,3\n", 0, 1, map[string]any{"header": true})
+    wantEqual(t, got["groups"], map[string]any{"a": map[string]any{"sum": 1.0, "share": 0.25}, "b": map[string]any{"sum": 3.0, "share": 0.75}})
+}
+
+func TestWrapEnumValidate(t *testing.T) {
+    got := call(wrapEnumValidate, "g\nA\nz\n\n", 0, []any{"A", "B"}, map[string]any{"header": true})
+    wantEqual(t, got, []any{3.0})
+}
+
+func TestWrapRowHashes(t *testing.T) {
+    got := callMap(t, wrapRowHashes, "id,v\n1,a\n2,b\n", []any{0}, map[string]any{"header": true})
+    if len(got) != 2 || got["1"] == got["2"] {
+        t.Errorf("wasmRowHashes = %v; want two different hashes", got)
+    }
+    wantError(t, call(wrapRowHashes, "id\n1\n", 0), codeBadArgument, "")
+}
+
+func TestWrapRectangularize(t *testing.T) {
+    wantEqual(t, call(wrapRectangularize, "a,b\n1\n1,2,3\n", "?", false, map[string]any{"header": true}), "a,b\n1,?\n1,2\n")
+    wantError(t, call(wrapRectangularize, "a,b\n1,2,3\n", "", true, map[string]any{"header": true}), codeBadArgument, "line 2 has 3 fields, more than the 2 of the header")
+}
+
+func TestWrapEstimateRows(t *testing.T) {
+    wantEqual(t, call(wrapEstimateRows, uint8Array([]byte("a\nb\n"))), map[string]any{"estimate": 2.0, "sampled": false})
+}
+
+func TestWrapMapColumn(t *testing.T) {
+    upper := js.FuncOf(func(this js.Value, args []js.Value) any {
+        return strings.ToUpper(args[0].String())
+    })
+    defer upper.Release()
+    wantEqual(t, call(wrapMapColumn, "n\nann\nbob\n", 0, upper, map[string]any{"header": true}), "n\nANN\nBOB\n")
+    throws := js.Global().Get("Function").New("v", "throw new Error('bad cell ' + v)")
+    wantError(t, call(wrapMapColumn, "n\nann\n", 0, throws, map[string]any{"header": true}), codeBadArgument, "line 2: JavaScript error: bad cell ann")
+    wantError(t, call(wrapMapColumn, "n\nann\n", 0, "x"), codeBadArgument, "callback must be a function")
+}
+
+func TestWrapMatchHeaders(t *testing.T) {
+    got := call(wrapMatchHeaders, []any{"firstName"}, []any{"first_name", "x"})
+    wantEqual(t, got, []any{map[string]any{"header": "firstName", "match": "first_name", "score": 1.0}})
+}
+
+func TestWrapProfile(t *testing.T) {
+    got := call(wrapProfile, "n,s\n1,a\n3,\n", map[string]any{"header": true})
+    wantEqual(t, got, []any{
+        map[string]any{"name": "n", "type": "integer", "fillRate": 1.0, "distinct": 2.0, "min": 1.0, "max": 3.0, "mean": 2.0},
+        map[string]any{"name": "s", "type": "string", "fillRate": 0.5, "distinct": 2.0},
+    })
+}
+
+func TestWrapWhitespaceReport(t *testing.T) {
+    wantEqual(t, call(wrapWhitespaceReport, " a,b\nx ,y\nz,w\n", map[string]any{"header": true}), []any{1.0, 0.0})
+}
+
+func TestWrapColumnDiff(t *testing.T) {
+    wantEqual(t, call(wrapColumnDiff, "a,b\n5,2\nx,1\n", 0, 1, "delta", map[string]any{"header": true}), "a,b,delta\n5,2,3\nx,1,\n")
+}
+
+func TestWrapChunkedOutput(t *testing.T) {
+    var chunks []string
+    onChunk := js.FuncOf(func(this js.Value, args []js.Value) any {
+        chunks = append(chunks, args[0].String())
+        return nil
+    })
+    defer onChunk.Release()
+    text := "a\n1\n2\n3\n"
+    got := call(wrapRequote, text, "all", map[string]any{"onChunk": onChunk, "chunkRows": 3})
+    if got != nil {
+        t.Errorf("wasmRequote with onChunk returned %v; want undefined", got)
+    }
+    wantEqual(t, chunks, []string{"\"a\"\n\"1\"\n\"2\"\n", "\"3\"\n"})
+    chunks = nil
+    call(wrapCanonicalize, text, map[string]any{"onChunk": onChunk, "chunkRows": 3})
+    wantEqual(t, strings.Join(chunks, ""), text)
+}
+
+func TestWrapCheckMonotonic(t *testing.T) {
+    got := call(wrapCheckMonotonic, "t\n1\n1\n", 0, true, map[string]any{"header": true})
+    wantEqual(t, got, map[string]any{"breakLine": 3.0, "nonNumeric": 0.0})
+}
+
+func TestWrapWeightedMean(t *testing.T) {
+    wantEqual(t, call(wrapWeightedMean, "v,w\n10,1\n20,3\n", 0, 1, map[string]any{"header": true}), 17.5)
+    wantEqual(t, call(wrapWeightedMean, "v,w\n10,1\n20,3 \n", 0, 1, map[string]any{"header": true, "trimSpace": true}), 17.5)
+    wantError(t, call(wrapWeightedMean, "v,w\n10,0\n", 0, 1, map[string]any{"header": true}), codeBadArgument, "weights in column 1 sum to zero")
+}
+
+func TestWrapCancelMidSummary(t *testing.T) {
+    id := call(wrapNewToken)
+    t.Cleanup(func() { call(wrapFreeToken, id) })
+    // The first progress report comes at progressEvery records, and the cancel check on
+    // the same record sees the flag.
+    onProgress := js.FuncOf(func(this js.Value, args []js.Value) any {
+        call(wrapCancel, id)
+        return nil
+    })
+    defer onProgress.Release()
+    text := "n\n" + strings.Repeat("1\n", 5*progressEvery)
+    got := call(wrapCSVSummary, text, map[string]any{"header": true, "onProgress": onProgress, "cancelToken": id})
+    wantError(t, got, codeCancelled, "cancelled")
+    got = call(wrapProfile, text, map[string]any{"header": true, "cancelToken": id})
+    wantError(t, got, codeCancelled, "cancelled")
+}
+
+func TestWrapStaleCancelTokens(t *testing.T) {
+    id := call(wrapNewToken)
+    wantEqual(t, call(wrapFreeToken, id), nil)
+    // Cancelling, freeing or passing a token that is gone is reported as a bad_argument
+    // error rather than a panic.
+    wantError(t, call(wrapCancel, id), codeBadArgument, fmt.Sprintf("unknown or freed cancel token %v", id))
+    wantError(t, call(wrapFreeToken, id), codeBadArgument, "")
+    wantError(t, call(wrapCancel, 123456), codeBadArgument, "unknown or freed cancel token 123456")
+    wantError(t, call(wrapCSVSummary, "n\n1\n", map[string]any{"cancelToken": id}), codeBadArgument, fmt.Sprintf("unknown or freed cancel token %v", id))
+    if next := call(wrapNewToken); next == id {
+        t.Errorf("freed token %v was reissued", id)
+    } else {
+        call(wrapFreeToken, next)
+    }
+}
+
+func TestWrapTypeConfidence(t *testing.T) {
+    got := call(wrapTypeConfidence, "n\n1\n2\n3\nx\n", map[string]any{"header": true})
+    wantEqual(t, got, []any{map[string]any{"integer": 0.75, "float": 0.0, "boolean": 0.0, "date": 0.0, "string": 0.25}})
+}
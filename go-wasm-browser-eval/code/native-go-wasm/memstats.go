@@ -0,0 +1,12 @@
+//go:build js && wasm
+
+package main
+
+import "syscall/js"
+
+// wrapMemStats exposes heap figures from runtime.ReadMemStats to JavaScript as
+// wasmMemStats(). The fields come from a build-tagged memStats because TinyGo's runtime
+// only fills in a subset of runtime.MemStats.
+func wrapMemStats(this js.Value, args []js.Value) any {
+    return memStats()
+}
@@ -0,0 +1,17 @@
+//go:build !tinygo
+
+package main
+
+import "runtime"
+
+// memStats reports allocator figures from the standard Go runtime.
+func memStats() map[string]any {
+    var m runtime.MemStats
+    runtime.ReadMemStats(&m)
+    return map[string]any{
+        "alloc":      m.Alloc,
+        "totalAlloc": m.TotalAlloc,
+        "heapInuse":  m.HeapInuse,
+        "numGC":      m.NumGC,
+    }
+}
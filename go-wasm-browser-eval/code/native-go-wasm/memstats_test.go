@@ -0,0 +1,29 @@
+package main
+
+import (
+    "runtime"
+    "testing"
+)
+
+// sink keeps test allocations reachable so the compiler cannot drop them.
+var sink []byte
+
+func TestMemStats(t *testing.T) {
+    sink = make([]byte, 1<<20)
+    t.Cleanup(func() { sink = nil })
+    stats := memStats()
+    if alloc := stats["alloc"].(uint64); alloc == 0 {
+        t.Error("alloc is zero after allocating 1 MiB")
+    }
+    if heap := stats["heapInuse"].(uint64); heap < 1<<20 {
+        t.Errorf("heapInuse = %d; want at least the 1 MiB still reachable", heap)
+    }
+    before := stats["numGC"].(uint32)
+    runtime.GC()
+    if after := memStats()["numGC"].(uint32); after <= before {
+        t.Errorf("numGC = %d after runtime.GC; want more than %d", after, before)
+    }
+    if total := stats["totalAlloc"].(uint64); total < stats["alloc"].(uint64) {
+        t.Errorf("totalAlloc %d is below alloc %d", total, stats["alloc"])
+    }
+}
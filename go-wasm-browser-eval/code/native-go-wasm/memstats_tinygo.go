@@ -0,0 +1,18 @@
+//go:build tinygo
+
+package main
+
+import "runtime"
+
+// memStats reports the allocator figures TinyGo tracks. TinyGo does not count
+// collections, so numGC is listed under "unsupported" instead of being reported as zero.
+func memStats() map[string]any {
+    var m runtime.MemStats
+    runtime.ReadMemStats(&m)
+    return map[string]any{
+        "alloc":       m.Alloc,
+        "totalAlloc":  m.TotalAlloc,
+        "heapInuse":   m.HeapInuse,
+        "unsupported": []any{"numGC"},
+    }
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+    "encoding/json"
+    "slices"
+    "strings"
+)
+
+// summaryFromNDJSON summarizes newline-delimited JSON where every non-blank line is an
+// independent object. It reports the number of objects under "records", the sorted union
+// of their top-level keys under "keys", and the 1-based numbers of lines that are not
+// valid JSON objects under "invalidLines" (capped at maxRowErrors).
+func summaryFromNDJSON(text string) map[string]any {
+    text = strings.TrimPrefix(text, utf8BOM)
+    keySet := map[string]bool{}
+    records := 0
+    invalid := []int{}
+    for lineNo := 1; text != ""; lineNo++ {
+        var line string
+        line, text, _ = strings.Cut(text, "\n")
+        line = strings.TrimSpace(line)
+        if line == "" {
+            continue
+        }
+        var object map[string]json.RawMessage
+        if err := json.Unmarshal([]byte(line), &object); err != nil || object == nil {
+            if len(invalid) < maxRowErrors {
+                invalid = append(invalid, lineNo)
+            }
+            continue
+        }
+        records++
+        for k := range object {
+            keySet[k] = true
+        }
+    }
+    keys := make([]string, 0, len(keySet))
+    for k := range keySet {
+        keys = append(keys, k)
+    }
+    slices.Sort(keys)
+    return map[string]any{
+        "records":      records,
+        "keys":         keys,
+        "invalidLines": invalid,
+    }
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestSummaryFromNDJSON(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        want map[string]any
+    }{
+        {
+            "differing key sets",
+            "{\"id\":1,\"name\":\"ann\"}\n{\"id\":2,\"tags\":[\"x\"]}\n",
+            map[string]any{"records": 2, "keys": []string{"id", "name", "tags"}, "invalidLines": []int{}},
+        },
+        {
+            "malformed and non-object lines",
+            "{\"a\":1}\n{\"a\":\n[1,2]\nnull\n\"s\"\r\n{\"b\":2}\r\n",
+            map[string]any{"records": 2, "keys": []string{"a", "b"}, "invalidLines": []int{2, 3, 4, 5}},
+        },
+        {
+            "blank lines are skipped but still numbered",
+            "\n{\"a\":1}\n   \n\n{oops}\n",
+            map[string]any{"records": 1, "keys": []string{"a"}, "invalidLines": []int{5}},
+        },
+        {
+            "empty",
+            "",
+            map[string]any{"records": 0, "keys": []string{}, "invalidLines": []int{}},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := summaryFromNDJSON(tt.text); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("summaryFromNDJSON = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSummaryFromNDJSONCapsInvalidLines(t *testing.T) {
+    got := summaryFromNDJSON(strings.Repeat("nope\n", maxRowErrors+10))
+    if n := len(got["invalidLines"].([]int)); n != maxRowErrors {
+        t.Errorf("reported %d invalid lines; want the %d cap", n, maxRowErrors)
+    }
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+    "math"
+    "strconv"
+)
+
+// pivotCell accumulates the values landing in one index/column combination.
+type pivotCell struct {
+    first string
+    sum   float64
+    sums  int
+    count int
+}
+
+// value renders the cell for agg; a sum with no numeric values is left empty.
+func (c *pivotCell) value(agg string) string {
+    switch agg {
+    case "sum":
+        if c.sums == 0 {
+            return ""
+        }
+        return strconv.FormatFloat(c.sum, 'f', -1, 64)
+    case "count":
+        return strconv.Itoa(c.count)
+    default:
+        return c.first
+    }
+}
+
+// pivot reshapes a long table to wide: each distinct indexCol value becomes a row, each
+// distinct columnsCol value a column, and each cell the agg ("sum", "first" or "count")
+// of valueCol over the rows with that pair. Rows and columns keep first-seen order, and
+// missing combinations are empty. The first output column is headed by the indexCol
+// header when opts.HasHeader is set; sum skips cells that are not finite numbers, as
+// groupBySum does.
+func pivot(csvText string, indexCol, columnsCol, valueCol int, agg string, opts csvOptions) (string, error) {
+    if agg != "sum" && agg != "first" && agg != "count" {
+        return "", badArgument("unknown aggregation %q (want sum, first or count)", agg)
+    }
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    width := max(len(header), tableWidth(rows))
+    for _, col := range []int{indexCol, columnsCol, valueCol} {
+        if err := checkColumn(col, width); err != nil {
+            return "", err
+        }
+    }
+    var keys, names []string
+    keyIndex, nameIndex := map[string]int{}, map[string]int{}
+    cells := map[[2]int]*pivotCell{}
+    for _, row := range rows {
+        key, name := cell(row, indexCol), cell(row, columnsCol)
+        k, ok := keyIndex[key]
+        if !ok {
+            k = len(keys)
+            keyIndex[key] = k
+            keys = append(keys, key)
+        }
+        n, ok := nameIndex[name]
+        if !ok {
+            n = len(names)
+            nameIndex[name] = n
+            names = append(names, name)
+        }
+        c := cells[[2]int{k, n}]
+        if c == nil {
+            c = &pivotCell{first: cell(row, valueCol)}
+            cells[[2]int{k, n}] = c
+        }
+        c.count++
+        if x, err := strconv.ParseFloat(cell(row, valueCol), 64); err == nil && !math.IsNaN(x) && !math.IsInf(x, 0) {
+            c.sum += x
+            c.sums++
+        }
+    }
+    out := make([][]string, 0, len(keys)+1)
+    out = append(out, append([]string{cell(header, indexCol)}, names...))
+    for k, key := range keys {
+        row := make([]string, len(names)+1)
+        row[0] = key
+        for n := range names {
+            if c := cells[[2]int{k, n}]; c != nil {
+                row[n+1] = c.value(agg)
+            }
+        }
+        out = append(out, row)
+    }
+    return encodeCSV(out, false)
+}
+
+// crosstab counts the rows for each pair of rowCol and colCol values, a contingency
+// table. "table" maps each rowCol value to {colCol value: count} with zeros filled in for
+// pairs that never occur, "rowTotals" and "columnTotals" hold the margins and "total" the
+// number of rows counted. Empty cells count as a value of their own, as in topValues.
+func crosstab(rows [][]string, rowCol, colCol int) map[string]any {
+    rowTotals := map[string]int{}
+    colTotals := map[string]int{}
+    counts := map[string]map[string]int{}
+    for _, row := range rows {
+        r, c := cell(row, rowCol), cell(row, colCol)
+        if counts[r] == nil {
+            counts[r] = map[string]int{}
+        }
+        counts[r][c]++
+        rowTotals[r]++
+        colTotals[c]++
+    }
+    table := make(map[string]any, len(counts))
+    for r, byCol := range counts {
+        for c := range colTotals {
+            if _, ok := byCol[c]; !ok {
+                byCol[c] = 0
+            }
+        }
+        table[r] = byCol
+    }
+    return map[string]any{
+        "table":        table,
+        "rowTotals":    rowTotals,
+        "columnTotals": colTotals,
+        "total":        len(rows),
+    }
+}
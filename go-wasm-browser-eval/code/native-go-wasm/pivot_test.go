@@ -0,0 +1,59 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestPivot(t *testing.T) {
+    const long = "region,quarter,sales\nnorth,q1,10\nnorth,q2,5\nsouth,q1,7\nnorth,q1,2.5\neast,q2,x\nsouth,q1,NaN\n"
+    tests := []struct {
+        agg  string
+        want string
+    }{
+        // east has no numeric q2 sales and no q1 row at all; both stay empty.
+        {"sum", "region,q1,q2\nnorth,12.5,5\nsouth,7,\neast,,\n"},
+        {"count", "region,q1,q2\nnorth,2,1\nsouth,2,\neast,,1\n"},
+        {"first", "region,q1,q2\nnorth,10,5\nsouth,7,\neast,,x\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.agg, func(t *testing.T) {
+            got, err := pivot(long, 0, 1, 2, tt.agg, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("pivot = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestPivotErrors(t *testing.T) {
+    _, err := pivot("a,b,c\n", 0, 1, 2, "mean", csvOptions{HasHeader: true})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != `unknown aggregation "mean" (want sum, first or count)` {
+        t.Errorf("unknown agg: got %v", m)
+    }
+    _, err = pivot("a,b,c\n1,2,3\n", 0, 1, 3, "sum", csvOptions{HasHeader: true})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != "column index 3 out of range (table has 3 columns)" {
+        t.Errorf("out-of-range value column: got %v", m)
+    }
+}
+
+func TestCrosstab(t *testing.T) {
+    rows := [][]string{{"m", "yes"}, {"f", "no"}, {"m", "no"}, {"m", "yes"}, {"f", "no"}}
+    got := crosstab(rows, 0, 1)
+    want := map[string]any{
+        // f never answered yes, so that cell is filled in as zero.
+        "table": map[string]any{
+            "m": map[string]int{"yes": 2, "no": 1},
+            "f": map[string]int{"yes": 0, "no": 2},
+        },
+        "rowTotals":    map[string]int{"m": 3, "f": 2},
+        "columnTotals": map[string]int{"yes": 2, "no": 3},
+        "total":        5,
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("crosstab = %v; want %v", got, want)
+    }
+}
@@ -0,0 +1,31 @@
+package main
+
+import "time"
+
+const (
+    // progressEvery is how many records pass between wall-clock checks, keeping the
+    // per-record cost of progress reporting to a modulo.
+    progressEvery = 1000
+    // progressInterval is the minimum wall time between progress callbacks.
+    progressInterval = 50 * time.Millisecond
+)
+
+// progressReporter throttles calls to a progress callback by record count and wall time.
+type progressReporter struct {
+    fn   func(processed int)
+    last time.Time
+}
+
+// tick reports processed records if a callback is set and enough records and time have
+// passed since the previous report.
+func (p *progressReporter) tick(processed int) {
+    if p.fn == nil || processed%progressEvery != 0 {
+        return
+    }
+    now := time.Now()
+    if now.Sub(p.last) < progressInterval {
+        return
+    }
+    p.last = now
+    p.fn(processed)
+}
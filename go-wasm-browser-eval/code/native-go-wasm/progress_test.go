@@ -0,0 +1,47 @@
+package main
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestProgressReporterThrottles(t *testing.T) {
+    var calls []int
+    p := progressReporter{fn: func(processed int) { calls = append(calls, processed) }}
+    for n := 1; n <= 10*progressEvery; n++ {
+        p.tick(n)
+    }
+    if len(calls) != 1 || calls[0] != progressEvery {
+        t.Fatalf("calls = %v; want one report at %d inside a single interval", calls, progressEvery)
+    }
+    p.last = time.Now().Add(-progressInterval)
+    p.tick(11 * progressEvery)
+    p.tick(11*progressEvery + 1)
+    if len(calls) != 2 || calls[1] != 11*progressEvery {
+        t.Errorf("calls = %v; want a second report once the interval has passed", calls)
+    }
+}
+
+func TestProgressReporterWithoutCallback(t *testing.T) {
+    p := progressReporter{}
+    p.tick(progressEvery)
+    if !p.last.IsZero() {
+        t.Error("a reporter with no callback should not track time")
+    }
+}
+
+func TestSummaryProgress(t *testing.T) {
+    calls := 0
+    last := 0
+    opts := csvOptions{HasHeader: true, Progress: func(processed int) {
+        calls++
+        last = processed
+    }}
+    if _, err := summaryFromCSV("n\n"+strings.Repeat("1\n", 3*progressEvery), opts); err != nil {
+        t.Fatal(err)
+    }
+    if calls == 0 || last%progressEvery != 0 {
+        t.Errorf("calls = %d, last = %d; want at least one report on a multiple of %d", calls, last, progressEvery)
+    }
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+    "bytes"
+    "math"
+    "unicode/utf8"
+)
+
+// quickStats counts the records of CSV bytes in a single pass without parsing them into
+// fields: newlines inside quoted fields do not end a record, blank lines and (when
+// opts.Comment is set) comment lines are skipped as encoding/csv skips them, and the
+// column count is the field count of the first record. It also reports the byte size and
+// whether the data ends inside an open quote, which the full parser would reject. Only
+// single-byte delimiters and comment characters are supported.
+func quickStats(data []byte, opts csvOptions) (map[string]any, error) {
+    delimiter, comment := byte(','), byte(0)
+    if opts.Delimiter != 0 {
+        if opts.Delimiter >= utf8.RuneSelf {
+            return nil, badArgument("quick stats needs a single-byte delimiter, got %q", opts.Delimiter)
+        }
+        delimiter = byte(opts.Delimiter)
+    }
+    if opts.Comment != 0 {
+        if opts.Comment >= utf8.RuneSelf {
+            return nil, badArgument("quick stats needs a single-byte comment character, got %q", opts.Comment)
+        }
+        comment = byte(opts.Comment)
+    }
+    size := len(data)
+    data = bytes.TrimPrefix(data, []byte(utf8BOM))
+    records, columns, fields := 0, 0, 1
+    quoted, lineStart, blank, skip := false, true, true, false
+    // endRecord closes the current line, counting it unless it was blank or a comment.
+    endRecord := func() {
+        if !blank && !skip {
+            if records == 0 {
+                columns = fields
+            }
+            records++
+        }
+        fields, lineStart, blank, skip = 1, true, true, false
+    }
+    for _, b := range data {
+        if lineStart {
+            lineStart = false
+            skip = comment != 0 && b == comment
+        }
+        switch {
+        case skip:
+            if b == '\n' {
+                endRecord()
+            }
+        case b == '"':
+            quoted = !quoted
+            blank = false
+        case quoted:
+            blank = false
+        case b == '\n':
+            endRecord()
+        case b == '\r':
+        case b == delimiter:
+            fields++
+            blank = false
+        default:
+            blank = false
+        }
+    }
+    endRecord()
+    return map[string]any{
+        "records":   records,
+        "columns":   columns,
+        "bytes":     size,
+        "openQuote": quoted,
+    }, nil
+}
+
+// defaultEstimateSample is the sample size estimateRowCount uses when the caller does not
+// give one.
+const defaultEstimateSample = 64 << 10
+
+// estimateRowCount estimates the record count of data from its first sampleBytes. The
+// sample is cut back to its last newline so a partial record is not counted, its records
+// are counted with quickStats and the count is scaled by the full length. Data no longer
+// than sampleBytes is counted exactly and reported with sampled false.
+func estimateRowCount(data []byte, sampleBytes int, opts csvOptions) (map[string]any, error) {
+    if sampleBytes <= 0 {
+        return nil, badArgument("sample size must be positive, got %d", sampleBytes)
+    }
+    if len(data) <= sampleBytes {
+        stats, err := quickStats(data, opts)
+        if err != nil {
+            return nil, err
+        }
+        return map[string]any{"estimate": stats["records"], "sampled": false}, nil
+    }
+    sample := data[:sampleBytes]
+    if cut := bytes.LastIndexByte(sample, '\n'); cut >= 0 {
+        sample = sample[:cut+1]
+    }
+    stats, err := quickStats(sample, opts)
+    if err != nil {
+        return nil, err
+    }
+    records := stats["records"].(int)
+    estimate := int(math.Round(float64(records) * float64(len(data)) / float64(len(sample))))
+    return map[string]any{"estimate": estimate, "sampled": true}, nil
+}
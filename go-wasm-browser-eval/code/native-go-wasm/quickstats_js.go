@@ -0,0 +1,45 @@
+//go:build js && wasm
+
+package main
+
+import "syscall/js"
+
+// wrapQuickStats exposes quickStats to JavaScript as wasmQuickStats(uint8array, options?),
+// returning {records, columns, bytes, openQuote}.
+func wrapQuickStats(this js.Value, args []js.Value) any {
+    data, err := bytesArg(args, 0)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    stats, err := quickStats(data, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return stats
+}
+
+// wrapEstimateRows exposes estimateRowCount to JavaScript as
+// wasmEstimateRows(uint8array, sampleBytes?, options?), returning {estimate, sampled}.
+func wrapEstimateRows(this js.Value, args []js.Value) any {
+    data, err := bytesArg(args, 0)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    opts, err := optionsArg(args, 2)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    sampleBytes := defaultEstimateSample
+    if !isMissing(args, 1) {
+        sampleBytes = args[1].Int()
+    }
+    estimate, err := estimateRowCount(data, sampleBytes, opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return estimate
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+    "fmt"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestQuickStatsMatchesParser(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        opts csvOptions
+    }{
+        {"quoted multiline", "id,note\n1,\"line one\nline two\"\n2,\"a,b\"\n3,\"say \"\"hi\"\"\n\"\n", csvOptions{}},
+        {"CRLF and no final newline", "a,b\r\n1,\"x\r\ny\"\r\n2,3", csvOptions{}},
+        {"blank lines and comments", "\ufeff# note\na;b;c\n\n1;2;3\n# skip\n4;5;6\n", csvOptions{Delimiter: ';', Comment: '#'}},
+        {"empty", "", csvOptions{}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            records, err := readAllRecords(tt.text, tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            got, err := quickStats([]byte(tt.text), tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            columns := 0
+            if len(records) > 0 {
+                columns = len(records[0])
+            }
+            want := map[string]any{"records": len(records), "columns": columns, "bytes": len(tt.text), "openQuote": false}
+            if !reflect.DeepEqual(got, want) {
+                t.Errorf("quickStats = %v; want %v", got, want)
+            }
+        })
+    }
+}
+
+func TestQuickStatsOpenQuote(t *testing.T) {
+    got, err := quickStats([]byte("a,b\n1,\"unterminated\n2,3\n"), csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got["openQuote"] != true {
+        t.Errorf("openQuote = %v; want true", got["openQuote"])
+    }
+    if _, err := quickStats(nil, csvOptions{Delimiter: '§'}); err == nil {
+        t.Error("multi-byte delimiter accepted")
+    }
+}
+
+func TestQuickStatsAllocations(t *testing.T) {
+    data := []byte(strings.Repeat("1,\"two\nlines\",3\n", 5000))
+    if n := testing.AllocsPerRun(5, func() { quickStats(data, csvOptions{}) }); n > 5 {
+        t.Errorf("quickStats made %v allocations over %d bytes; want a handful", n, len(data))
+    }
+}
+
+func TestEstimateRowCount(t *testing.T) {
+    // Fixed-width numbers keep the records in the sample as long as the rest.
+    var b strings.Builder
+    for i := range 5000 {
+        if i%3 == 0 {
+            fmt.Fprintf(&b, "%05d,\"two\nlines\",%05d\n", i, i%997)
+        } else {
+            fmt.Fprintf(&b, "%05d,plain,%05d\n", i, i%991)
+        }
+    }
+    data := []byte(b.String())
+    got, err := estimateRowCount(data, 16<<10, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    estimate := got["estimate"].(int)
+    if got["sampled"] != true || estimate < 4500 || estimate > 5500 {
+        t.Errorf("estimate = %v; want a sampled count within 10%% of 5000", got)
+    }
+    got, err = estimateRowCount(data[:200], 16<<10, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    exact, _ := quickStats(data[:200], csvOptions{})
+    if got["sampled"] != false || got["estimate"] != exact["records"] {
+        t.Errorf("small input = %v; want the exact count %v", got, exact["records"])
+    }
+    if _, err := estimateRowCount(data, 0, csvOptions{}); err == nil {
+        t.Error("zero sample size accepted")
+    }
+}
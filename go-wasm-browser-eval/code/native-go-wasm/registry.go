@@ -0,0 +1,93 @@
+package main
+
+import "slices"
+
+// parsedTable is a CSV payload parsed once by wasmParse and kept on the Go side so later
+// calls can reuse the records instead of re-parsing the text.
+type parsedTable struct {
+    opts    csvOptions
+    records [][]string
+}
+
+var (
+    // tables maps live handles to their parsed records; freed handles are deleted.
+    tables = map[int]*parsedTable{}
+    // nextHandle is never reused, so a stale handle cannot alias a newer table.
+    nextHandle = 1
+)
+
+// storeTable parses csvText with opts and registers it, returning the new handle.
+func storeTable(csvText string, opts csvOptions) (int, error) {
+    records, err := readAllRecords(csvText, opts)
+    if err != nil {
+        return 0, err
+    }
+    handle := nextHandle
+    nextHandle++
+    tables[handle] = &parsedTable{opts: opts, records: records}
+    return handle, nil
+}
+
+// lookupTable returns the table behind handle, or a bad_argument error for handles that
+// were never issued or have already been freed.
+func lookupTable(handle int) (*parsedTable, error) {
+    t, ok := tables[handle]
+    if !ok {
+        return nil, badArgument("unknown or freed table handle %d", handle)
+    }
+    return t, nil
+}
+
+// freeTable releases handle so its records can be garbage collected.
+func freeTable(handle int) error {
+    if _, err := lookupTable(handle); err != nil {
+        return err
+    }
+    delete(tables, handle)
+    return nil
+}
+
+// summary runs the streaming summary accumulator over the stored records. Numeric stats
+// are always included and, since every record is already in memory, computed column by
+// column with tableStats rather than cell by cell in the accumulator.
+func (t *parsedTable) summary() map[string]any {
+    acc := newSummaryAccumulator(t.opts)
+    for _, record := range t.records {
+        acc.add(record)
+    }
+    result := acc.result()
+    sources := t.opts.Columns
+    if sources == nil {
+        sources = make([]int, tableWidth(t.records))
+        for i := range sources {
+            sources[i] = i
+        }
+    }
+    result["stats"] = tableStats(t.dataRows(), sources, t.opts)
+    return result
+}
+
+// dataRows returns the records the accumulator counts as rows: the header is left out,
+// and so are blank rows when opts.SkipBlankRows is set.
+func (t *parsedTable) dataRows() [][]string {
+    rows := t.records
+    if t.opts.HasHeader && len(rows) > 0 {
+        rows = rows[1:]
+    }
+    if !t.opts.SkipBlankRows {
+        return rows
+    }
+    kept := make([][]string, 0, len(rows))
+    for _, row := range rows {
+        if slices.ContainsFunc(row, func(v string) bool { return !isBlank(v) }) {
+            kept = append(kept, row)
+        }
+    }
+    return kept
+}
+
+// preview returns up to n of the rows dataRows counts, as wasmCSVPreview does.
+func (t *parsedTable) preview(n int) [][]string {
+    rows := t.dataRows()
+    return rows[:min(max(n, 0), len(rows))]
+}
@@ -0,0 +1,63 @@
+//go:build js && wasm
+
+package main
+
+import "syscall/js"
+
+// handleArg reads the table handle at args[0] and resolves it.
+func handleArg(args []js.Value) (*parsedTable, error) {
+    if len(args) < 1 || args[0].Type() != js.TypeNumber {
+        return nil, badArgument("expected a table handle")
+    }
+    return lookupTable(args[0].Int())
+}
+
+// wrapParse exposes storeTable to JavaScript as wasmParse(text, options?), returning an
+// integer handle for wasmTableStats, wasmTablePreview and wasmTableFree.
+func wrapParse(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    opts, err := optionsArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    handle, err := storeTable(args[0].String(), opts)
+    if err != nil {
+        return errorMap(err)
+    }
+    return handle
+}
+
+// wrapTableStats returns the summary of a parsed table: wasmTableStats(handle).
+func wrapTableStats(this js.Value, args []js.Value) any {
+    t, err := handleArg(args)
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(t.summary())
+}
+
+// wrapTablePreview returns the first n data rows of a parsed table: wasmTablePreview(handle, n).
+func wrapTablePreview(this js.Value, args []js.Value) any {
+    t, err := handleArg(args)
+    if err != nil {
+        return errorMap(err)
+    }
+    if len(args) < 2 {
+        return errorResult(codeBadArgument, "expected a row count")
+    }
+    return toJS(t.preview(args[1].Int()))
+}
+
+// wrapTableFree releases a parsed table: wasmTableFree(handle). Freeing a handle twice is a
+// bad_argument error rather than a silent no-op, so lifecycle bugs surface in JS.
+func wrapTableFree(this js.Value, args []js.Value) any {
+    if len(args) < 1 || args[0].Type() != js.TypeNumber {
+        return errorResult(codeBadArgument, "expected a table handle")
+    }
+    if err := freeTable(args[0].Int()); err != nil {
+        return errorMap(err)
+    }
+    return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+    "fmt"
+    "reflect"
+    "testing"
+)
+
+func TestTableLifecycle(t *testing.T) {
+    handle, err := storeTable("a,b\n1,2\n3,4\n5,6\n", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    table, err := lookupTable(handle)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got, want := table.preview(2), [][]string{{"1", "2"}, {"3", "4"}}; !reflect.DeepEqual(got, want) {
+        t.Errorf("preview(2) = %q; want %q", got, want)
+    }
+    if got := table.preview(-1); len(got) != 0 {
+        t.Errorf("preview(-1) = %q; want no rows", got)
+    }
+    summary := table.summary()
+    if summary["rows"] != 3 || summary["stats"] == nil {
+        t.Errorf("summary rows = %v, stats = %v; want 3 rows with stats", summary["rows"], summary["stats"])
+    }
+    if err := freeTable(handle); err != nil {
+        t.Fatal(err)
+    }
+    want := fmt.Sprintf("unknown or freed table handle %d", handle)
+    _, lookupErr := lookupTable(handle)
+    for name, err := range map[string]error{"double free": freeTable(handle), "lookup after free": lookupErr} {
+        if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != want {
+            t.Errorf("%s: got %v; want bad_argument %q", name, m, want)
+        }
+    }
+}
+
+func TestTableHandlesAreNotReused(t *testing.T) {
+    first, err := storeTable("a\n", csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := freeTable(first); err != nil {
+        t.Fatal(err)
+    }
+    second, err := storeTable("b\n", csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer freeTable(second)
+    if second == first {
+        t.Errorf("handle %d was reissued after being freed", first)
+    }
+    if _, err := lookupTable(0); err == nil {
+        t.Error("handle 0 was never issued but resolved")
+    }
+}
+
+func TestTablePreviewSkipBlankRows(t *testing.T) {
+    handle, err := storeTable("a,b\n,\n1,2\n", csvOptions{HasHeader: true, SkipBlankRows: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer freeTable(handle)
+    table, _ := lookupTable(handle)
+    if got, want := table.preview(5), [][]string{{"1", "2"}}; !reflect.DeepEqual(got, want) {
+        t.Errorf("preview = %q; want %q without the blank row", got, want)
+    }
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+    "strconv"
+    "strings"
+    "unicode/utf8"
+)
+
+// ellipsis marks a cell cut short by renderTable.
+const ellipsis = "…"
+
+// truncateRunes shortens value to at most width runes, replacing the tail with an
+// ellipsis when it does not fit.
+func truncateRunes(value string, width int) string {
+    if utf8.RuneCountInString(value) <= width {
+        return value
+    }
+    runes := []rune(value)
+    return string(runes[:width-1]) + ellipsis
+}
+
+// renderTable lays csvText out as fixed-width text with columns separated by " | ". Each
+// column is padded to its widest cell, measured in runes, and cells wider than
+// maxColWidth are truncated with an ellipsis; a non-positive maxColWidth means no cap.
+// Trailing padding is trimmed from every line.
+func renderTable(csvText string, maxColWidth int, opts csvOptions) (string, error) {
+    rows, err := readAllRecords(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    widths := maxWidths(rows)
+    if maxColWidth > 0 {
+        for i := range widths {
+            widths[i] = min(widths[i], maxColWidth)
+        }
+    }
+    var out strings.Builder
+    var line strings.Builder
+    for _, row := range rows {
+        line.Reset()
+        for i, width := range widths {
+            if i > 0 {
+                line.WriteString(" | ")
+            }
+            value := cell(row, i)
+            if maxColWidth > 0 {
+                value = truncateRunes(value, width)
+            }
+            line.WriteString(value)
+            line.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(value)))
+        }
+        out.WriteString(strings.TrimRight(line.String(), " "))
+        out.WriteByte('\n')
+    }
+    return out.String(), nil
+}
+
+// csvToFixedWidth lays each record out as fixed-width text for legacy consumers: every
+// field is truncated or right-padded with pad to its entry in widths, fields are joined
+// without separators and each record ends with a newline. Widths are in runes, and
+// len(widths) must match the widest record.
+func csvToFixedWidth(csvText string, widths []int, pad rune, opts csvOptions) (string, error) {
+    rows, err := readAllRecords(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    if columns := tableWidth(rows); len(widths) != columns {
+        return "", badArgument("got %d widths for %d columns", len(widths), columns)
+    }
+    for i, width := range widths {
+        if width < 0 {
+            return "", badArgument("width %d for column %d must not be negative", width, i)
+        }
+    }
+    padding := string(pad)
+    var out strings.Builder
+    for _, row := range rows {
+        for i, width := range widths {
+            value := []rune(cell(row, i))
+            if len(value) > width {
+                value = value[:width]
+            }
+            out.WriteString(string(value))
+            out.WriteString(strings.Repeat(padding, width-len(value)))
+        }
+        out.WriteByte('\n')
+    }
+    return out.String(), nil
+}
+
+// wrapText breaks value into lines of at most width runes, breaking at spaces where it
+// can and splitting words longer than width. The space a line is broken at is dropped;
+// existing line breaks are kept.
+func wrapText(value string, width int) string {
+    var lines []string
+    for _, paragraph := range strings.Split(value, "\n") {
+        var line []rune
+        for _, word := range strings.Split(paragraph, " ") {
+            w := []rune(word)
+            switch {
+            case line == nil:
+            case len(line)+1+len(w) <= width:
+                line = append(line, ' ')
+            default:
+                lines = append(lines, string(line))
+                line = nil
+            }
+            for len(line)+len(w) > width {
+                cut := width - len(line)
+                lines = append(lines, string(append(line, w[:cut]...)))
+                line, w = nil, w[cut:]
+            }
+            line = append(line, w...)
+            if line == nil {
+                line = []rune{}
+            }
+        }
+        lines = append(lines, string(line))
+    }
+    return strings.Join(lines, "\n")
+}
+
+// wrapCells word-wraps every cell longer than width runes with wrapText and re-encodes
+// the table; the CSV writer quotes the now multi-line cells.
+func wrapCells(csvText string, width int, opts csvOptions) (string, error) {
+    if width < 1 {
+        return "", badArgument("wrap width must be positive, got %d", width)
+    }
+    rows, err := readAllRecords(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    for _, row := range rows {
+        for i, value := range row {
+            if utf8.RuneCountInString(value) > width {
+                row[i] = wrapText(value, width)
+            }
+        }
+    }
+    return encodeCSV(rows, false)
+}
+
+// markdownEscaper escapes a value for a GitHub-flavored Markdown table cell: pipes
+// become \| and line breaks become <br>, since a table row cannot span lines.
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+// csvToMarkdown renders csvText as a GitHub-flavored Markdown table: a header row, a
+// "---" separator row and one row per record, each padded to the widest record. The
+// header is the first record when opts.HasHeader is set and col_1, col_2, ... otherwise.
+// An empty table renders as "".
+func csvToMarkdown(csvText string, opts csvOptions) (string, error) {
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    width := max(len(header), tableWidth(rows))
+    if width == 0 {
+        return "", nil
+    }
+    var out strings.Builder
+    line := func(cells func(i int) string) {
+        for i := range width {
+            out.WriteString("| ")
+            out.WriteString(cells(i))
+            out.WriteString(" ")
+        }
+        out.WriteString("|\n")
+    }
+    line(func(i int) string {
+        if header != nil {
+            return markdownEscaper.Replace(cell(header, i))
+        }
+        return "col_" + strconv.Itoa(i+1)
+    })
+    line(func(int) string { return "---" })
+    for _, row := range rows {
+        line(func(i int) string { return markdownEscaper.Replace(cell(row, i)) })
+    }
+    return out.String(), nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestRenderTable(t *testing.T) {
+    const text = "name,city\nZoë,東京\nbartholomew,x\n"
+    tests := []struct {
+        name        string
+        maxColWidth int
+        want        string
+    }{
+        {"aligned by runes", 0, "name        | city\nZoë         | 東京\nbartholomew | x\n"},
+        {"truncated with an ellipsis", 5, "name  | city\nZoë   | 東京\nbart… | x\n"},
+        {"cap above every width", 50, "name        | city\nZoë         | 東京\nbartholomew | x\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := renderTable(text, tt.maxColWidth, csvOptions{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("renderTable =\n%s\nwant\n%s", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestRenderTableRagged(t *testing.T) {
+    got, err := renderTable("a,b,c\n1\n", 0, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "a | b | c\n1 |   |\n"; got != want {
+        t.Errorf("renderTable = %q; want %q", got, want)
+    }
+}
+
+func TestTruncateRunes(t *testing.T) {
+    tests := []struct {
+        value string
+        width int
+        want  string
+    }{
+        {"hello", 5, "hello"},
+        {"hello!", 5, "hell…"},
+        {"東京都庁", 3, "東京…"},
+        {"ab", 1, "…"},
+    }
+    for _, tt := range tests {
+        if got := truncateRunes(tt.value, tt.width); got != tt.want {
+            t.Errorf("truncateRunes(%q, %d) = %q; want %q", tt.value, tt.width, got, tt.want)
+        }
+    }
+}
+
+func TestCSVToFixedWidth(t *testing.T) {
+    tests := []struct {
+        name   string
+        text   string
+        widths []int
+        pad    rune
+        want   string
+    }{
+        {"padding", "id,name\n7,Zoë\n", []int{3, 5}, ' ', "id name \n7  Zoë  \n"},
+        {"truncation", "code,label\nABCDEF,bartholomew\n", []int{4, 3}, '.', "codelab\nABCDbar\n"},
+        {"short row", "a,b\n1\n", []int{2, 2}, '_', "a_b_\n1___\n"},
+        {"zero width", "a,b\n", []int{0, 1}, ' ', "b\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := csvToFixedWidth(tt.text, tt.widths, tt.pad, csvOptions{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("csvToFixedWidth = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCSVToFixedWidthBadWidths(t *testing.T) {
+    tests := []struct {
+        widths []int
+        msg    string
+    }{
+        {[]int{3}, "got 1 widths for 2 columns"},
+        {[]int{3, 3, 3}, "got 3 widths for 2 columns"},
+        {[]int{3, -1}, "width -1 for column 1 must not be negative"},
+    }
+    for _, tt := range tests {
+        _, err := csvToFixedWidth("a,b\n1,2\n", tt.widths, ' ', csvOptions{})
+        if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.msg {
+            t.Errorf("widths %v: got %v; want %q", tt.widths, m, tt.msg)
+        }
+    }
+}
+
+func TestWrapText(t *testing.T) {
+    tests := []struct {
+        value, want string
+    }{
+        {"the quick brown fox", "the quick\nbrown fox"},
+        {"exactly10!", "exactly10!"},
+        {"supercalifragilistic", "supercalif\nragilistic"},
+        // A word longer than the width starts its own line before it is split.
+        {"ab cdefghijklmn", "ab\ncdefghijkl\nmn"},
+        {"né ça va bien", "né ça va\nbien"},
+        {"one\ntwo three four", "one\ntwo three\nfour"},
+    }
+    for _, tt := range tests {
+        if got := wrapText(tt.value, 10); got != tt.want {
+            t.Errorf("wrapText(%q, 10) = %q; want %q", tt.value, got, tt.want)
+        }
+    }
+}
+
+func TestWrapCells(t *testing.T) {
+    const text = "id,note\n1,the quick brown fox\n2,short\n"
+    got, err := wrapCells(text, 10, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "id,note\n1,\"the quick\nbrown fox\"\n2,short\n"; got != want {
+        t.Errorf("wrapCells = %q; want %q", got, want)
+    }
+    rows, err := readAllRecords(got, csvOptions{})
+    if err != nil {
+        t.Fatalf("wrapped output does not parse: %v", err)
+    }
+    if rows[1][1] != "the quick\nbrown fox" || len(rows) != 3 {
+        t.Errorf("round trip = %q; want the wrapped cell back as one field", rows)
+    }
+    if _, err := wrapCells(text, 0, csvOptions{}); err == nil {
+        t.Error("width 0 accepted")
+    }
+}
+
+func TestCSVToMarkdown(t *testing.T) {
+    tests := []struct {
+        name, text, want string
+        opts             csvOptions
+    }{
+        {
+            "pipes escaped and short rows padded",
+            "a,b|c\n1,x|y\n2\n",
+            "| a | b\\|c |\n| --- | --- |\n| 1 | x\\|y |\n| 2 |  |\n",
+            csvOptions{HasHeader: true},
+        },
+        {"generated headers", "1,2\n", "| col_1 | col_2 |\n| --- | --- |\n| 1 | 2 |\n", csvOptions{}},
+        {"line break in a cell", "a\n\"x\ny\"\n", "| a |\n| --- |\n| x<br>y |\n", csvOptions{HasHeader: true}},
+        {"empty", "", "", csvOptions{HasHeader: true}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := csvToMarkdown(tt.text, tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("csvToMarkdown = %q; want %q", got, tt.want)
+            }
+            // The separator row has one cell per header cell.
+            if lines := strings.Split(got, "\n"); len(lines) > 1 && strings.Count(lines[1], "---") != strings.Count(lines[0], " | ")+1 {
+                t.Errorf("separator %q does not line up with header %q", lines[1], lines[0])
+            }
+        })
+    }
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+    "math/rand/v2"
+    "slices"
+    "strings"
+)
+
+// sampledRow is a reservoir entry remembering where the row came from, so the sample can
+// be returned in file order.
+type sampledRow struct {
+    index  int
+    record []string
+}
+
+// sampleRows draws k data rows uniformly at random from csvText in a single streaming
+// pass (Algorithm R). The same seed always yields the same sample, and a k larger than the
+// row count returns every row. Rows are returned in file order under "rows"; the header
+// (when opts.HasHeader is set) is reported separately under "headers".
+func sampleRows(csvText string, k int, seed int64, opts csvOptions) (map[string]any, error) {
+    if k < 0 {
+        return nil, badArgument("sample size must not be negative, got %d", k)
+    }
+    rng := rand.New(rand.NewPCG(uint64(seed), 0))
+    var headers []string
+    reservoir := make([]sampledRow, 0, k)
+    seen := 0
+    err := readRecords(strings.NewReader(csvText), opts, func(record []string) {
+        if opts.HasHeader && headers == nil {
+            headers = slices.Clone(record)
+            return
+        }
+        if len(reservoir) < k {
+            reservoir = append(reservoir, sampledRow{seen, slices.Clone(record)})
+        } else if j := rng.IntN(seen + 1); j < k {
+            reservoir[j] = sampledRow{seen, slices.Clone(record)}
+        }
+        seen++
+    })
+    if err != nil {
+        return nil, err
+    }
+    slices.SortFunc(reservoir, func(a, b sampledRow) int { return a.index - b.index })
+    rows := make([][]string, len(reservoir))
+    for i, s := range reservoir {
+        rows[i] = s.record
+    }
+    result := map[string]any{"rows": rows}
+    if opts.HasHeader {
+        if headers == nil {
+            headers = []string{}
+        }
+        result["headers"] = headers
+    }
+    return result, nil
+}
+
+// sampleValuesPerColumn returns up to perColumn distinct non-empty values from each
+// column, in the order they first appear. Reading stops as soon as every column seen so
+// far has its quota, so a huge file with well-populated columns is not read to the end;
+// a column that stays sparse keeps the scan going. Columns first reached by a row wider
+// than those before it are sampled from that row on. The header (when opts.HasHeader is
+// set) is not sampled.
+func sampleValuesPerColumn(csvText string, perColumn int, opts csvOptions) ([][]string, error) {
+    if perColumn < 1 {
+        return nil, badArgument("values per column must be positive, got %d", perColumn)
+    }
+    samples := [][]string{}
+    var seen []map[string]bool
+    full, skippedHeader := 0, false
+    err := readRecordsAt(strings.NewReader(csvText), opts, func(record []string, line int) bool {
+        if opts.HasHeader && !skippedHeader {
+            skippedHeader = true
+            return true
+        }
+        for len(samples) < len(record) {
+            samples = append(samples, []string{})
+            seen = append(seen, map[string]bool{})
+        }
+        for i, value := range record {
+            if value == "" || seen[i][value] || len(samples[i]) == perColumn {
+                continue
+            }
+            seen[i][value] = true
+            samples[i] = append(samples[i], value)
+            if len(samples[i]) == perColumn {
+                full++
+            }
+        }
+        return full < len(samples)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return samples, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+    "fmt"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// numberedCSV is a header "n" followed by the data rows 0 through rows-1.
+func numberedCSV(rows int) string {
+    var b strings.Builder
+    b.WriteString("n\n")
+    for i := 0; i < rows; i++ {
+        fmt.Fprintf(&b, "%d\n", i)
+    }
+    return b.String()
+}
+
+func TestSampleRowsDeterministic(t *testing.T) {
+    text := numberedCSV(1000)
+    opts := csvOptions{HasHeader: true}
+    first, err := sampleRows(text, 10, 42, opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    second, err := sampleRows(text, 10, 42, opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(first, second) {
+        t.Errorf("seed 42 gave %v then %v", first["rows"], second["rows"])
+    }
+    rows := first["rows"].([][]string)
+    if len(rows) != 10 || !reflect.DeepEqual(first["headers"], []string{"n"}) {
+        t.Fatalf("got %d rows and headers %v; want 10 rows and [n]", len(rows), first["headers"])
+    }
+    for i := 1; i < len(rows); i++ {
+        if prev, cur := rows[i-1][0], rows[i][0]; len(prev) > len(cur) || len(prev) == len(cur) && prev >= cur {
+            t.Errorf("rows out of file order: %q before %q", prev, cur)
+        }
+    }
+    other, err := sampleRows(text, 10, 7, opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if reflect.DeepEqual(other["rows"], first["rows"]) {
+        t.Error("seeds 7 and 42 drew the same sample")
+    }
+}
+
+func TestSampleRowsSmallInput(t *testing.T) {
+    got, err := sampleRows(numberedCSV(3), 100, 1, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := [][]string{{"0"}, {"1"}, {"2"}}; !reflect.DeepEqual(got["rows"], want) {
+        t.Errorf("rows = %v; want every row %v", got["rows"], want)
+    }
+    if _, err := sampleRows("n\n", -1, 1, csvOptions{}); errorMap(err)["code"] != codeBadArgument {
+        t.Errorf("negative k: %v; want bad_argument", err)
+    }
+}
+
+func TestSampleValuesPerColumn(t *testing.T) {
+    const text = "id,team,note\n1,red,\n2,red,x\n3,blue,\n4,red,x\n5,green,y\n"
+    got, err := sampleValuesPerColumn(text, 2, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    // Repeats and blanks are passed over; values keep first-seen order.
+    want := [][]string{{"1", "2"}, {"red", "blue"}, {"x", "y"}}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("sampleValuesPerColumn = %v; want %v", got, want)
+    }
+    got, err = sampleValuesPerColumn("a\nb,c\n", 5, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := [][]string{{"a", "b"}, {"c"}}; !reflect.DeepEqual(got, want) {
+        t.Errorf("widening rows: got %v; want %v", got, want)
+    }
+    if _, err := sampleValuesPerColumn(text, 0, csvOptions{}); err == nil {
+        t.Error("perColumn 0 accepted")
+    }
+}
+
+func TestSampleValuesPerColumnStopsEarly(t *testing.T) {
+    // A bare quote far down the file is a parse error, so getting samples back at all
+    // shows the scan never reached it.
+    text := numberedCSV(200_000) + "bad\"quote\n"
+    got, err := sampleValuesPerColumn(text, 3, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatalf("scan read to the end: %v", err)
+    }
+    if want := [][]string{{"0", "1", "2"}}; !reflect.DeepEqual(got, want) {
+        t.Errorf("sampleValuesPerColumn = %v; want %v", got, want)
+    }
+    // A column that stays sparse keeps the scan going, all the way to the bad line.
+    sparse := "a,b\n" + strings.Repeat("1,\n2,\n", 1000) + "bad\"quote,\n"
+    if _, err := sampleValuesPerColumn(sparse, 1, csvOptions{HasHeader: true}); err == nil {
+        t.Error("sparse column did not keep the scan going")
+    }
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+    "strconv"
+    "strings"
+)
+
+// ColumnSchema describes one column as reported by inferSchema.
+type ColumnSchema struct {
+    Name     string
+    Type     string
+    Nullable bool
+    Distinct int
+}
+
+// toMap renders c for JavaScript.
+func (c ColumnSchema) toMap() map[string]any {
+    return map[string]any{
+        "name":     c.Name,
+        "type":     c.Type,
+        "nullable": c.Nullable,
+        "distinct": c.Distinct,
+    }
+}
+
+// schema turns the accumulated figures into one descriptor per column. Names come from
+// the header row when there is one and default to col_1, col_2, ... otherwise.
+func (a *summaryAccumulator) schema() []ColumnSchema {
+    out := make([]ColumnSchema, max(len(a.cols), len(a.labels)))
+    for i := range out {
+        var col columnAccumulator
+        if i < len(a.cols) {
+            col = a.cols[i]
+        }
+        name := "col_" + strconv.Itoa(a.source(i)+1)
+        if i < len(a.labels) && a.labels[i] != "" {
+            name = a.labels[i]
+        }
+        out[i] = ColumnSchema{
+            Name:     name,
+            Type:     col.types.result(),
+            Nullable: col.empty.result(a.observed()) > 0,
+            Distinct: col.card.result(),
+        }
+    }
+    return out
+}
+
+// inferSchema describes every column of csvText: its name, inferred type, whether any
+// cell is empty, and its distinct value count (-1 past opts.CardinalityCap).
+func inferSchema(csvText string, opts csvOptions) ([]ColumnSchema, error) {
+    opts.Cardinality = true
+    acc := newSummaryAccumulator(opts)
+    if err := readRecords(strings.NewReader(csvText), opts, acc.add); err != nil {
+        return nil, err
+    }
+    return acc.schema(), nil
+}
+
+// profileAccumulator reads csvText once with cardinality and stats tracking on, for the
+// profile helpers below, stopping with errCancelled when opts.CancelToken is flagged.
+func profileAccumulator(csvText string, opts csvOptions) (*summaryAccumulator, error) {
+    opts.Cardinality = true
+    opts.Stats = true
+    acc := newSummaryAccumulator(opts)
+    records, cancelled := 0, false
+    err := readRecordsAt(strings.NewReader(csvText), opts, func(record []string, line int) bool {
+        records++
+        if opts.cancelled(records) {
+            cancelled = true
+            return false
+        }
+        acc.add(record)
+        return true
+    })
+    if err == nil && cancelled {
+        err = errCancelled
+    }
+    if err != nil {
+        return nil, err
+    }
+    return acc, nil
+}
+
+// profileHeader is the header row of inferProfile's output.
+var profileHeader = []string{"name", "type", "nullable", "distinctCount", "min", "max", "mean"}
+
+// inferProfile is inferSchema with the column stats folded in, rendered as a CSV table
+// with one row per column under profileHeader. The min, max and mean fields are empty
+// for columns that are not fully numeric, and are rounded to opts.Precision when set.
+func inferProfile(csvText string, opts csvOptions) (string, error) {
+    acc, err := profileAccumulator(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    rows := [][]string{profileHeader}
+    for i, c := range acc.schema() {
+        row := []string{c.Name, c.Type, strconv.FormatBool(c.Nullable), strconv.Itoa(c.Distinct), "", "", ""}
+        if i < len(acc.cols) {
+            if s, ok := acc.cols[i].stats.result(); ok {
+                if opts.Precision != nil {
+                    s = s.rounded(*opts.Precision)
+                }
+                for j, x := range []float64{s.Min, s.Max, s.Mean} {
+                    row[4+j] = strconv.FormatFloat(x, 'f', -1, 64)
+                }
+            }
+        }
+        rows = append(rows, row)
+    }
+    return encodeCSV(rows, false)
+}
+
+// profile describes every column of csvText from a single parse: name, type, fillRate
+// (the share of non-empty cells), distinct (-1 past opts.CardinalityCap) and, for fully
+// numeric columns, min, max and mean rounded to opts.Precision when set.
+func profile(csvText string, opts csvOptions) ([]map[string]any, error) {
+    acc, err := profileAccumulator(csvText, opts)
+    if err != nil {
+        return nil, err
+    }
+    rows := acc.observed()
+    schema := acc.schema()
+    out := make([]map[string]any, len(schema))
+    for i, c := range schema {
+        empty := rows
+        if i < len(acc.cols) {
+            empty = acc.cols[i].empty.result(rows)
+        }
+        desc := map[string]any{
+            "name":     c.Name,
+            "type":     c.Type,
+            "fillRate": fillRate(empty, rows),
+            "distinct": c.Distinct,
+        }
+        if i < len(acc.cols) {
+            if s, ok := acc.cols[i].stats.result(); ok {
+                if opts.Precision != nil {
+                    s = s.rounded(*opts.Precision)
+                }
+                desc["min"], desc["max"], desc["mean"] = s.Min, s.Max, s.Mean
+            }
+        }
+        out[i] = desc
+    }
+    return out, nil
+}
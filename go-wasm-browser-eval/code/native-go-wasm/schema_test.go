@@ -0,0 +1,112 @@
+package main
+
+import (
+    "encoding/csv"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestInferSchema(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        opts csvOptions
+        want []ColumnSchema
+    }{
+        {
+            "header names and nullable",
+            "id,name,score\n1,ann,\n2,,3.5\n3,ann,4\n",
+            csvOptions{HasHeader: true},
+            []ColumnSchema{
+                {Name: "id", Type: "integer", Nullable: false, Distinct: 3},
+                {Name: "name", Type: "string", Nullable: true, Distinct: 2},
+                {Name: "score", Type: "float", Nullable: true, Distinct: 3},
+            },
+        },
+        {
+            "default names without a header",
+            "1,ann\n2,bob\n",
+            csvOptions{},
+            []ColumnSchema{
+                {Name: "col_1", Type: "integer", Distinct: 2},
+                {Name: "col_2", Type: "string", Distinct: 2},
+            },
+        },
+        {
+            "short row makes a column nullable",
+            "a,b\n1,2\n3\n",
+            csvOptions{HasHeader: true},
+            []ColumnSchema{
+                {Name: "a", Type: "integer", Distinct: 2},
+                {Name: "b", Type: "integer", Nullable: true, Distinct: 1},
+            },
+        },
+        {
+            "blank header cell falls back to col_N",
+            "a,\n1,2\n",
+            csvOptions{HasHeader: true},
+            []ColumnSchema{
+                {Name: "a", Type: "integer", Distinct: 1},
+                {Name: "col_2", Type: "integer", Distinct: 1},
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := inferSchema(tt.text, tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("inferSchema = %+v; want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestInferProfile(t *testing.T) {
+    text, err := inferProfile("id,name,score\n1,ann,2.5\n2,,3\n", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    got, err := csv.NewReader(strings.NewReader(text)).ReadAll()
+    if err != nil {
+        t.Fatalf("profile does not parse back: %v\n%s", err, text)
+    }
+    want := [][]string{
+        {"name", "type", "nullable", "distinctCount", "min", "max", "mean"},
+        {"id", "integer", "false", "2", "1", "2", "1.5"},
+        {"name", "string", "true", "2", "", "", ""},
+        {"score", "float", "false", "2", "2.5", "3", "2.75"},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("inferProfile = %q; want %q", got, want)
+    }
+}
+
+func TestProfile(t *testing.T) {
+    got, err := profile("id,name,score,when\n1,ann,2.5,\n2,,x,2024-01-01\n3,bob,4,\n", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    // Only the fully numeric id column carries min, max and mean; the stray x makes
+    // score textual.
+    want := []map[string]any{
+        {"name": "id", "type": "integer", "fillRate": 1.0, "distinct": 3, "min": 1.0, "max": 3.0, "mean": 2.0},
+        {"name": "name", "type": "string", "fillRate": 2.0 / 3, "distinct": 3},
+        {"name": "score", "type": "string", "fillRate": 1.0, "distinct": 3},
+        {"name": "when", "type": "date", "fillRate": 1.0 / 3, "distinct": 2},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("profile = %v\nwant %v", got, want)
+    }
+    two := 2
+    got, err = profile("v\n1\n2\n2\n", csvOptions{HasHeader: true, Precision: &two})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got[0]["mean"] != 1.67 {
+        t.Errorf("mean with precision 2 = %v; want 1.67", got[0]["mean"])
+    }
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+    "math"
+    "regexp"
+    "slices"
+    "strconv"
+    "strings"
+)
+
+// decimalCommaNumber matches numbers written with "." thousands grouping and a ","
+// decimal mark, such as "1.234,56", "-0,5" or "1234".
+var decimalCommaNumber = regexp.MustCompile(`^[+-]?(\d{1,3}(\.\d{3})+|\d+)(,\d+)?$`)
+
+// normalizeDecimal rewrites a decimal-comma number into the form strconv.ParseFloat
+// accepts when separator is ','. Anything else, including values that merely contain
+// dots and commas such as dotted dates, is returned unchanged.
+func normalizeDecimal(value string, separator rune) string {
+    if separator != ',' || !decimalCommaNumber.MatchString(value) {
+        return value
+    }
+    return strings.Replace(strings.ReplaceAll(value, ".", ""), ",", ".", 1)
+}
+
+// Stats summarizes the numeric values of one column.
+type Stats struct {
+    Min    float64
+    Max    float64
+    Mean   float64
+    StdDev float64
+    Count  int
+}
+
+// statsTracker accumulates Stats in a single pass using Welford's algorithm, so it can
+// run inside the streaming summarizer. A column is disqualified by any non-numeric cell.
+type statsTracker struct {
+    stats   Stats
+    m2      float64
+    invalid bool
+}
+
+// observe folds one cell into the running statistics. Empty cells are ignored; "NaN"
+// and "Inf" parse as floats but would poison every figure, so they disqualify the column
+// like any other non-numeric cell.
+func (t *statsTracker) observe(value string) {
+    if value == "" || t.invalid {
+        return
+    }
+    x, err := strconv.ParseFloat(value, 64)
+    if err != nil || math.IsNaN(x) || math.IsInf(x, 0) {
+        t.invalid = true
+        return
+    }
+    s := &t.stats
+    if s.Count == 0 {
+        s.Min, s.Max = x, x
+    }
+    s.Min = math.Min(s.Min, x)
+    s.Max = math.Max(s.Max, x)
+    s.Count++
+    delta := x - s.Mean
+    s.Mean += delta / float64(s.Count)
+    t.m2 += delta * (x - s.Mean)
+}
+
+// result returns the finished Stats and whether the column was entirely numeric.
+// StdDev is the sample standard deviation and is zero for fewer than two values.
+func (t *statsTracker) result() (Stats, bool) {
+    if t.invalid || t.stats.Count == 0 {
+        return Stats{}, false
+    }
+    s := t.stats
+    if s.Count > 1 {
+        s.StdDev = math.Sqrt(t.m2 / float64(s.Count-1))
+    }
+    return s, true
+}
+
+// rounded returns s with Min, Max, Mean and StdDev rounded half away from zero to places
+// decimal places.
+func (s Stats) rounded(places int) Stats {
+    scale := math.Pow10(places)
+    round := func(x float64) float64 { return math.Round(x*scale) / scale }
+    s.Min, s.Max, s.Mean, s.StdDev = round(s.Min), round(s.Max), round(s.Mean), round(s.StdDev)
+    return s
+}
+
+// toMap renders s for JavaScript.
+func (s Stats) toMap() map[string]any {
+    return map[string]any{
+        "min":    s.Min,
+        "max":    s.Max,
+        "mean":   s.Mean,
+        "stdDev": s.StdDev,
+        "count":  s.Count,
+    }
+}
+
+// numericStats computes Stats for each column of rows that parses fully as numbers, in
+// one pass with a statsTracker per column. Columns containing any non-numeric, non-empty
+// cell are omitted.
+func numericStats(rows [][]string) map[int]Stats {
+    var trackers []statsTracker
+    for _, row := range rows {
+        for len(trackers) < len(row) {
+            trackers = append(trackers, statsTracker{})
+        }
+        for i, value := range row {
+            trackers[i].observe(value)
+        }
+    }
+    stats := map[int]Stats{}
+    for i := range trackers {
+        if s, ok := trackers[i].result(); ok {
+            stats[i] = s
+        }
+    }
+    return stats
+}
+
+// columnStatsOf computes the stats of record index src over rows the way the summary
+// accumulator does: cells are trimmed when opts.TrimSpace is set, null tokens count as
+// empty, decimal commas are normalized, and the result is rounded when opts.Precision is set.
+func columnStatsOf(rows [][]string, src int, opts csvOptions) (Stats, bool) {
+    var t statsTracker
+    for _, row := range rows {
+        if src >= len(row) {
+            continue
+        }
+        value := row[src]
+        if opts.TrimSpace {
+            value = strings.TrimSpace(value)
+        }
+        if opts.isNullToken(value) {
+            value = ""
+        }
+        t.observe(normalizeDecimal(value, opts.DecimalSeparator))
+    }
+    s, ok := t.result()
+    if ok && opts.Precision != nil {
+        s = s.rounded(*opts.Precision)
+    }
+    return s, ok
+}
+
+// serialTableStats runs columnStatsOf for each of sources in turn, keeping the fully
+// numeric columns.
+func serialTableStats(rows [][]string, sources []int, opts csvOptions) map[int]Stats {
+    stats := map[int]Stats{}
+    for _, src := range sources {
+        if s, ok := columnStatsOf(rows, src, opts); ok {
+            stats[src] = s
+        }
+    }
+    return stats
+}
+
+// columnEntropy returns the Shannon entropy, in bits, of the distribution of values in
+// col, counting blanks as a value like groupByCount does. A constant column, or no rows
+// at all, has entropy 0.
+func columnEntropy(rows [][]string, col int) float64 {
+    entropy := 0.0
+    for _, n := range groupByCount(rows, col) {
+        p := float64(n) / float64(len(rows))
+        entropy -= p * math.Log2(p)
+    }
+    // A single value gives -1*log2(1), which is negative zero.
+    return math.Abs(entropy)
+}
+
+// correlation returns the Pearson correlation coefficient between colA and colB over the
+// rows where both cells parse as finite numbers. Fewer than two such pairs, or a column
+// that is constant across them, leaves the coefficient undefined and is an error rather
+// than a NaN.
+func correlation(rows [][]string, colA, colB int) (float64, error) {
+    var n, meanA, meanB, coMoment, m2A, m2B float64
+    for _, row := range rows {
+        a, errA := strconv.ParseFloat(cell(row, colA), 64)
+        b, errB := strconv.ParseFloat(cell(row, colB), 64)
+        if errA != nil || errB != nil || math.IsInf(a, 0) || math.IsInf(b, 0) || math.IsNaN(a) || math.IsNaN(b) {
+            continue
+        }
+        // Welford-style co-moment update, stable for large offsets as in statsTracker.
+        n++
+        deltaA := a - meanA
+        meanA += deltaA / n
+        deltaB := b - meanB
+        meanB += deltaB / n
+        coMoment += deltaA * (b - meanB)
+        m2A += deltaA * (a - meanA)
+        m2B += deltaB * (b - meanB)
+    }
+    if n < 2 {
+        return 0, badArgument("correlation needs at least two rows where both columns are numeric, got %d", int(n))
+    }
+    if m2A == 0 || m2B == 0 {
+        return 0, badArgument("correlation is undefined when a column is constant")
+    }
+    return coMoment / math.Sqrt(m2A*m2B), nil
+}
+
+// quantiles returns the qs quantiles of the numeric cells of col, using linear
+// interpolation between the closest ranks of the sorted values (the same definition as
+// numpy's default and R's type 7). Blank, non-numeric and non-finite cells are skipped.
+func quantiles(rows [][]string, col int, qs []float64) ([]float64, error) {
+    for _, q := range qs {
+        if !(q >= 0 && q <= 1) {
+            return nil, badArgument("quantile %v is outside [0, 1]", q)
+        }
+    }
+    values := make([]float64, 0, len(rows))
+    for _, row := range rows {
+        x, err := strconv.ParseFloat(cell(row, col), 64)
+        if err != nil || math.IsInf(x, 0) || math.IsNaN(x) {
+            continue
+        }
+        values = append(values, x)
+    }
+    if len(values) == 0 {
+        return nil, badArgument("column %d has no numeric values", col)
+    }
+    slices.Sort(values)
+    out := make([]float64, len(qs))
+    for i, q := range qs {
+        rank := q * float64(len(values)-1)
+        lo := int(math.Floor(rank))
+        hi := min(lo+1, len(values)-1)
+        out[i] = values[lo] + (rank-float64(lo))*(values[hi]-values[lo])
+    }
+    return out, nil
+}
+
+// detectOutliers flags the numeric cells of col outside Tukey's fences,
+// [Q1 - 1.5*IQR, Q3 + 1.5*IQR], with the quartiles computed as in quantiles. It returns
+// the fences and quartiles alongside the 1-based line numbers of the outliers (at most
+// maxRowErrors of them). Blank, non-numeric and non-finite cells and the header (when
+// opts.HasHeader is set) are skipped. It takes csvText rather than parsed rows because
+// the line numbers come from the reader; a multiline field would throw off any count
+// recovered from row indexes.
+func detectOutliers(csvText string, col int, opts csvOptions) (map[string]any, error) {
+    var rows [][]string
+    var lines []int
+    skipHeader := opts.HasHeader
+    width := 0
+    err := readRecordsAt(strings.NewReader(csvText), opts, func(record []string, line int) bool {
+        width = max(width, len(record))
+        if skipHeader {
+            skipHeader = false
+            return true
+        }
+        rows = append(rows, slices.Clone(record))
+        lines = append(lines, line)
+        return true
+    })
+    if err != nil {
+        return nil, err
+    }
+    if err := checkColumn(col, width); err != nil {
+        return nil, err
+    }
+    q, err := quantiles(rows, col, []float64{0.25, 0.75})
+    if err != nil {
+        return nil, err
+    }
+    iqr := q[1] - q[0]
+    lower, upper := q[0]-1.5*iqr, q[1]+1.5*iqr
+    outliers := []int{}
+    for r, row := range rows {
+        x, err := strconv.ParseFloat(cell(row, col), 64)
+        if err != nil || math.IsNaN(x) || math.IsInf(x, 0) {
+            continue
+        }
+        if (x < lower || x > upper) && len(outliers) < maxRowErrors {
+            outliers = append(outliers, lines[r])
+        }
+    }
+    return map[string]any{
+        "q1":       q[0],
+        "q3":       q[1],
+        "iqr":      iqr,
+        "lower":    lower,
+        "upper":    upper,
+        "outliers": outliers,
+    }, nil
+}
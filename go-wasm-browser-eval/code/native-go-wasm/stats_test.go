@@ -0,0 +1,384 @@
+package main
+
+import (
+    "fmt"
+    "math"
+    "reflect"
+    "testing"
+)
+
+// approx reports whether a and b agree to within 1e-9.
+func approx(a, b float64) bool {
+    return math.Abs(a-b) < 1e-9
+}
+
+func TestStatsTracker(t *testing.T) {
+    tests := []struct {
+        name   string
+        values []string
+        want   Stats
+        ok     bool
+    }{
+        // mean 2.5, squared deviations 2.25+0.25+0.25+2.25 = 5, sample variance 5/3.
+        {"positive", []string{"1", "2", "3", "4"}, Stats{Min: 1, Max: 4, Mean: 2.5, StdDev: math.Sqrt(5.0 / 3), Count: 4}, true},
+        // mean 0, squared deviations 25+1+1+25 = 52, sample variance 52/3.
+        {"negative", []string{"-5", "-1", "", "1", "5"}, Stats{Min: -5, Max: 5, Mean: 0, StdDev: math.Sqrt(52.0 / 3), Count: 4}, true},
+        {"single value", []string{"7.5"}, Stats{Min: 7.5, Max: 7.5, Mean: 7.5, Count: 1}, true},
+        {"non-numeric cell", []string{"1", "x", "3"}, Stats{}, false},
+        {"NaN", []string{"1", "NaN"}, Stats{}, false},
+        {"Inf", []string{"Inf", "2"}, Stats{}, false},
+        {"negative Inf", []string{"1", "-Inf"}, Stats{}, false},
+        {"only empty", []string{"", ""}, Stats{}, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var tracker statsTracker
+            for _, v := range tt.values {
+                tracker.observe(v)
+            }
+            got, ok := tracker.result()
+            if ok != tt.ok {
+                t.Fatalf("ok = %v; want %v", ok, tt.ok)
+            }
+            if got.Count != tt.want.Count || !approx(got.Min, tt.want.Min) || !approx(got.Max, tt.want.Max) ||
+                !approx(got.Mean, tt.want.Mean) || !approx(got.StdDev, tt.want.StdDev) {
+                t.Errorf("stats = %+v; want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSummaryStats(t *testing.T) {
+    result, err := summaryFromCSV("a,b,c\n-2,1,x\n4,NaN,2\n", csvOptions{HasHeader: true, Stats: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    stats := result["stats"].(map[int]Stats)
+    if len(stats) != 1 {
+        t.Fatalf("stats = %v; want only column 0", stats)
+    }
+    if s := stats[0]; s.Min != -2 || s.Max != 4 || s.Mean != 1 || s.Count != 2 {
+        t.Errorf("column 0 stats = %+v", s)
+    }
+}
+
+func TestNumericStats(t *testing.T) {
+    rows := [][]string{
+        {"-1", "1", "x"},
+        {"-3", "", "2"},
+        {"5", "3"},
+    }
+    stats := numericStats(rows)
+    if len(stats) != 2 {
+        t.Fatalf("stats = %v; want columns 0 and 1 only", stats)
+    }
+    // mean 1/3, squared deviations 16/9+100/9+196/9 = 312/9, sample variance 156/9.
+    if s := stats[0]; s.Min != -3 || s.Max != 5 || !approx(s.Mean, 1.0/3) || !approx(s.StdDev, math.Sqrt(156.0/9)) || s.Count != 3 {
+        t.Errorf("column 0 stats = %+v", s)
+    }
+    if s := stats[1]; s.Min != 1 || s.Max != 3 || s.Mean != 2 || s.Count != 2 {
+        t.Errorf("column 1 stats = %+v", s)
+    }
+    if got := numericStats(nil); len(got) != 0 {
+        t.Errorf("numericStats(nil) = %v; want empty", got)
+    }
+}
+
+func TestNormalizeDecimal(t *testing.T) {
+    tests := []struct {
+        value     string
+        separator rune
+        want      string
+    }{
+        {"1.234,56", ',', "1234.56"},
+        {"-0,5", ',', "-0.5"},
+        {"1.234.567", ',', "1234567"},
+        {"1234", ',', "1234"},
+        {"01.02.2024", ',', "01.02.2024"},
+        {"1,2,3", ',', "1,2,3"},
+        {"1.234,56", '.', "1.234,56"},
+        {"1.5", 0, "1.5"},
+    }
+    for _, tt := range tests {
+        if got := normalizeDecimal(tt.value, tt.separator); got != tt.want {
+            t.Errorf("normalizeDecimal(%q, %q) = %q; want %q", tt.value, tt.separator, got, tt.want)
+        }
+    }
+}
+
+func TestSummaryDecimalComma(t *testing.T) {
+    text := "price;qty\n1.234,50;2\n0,50;3\n10;4\n"
+    summary, err := summaryFromCSV(text, csvOptions{HasHeader: true, Delimiter: ';', DecimalSeparator: ',', Stats: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    price := summary["stats"].(map[int]Stats)[0]
+    if !approx(price.Mean, 415) || price.Min != 0.5 || price.Max != 1234.5 {
+        t.Errorf("price stats = %+v; want mean 415 over 1234.5, 0.5 and 10", price)
+    }
+    if types := summary["types"].([]string); types[0] != "float" {
+        t.Errorf("price type = %q; want float", types[0])
+    }
+}
+
+func TestDecimalSeparatorValidation(t *testing.T) {
+    tests := []struct {
+        opts    csvOptions
+        message string
+    }{
+        {csvOptions{DecimalSeparator: ','}, "decimal separator ',' requires a delimiter other than ','"},
+        {csvOptions{Delimiter: ',', DecimalSeparator: ','}, "decimal separator ',' requires a delimiter other than ','"},
+        {csvOptions{Delimiter: ';', DecimalSeparator: '\''}, "decimal separator must be '.' or ','"},
+    }
+    for _, tt := range tests {
+        if err := tt.opts.validate(); err == nil || err.Error() != tt.message {
+            t.Errorf("validate(%+v) = %v; want %q", tt.opts, err, tt.message)
+        }
+    }
+    if err := (csvOptions{Delimiter: '\t', DecimalSeparator: ','}).validate(); err != nil {
+        t.Errorf("decimal comma with tab delimiter rejected: %v", err)
+    }
+}
+
+func TestStatsPrecision(t *testing.T) {
+    s := Stats{Min: 1.005, Max: 2.5, Mean: 10.0 / 3, StdDev: 0.4999, Count: 3}
+    tests := []struct {
+        places int
+        want   Stats
+    }{
+        {2, Stats{Min: 1, Max: 2.5, Mean: 3.33, StdDev: 0.5, Count: 3}},
+        {0, Stats{Min: 1, Max: 3, Mean: 3, StdDev: 0, Count: 3}},
+    }
+    for _, tt := range tests {
+        if got := s.rounded(tt.places); got != tt.want {
+            t.Errorf("rounded(%d) = %+v; want %+v", tt.places, got, tt.want)
+        }
+    }
+}
+
+func TestSummaryPrecision(t *testing.T) {
+    two, zero := 2, 0
+    text := "n\n1\n2\n7\n"
+    tests := []struct {
+        precision *int
+        mean      float64
+    }{
+        {nil, 10.0 / 3},
+        {&two, 3.33},
+        {&zero, 3},
+    }
+    for _, tt := range tests {
+        summary, err := summaryFromCSV(text, csvOptions{HasHeader: true, Stats: true, Precision: tt.precision})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got := summary["stats"].(map[int]Stats)[0].Mean; !approx(got, tt.mean) {
+            t.Errorf("precision %v: mean = %v; want %v", tt.precision, got, tt.mean)
+        }
+    }
+    for _, bad := range []int{-1, maxPrecision + 1} {
+        if err := (csvOptions{Precision: &bad}).validate(); err == nil {
+            t.Errorf("precision %d accepted", bad)
+        }
+    }
+}
+
+func TestCorrelation(t *testing.T) {
+    tests := []struct {
+        name string
+        rows [][]string
+        want float64
+    }{
+        {"perfect", [][]string{{"1", "10"}, {"2", "20"}, {"3", "30"}, {"4", "40"}}, 1},
+        {"anti", [][]string{{"1", "8"}, {"2", "6"}, {"3", "4"}, {"4", "2"}}, -1},
+        // x deviations -1.5,-0.5,0.5,1.5 against y 1,-1,-1,1 sum to zero.
+        {"uncorrelated", [][]string{{"1", "1"}, {"2", "-1"}, {"3", "-1"}, {"4", "1"}}, 0},
+        {"skips non-numeric pairs", [][]string{{"1", "2"}, {"x", "9"}, {"2", "4"}, {"3", ""}, {"3", "6"}}, 1},
+        {"large offset", [][]string{{"1e9", "1e9"}, {"1000000001", "1000000002"}, {"1000000002", "1000000004"}}, 1},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := correlation(tt.rows, 0, 1)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !approx(got, tt.want) {
+                t.Errorf("correlation = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCorrelationUndefined(t *testing.T) {
+    tests := []struct {
+        name string
+        rows [][]string
+        msg  string
+    }{
+        {"one pair", [][]string{{"1", "2"}, {"x", "3"}}, "correlation needs at least two rows where both columns are numeric, got 1"},
+        {"no rows", nil, "correlation needs at least two rows where both columns are numeric, got 0"},
+        {"NaN", [][]string{{"NaN", "1"}, {"1", "Inf"}}, "correlation needs at least two rows where both columns are numeric, got 0"},
+        {"constant", [][]string{{"1", "5"}, {"2", "5"}, {"3", "5"}}, "correlation is undefined when a column is constant"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := correlation(tt.rows, 0, 1)
+            if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.msg {
+                t.Errorf("got %v; want bad_argument %q", m, tt.msg)
+            }
+        })
+    }
+}
+
+func TestQuantiles(t *testing.T) {
+    tests := []struct {
+        name   string
+        values []string
+        qs     []float64
+        want   []float64
+    }{
+        {"odd median", []string{"5", "1", "3"}, []float64{0.5}, []float64{3}},
+        {"even median", []string{"4", "1", "3", "2"}, []float64{0.5}, []float64{2.5}},
+        // 1..11: rank q*10, so 0.9 is 10 and 0.99 interpolates between 10 and 11.
+        {"percentiles", []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}, []float64{0, 0.25, 0.9, 0.99, 1}, []float64{1, 3.5, 10, 10.9, 11}},
+        {"skips non-numeric", []string{"x", "", "2", "NaN", "4", "Inf"}, []float64{0.5}, []float64{3}},
+        {"skips negative Inf", []string{"-Inf", "2", "4"}, []float64{0}, []float64{2}},
+        {"single value", []string{"7"}, []float64{0, 0.5, 1}, []float64{7, 7, 7}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rows := make([][]string, len(tt.values))
+            for i, v := range tt.values {
+                rows[i] = []string{"id", v}
+            }
+            got, err := quantiles(rows, 1, tt.qs)
+            if err != nil {
+                t.Fatal(err)
+            }
+            for i := range tt.want {
+                if !approx(got[i], tt.want[i]) {
+                    t.Errorf("quantiles = %v; want %v", got, tt.want)
+                    break
+                }
+            }
+        })
+    }
+}
+
+func TestQuantilesErrors(t *testing.T) {
+    rows := [][]string{{"1"}, {"2"}}
+    for _, q := range []float64{-0.1, 1.5, math.NaN()} {
+        _, err := quantiles(rows, 0, []float64{0.5, q})
+        if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != fmt.Sprintf("quantile %v is outside [0, 1]", q) {
+            t.Errorf("q=%v: got %v", q, m)
+        }
+    }
+    _, err := quantiles([][]string{{"a"}, {""}}, 0, []float64{0.5})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != "column 0 has no numeric values" {
+        t.Errorf("no numeric values: got %v", m)
+    }
+}
+
+func TestColumnEntropy(t *testing.T) {
+    tests := []struct {
+        name   string
+        values []string
+        want   float64
+    }{
+        {"uniform two values", []string{"a", "b", "a", "b"}, 1},
+        {"constant", []string{"x", "x", "x"}, 0},
+        {"uniform four values", []string{"a", "b", "c", "d"}, 2},
+        // p = 3/4 and 1/4: -(0.75*log2(0.75) + 0.25*log2(0.25)).
+        {"skewed", []string{"a", "a", "a", "b"}, -(0.75*math.Log2(0.75) + 0.25*math.Log2(0.25))},
+        {"blank is a value", []string{"", "a"}, 1},
+        {"no rows", nil, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rows := make([][]string, len(tt.values))
+            for i, v := range tt.values {
+                rows[i] = []string{v}
+            }
+            got := columnEntropy(rows, 0)
+            if !approx(got, tt.want) || math.Signbit(got) {
+                t.Errorf("columnEntropy = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSummaryNullTokens(t *testing.T) {
+    text := "n,s\n1,NA\nNA,x\n3,NULL\nna,-\n"
+    tests := []struct {
+        name       string
+        ignoreCase bool
+        types      []string
+        empty      []int
+    }{
+        // "na" only matches "NA" when case is ignored; until then it keeps column 0 textual.
+        {"exact", false, []string{"string", "string"}, []int{1, 2}},
+        {"ignore case", true, []string{"integer", "string"}, []int{2, 2}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            summary, err := summaryFromCSV(text, csvOptions{HasHeader: true, Stats: true, NullTokens: []string{"NA", "-"}, NullIgnoreCase: tt.ignoreCase})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got := summary["types"].([]string); !reflect.DeepEqual(got, tt.types) {
+                t.Errorf("types = %v; want %v", got, tt.types)
+            }
+            if got := summary["emptyCounts"].([]int); !reflect.DeepEqual(got, tt.empty) {
+                t.Errorf("emptyCounts = %v; want %v", got, tt.empty)
+            }
+            stats := summary["stats"].(map[int]Stats)
+            if !tt.ignoreCase {
+                if len(stats) != 0 {
+                    t.Errorf("stats = %v; want none while \"na\" is a value", stats)
+                }
+                return
+            }
+            if s := stats[0]; s.Count != 2 || s.Min != 1 || s.Max != 3 || s.Mean != 2 {
+                t.Errorf("column 0 stats = %+v; want the two numbers only", s)
+            }
+        })
+    }
+    summary, err := summaryFromCSV(text, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := summary["emptyCounts"].([]int); !reflect.DeepEqual(got, []int{0, 0}) {
+        t.Errorf("emptyCounts without null tokens = %v; want [0 0]", got)
+    }
+}
+
+func TestDetectOutliers(t *testing.T) {
+    // Without 100 and -50 the quartiles are 10.5 and 12.5, so the fences are 7.5 and 15.5.
+    got, err := detectOutliers("id,v\n1,10\n2,11\n3,12\n4,13\n5,100\n6,x\n7,-50\n8,12\n9,\n", 1, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := map[string]any{"q1": 10.5, "q3": 12.5, "iqr": 2.0, "lower": 7.5, "upper": 15.5, "outliers": []int{6, 8}}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("detectOutliers = %v; want %v", got, want)
+    }
+    got, err = detectOutliers("v\n1\n2\n3\n4\n5\n", 0, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if outliers := got["outliers"].([]int); len(outliers) != 0 {
+        t.Errorf("outliers in an evenly spread column = %v; want none", outliers)
+    }
+    // Inf and -Inf are not numbers to flag, and they do not widen the quartiles either.
+    got, err = detectOutliers("v\n1\n2\nInf\n3\n-Inf\n4\n5\n", 0, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if outliers := got["outliers"].([]int); len(outliers) != 0 || got["q1"] != 2.0 || got["q3"] != 4.0 {
+        t.Errorf("with infinite cells: got %v; want quartiles 2 and 4 and no outliers", got)
+    }
+    _, err = detectOutliers("v\nx\n", 0, csvOptions{HasHeader: true})
+    if m := errorMap(err); m["code"] != codeBadArgument {
+        t.Errorf("no numeric values: got %v", m)
+    }
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+    "bytes"
+    "encoding/csv"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "hash"
+)
+
+// pushStream summarizes CSV fed in arbitrary byte chunks from JS, for example from a
+// ReadableStream reader. Complete records are folded into the accumulator as they arrive;
+// the bytes after the last record boundary wait in pending for the next push.
+type pushStream struct {
+    opts    csvOptions
+    acc     *summaryAccumulator
+    pending []byte
+    // detected and guessedHeader record which of the delimiter and header flag were
+    // sniffed, so the result can report them like wasmCSVSummary does.
+    detected, guessedHeader bool
+    // lines is the number of physical lines already parsed, used to make parse error
+    // line numbers absolute.
+    lines   int
+    records int
+    // width is the field count every record must match when opts.Strict is set, taken
+    // from the stream's first record; 0 until that record has been parsed.
+    width   int
+    counter lineCounter
+    // checksum hashes every pushed byte when opts.Checksum is set.
+    checksum hash.Hash
+}
+
+var (
+    // streams maps live stream handles to their state; finished streams are deleted.
+    streams          = map[int]*pushStream{}
+    nextStreamHandle = 1
+)
+
+// recordBoundary returns the index just past the last newline in data that is not inside
+// a quoted field, or 0 when data holds no complete record. Quote state is tracked by
+// toggling on every '"', which also handles doubled quotes. Lines starting with comment
+// (when non-zero) are skipped whole, as csv.Reader skips them, so a stray quote in a
+// comment cannot leave the rest of the stream looking quoted.
+func recordBoundary(data []byte, comment rune) int {
+    var prefix []byte
+    if comment != 0 {
+        prefix = []byte(string(comment))
+    }
+    quoted, end, lineStart := false, 0, true
+    for i := 0; i < len(data); i++ {
+        if lineStart && prefix != nil && bytes.HasPrefix(data[i:], prefix) {
+            n := bytes.IndexByte(data[i:], '\n')
+            if n < 0 {
+                break
+            }
+            i += n
+            end = i + 1
+            continue
+        }
+        lineStart = false
+        switch b := data[i]; {
+        case b == '"':
+            quoted = !quoted
+        case b == '\n' && !quoted:
+            end = i + 1
+            lineStart = true
+        }
+    }
+    return end
+}
+
+// push appends data and parses every record it completes. Parsing starts once a full
+// detection sample has arrived, so sniffing sees the same bytes as a single-shot summary.
+func (s *pushStream) push(data []byte) error {
+    if s.opts.isCancelled() {
+        return errCancelled
+    }
+    s.counter.count(data)
+    if s.checksum != nil {
+        s.checksum.Write(data)
+    }
+    s.pending = append(s.pending, data...)
+    if s.acc == nil {
+        if len(s.pending) < detectSampleBytes {
+            return nil
+        }
+        s.start()
+    }
+    return s.drain(recordBoundary(s.pending, s.opts.Comment))
+}
+
+// start sniffs any unset delimiter or header flag from the buffered sample and creates
+// the accumulator.
+func (s *pushStream) start() {
+    s.pending = bytes.TrimPrefix(s.pending, []byte(utf8BOM))
+    sample := string(s.pending[:min(len(s.pending), detectSampleBytes)])
+    s.detected = detectUnsetDelimiter(&s.opts, sample)
+    s.guessedHeader = detectUnsetHeader(&s.opts, sample)
+    s.acc = newSummaryAccumulator(s.opts)
+}
+
+// drain parses pending[:end] into the accumulator and keeps the rest for later.
+func (s *pushStream) drain(end int) error {
+    if end == 0 {
+        return nil
+    }
+    chunk := s.pending[:end]
+    cancelled := false
+    var widthErr error
+    err := readRecordsAt(bytes.NewReader(chunk), s.opts, func(record []string, line int) bool {
+        if s.opts.Strict {
+            // Each drain gets a fresh csv.Reader, which only holds records to the width
+            // of the first one in its own chunk, so the stream-wide width is checked here.
+            if s.width == 0 {
+                s.width = len(record)
+            } else if len(record) != s.width {
+                widthErr = fmt.Errorf("failed to parse csv: %w", &csv.ParseError{StartLine: line, Line: line, Column: 1, Err: csv.ErrFieldCount})
+                return false
+            }
+        }
+        s.records++
+        if s.opts.cancelled(s.records) {
+            cancelled = true
+            return false
+        }
+        s.acc.add(record)
+        return true
+    })
+    if err == nil && cancelled {
+        return errCancelled
+    }
+    if err == nil {
+        err = widthErr
+    }
+    if err != nil {
+        var parseErr *csv.ParseError
+        if errors.As(err, &parseErr) {
+            parseErr.StartLine += s.lines
+            parseErr.Line += s.lines
+        }
+        return err
+    }
+    s.lines += bytes.Count(chunk, []byte{'\n'})
+    s.pending = append([]byte(nil), s.pending[end:]...)
+    return nil
+}
+
+// finish parses whatever is still buffered and returns the summary in the same shape as
+// wasmCSVSummary.
+func (s *pushStream) finish() (map[string]any, error) {
+    if s.acc == nil {
+        s.start()
+    }
+    if err := s.drain(len(s.pending)); err != nil {
+        return nil, err
+    }
+    result := s.acc.result()
+    result["physicalVsLogicalLines"] = map[string]any{
+        "physicalLines":  s.counter.lines(),
+        "logicalRecords": s.records,
+    }
+    result["dialect"] = s.counter.dialect(s.opts)
+    if s.checksum != nil {
+        result["checksum"] = hex.EncodeToString(s.checksum.Sum(nil))
+    }
+    if s.detected {
+        result["detectedDelimiter"] = string(s.opts.Delimiter)
+    }
+    if s.guessedHeader {
+        result["headerDetected"] = s.opts.HasHeader
+    }
+    return result, nil
+}
@@ -0,0 +1,64 @@
+//go:build js && wasm
+
+package main
+
+import "syscall/js"
+
+// streamArg resolves the stream handle at args[0].
+func streamArg(args []js.Value) (int, *pushStream, error) {
+    if len(args) < 1 || args[0].Type() != js.TypeNumber {
+        return 0, nil, badArgument("expected a stream handle")
+    }
+    handle := args[0].Int()
+    s, ok := streams[handle]
+    if !ok {
+        return 0, nil, badArgument("unknown or finished stream handle %d", handle)
+    }
+    return handle, s, nil
+}
+
+// wrapStreamStart opens a push stream: wasmStreamStart(options?) returns a handle for
+// wasmStreamPush and wasmStreamFinish. The checksum option adds a digest of the raw bytes.
+func wrapStreamStart(this js.Value, args []js.Value) any {
+    opts, err := optionsArg(args, 0)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    handle := nextStreamHandle
+    nextStreamHandle++
+    streams[handle] = &pushStream{opts: opts, checksum: newChecksum(opts.Checksum)}
+    return handle
+}
+
+// wrapStreamPush feeds one chunk: wasmStreamPush(handle, uint8array). Records may be split
+// anywhere across chunks. A parse error ends the stream.
+func wrapStreamPush(this js.Value, args []js.Value) any {
+    handle, s, err := streamArg(args)
+    if err != nil {
+        return errorMap(err)
+    }
+    data, err := bytesArg(args, 1)
+    if err != nil {
+        return errorResult(codeBadArgument, err.Error())
+    }
+    if err := s.push(data); err != nil {
+        delete(streams, handle)
+        return errorMap(err)
+    }
+    return nil
+}
+
+// wrapStreamFinish flushes the stream and returns its summary: wasmStreamFinish(handle).
+// The handle is released either way.
+func wrapStreamFinish(this js.Value, args []js.Value) any {
+    handle, s, err := streamArg(args)
+    if err != nil {
+        return errorMap(err)
+    }
+    delete(streams, handle)
+    result, err := s.finish()
+    if err != nil {
+        return errorMap(err)
+    }
+    return toJS(result)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "hash/crc32"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// streamText is larger than one detection sample, so pushing it in pieces makes the
+// stream drain several times. It has a quoted field with an embedded delimiter, a doubled
+// quote and a newline to split across chunks.
+func streamText() string {
+    var b strings.Builder
+    b.WriteString("id,name,score\n")
+    for i := 1; b.Len() < 3*detectSampleBytes; i++ {
+        fmt.Fprintf(&b, "%d,\"user %d, \"\"x\"\"\nline two\",%d.5\n", i, i, i%97)
+    }
+    return b.String()
+}
+
+// pushInPieces feeds text to a new stream size bytes at a time and finishes it.
+func pushInPieces(text string, size int, opts csvOptions) (map[string]any, error) {
+    s := &pushStream{opts: opts}
+    for start := 0; start < len(text); start += size {
+        if err := s.push([]byte(text[start:min(start+size, len(text))])); err != nil {
+            return nil, err
+        }
+    }
+    return s.finish()
+}
+
+func TestPushStreamMatchesSingleShot(t *testing.T) {
+    text := streamText()
+    opts := csvOptions{Delimiter: ',', HasHeader: true, Stats: true}
+    want, err := summarizeStream(strings.NewReader(text), opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // Odd sizes land split points inside quoted fields, on doubled quotes and between
+    // the two halves of an embedded newline.
+    for _, size := range []int{1 << 20, 65536, 4099, 331, 7} {
+        t.Run(fmt.Sprint(size), func(t *testing.T) {
+            got, err := pushInPieces(text, size, opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, want) {
+                t.Errorf("pushed in %d-byte chunks:\n got %v\nwant %v", size, got, want)
+            }
+        })
+    }
+}
+
+func TestPushStreamSplitRecord(t *testing.T) {
+    text := streamText()
+    // Split the first record after the sample inside its quoted field, so one push ends
+    // mid-record and the next completes it.
+    cut := detectSampleBytes + strings.Index(text[detectSampleBytes:], "user") + 2
+    s := &pushStream{opts: csvOptions{Delimiter: ',', HasHeader: true}}
+    if err := s.push([]byte(text[:cut])); err != nil {
+        t.Fatal(err)
+    }
+    if len(s.pending) == 0 || s.pending[len(s.pending)-1] == '\n' {
+        t.Fatalf("pending = %q; want the start of a split record", s.pending)
+    }
+    if err := s.push([]byte(text[cut:])); err != nil {
+        t.Fatal(err)
+    }
+    got, err := s.finish()
+    if err != nil {
+        t.Fatal(err)
+    }
+    // Every data record spans two lines, under a one-line header.
+    want := (strings.Count(text, "\n") - 1) / 2
+    if got["rows"] != want {
+        t.Errorf("rows = %v; want %d", got["rows"], want)
+    }
+}
+
+func TestRecordBoundary(t *testing.T) {
+    tests := []struct {
+        name    string
+        data    string
+        comment rune
+        want    int
+    }{
+        {"no newline", "a,b", 0, 0},
+        {"last newline", "a,b\n1,2\n3", 0, 8},
+        {"newline in quotes", "a,\"x\ny\"\n1,\"z\n", 0, 8},
+        {"quote in comment", "# it\"s\na,b\n1,2\n", '#', 15},
+        {"quote in comment without the option", "# it\"s\na,b\n", 0, 0},
+        {"unfinished comment", "a,b\n# \"x", '#', 4},
+        {"comment only at line start", "a,#\"\n1,2\n", '#', 0},
+        {"multibyte comment", "§ \"\na\n", '§', 7},
+    }
+    for _, tt := range tests {
+        if got := recordBoundary([]byte(tt.data), tt.comment); got != tt.want {
+            t.Errorf("%s: recordBoundary(%q) = %d; want %d", tt.name, tt.data, got, tt.want)
+        }
+    }
+}
+
+func TestPushStreamCommentWithQuote(t *testing.T) {
+    text := "# it's a \"draft\n" + streamText()
+    opts := csvOptions{Delimiter: ',', HasHeader: true, Comment: '#'}
+    want, err := summarizeStream(strings.NewReader(text), opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    s := &pushStream{opts: opts}
+    half := len(text) / 2
+    if err := s.push([]byte(text[:half])); err != nil {
+        t.Fatal(err)
+    }
+    // The first push drains up to its last record; the comment's quote must not hold
+    // everything back until finish.
+    if len(s.pending) >= half-detectSampleBytes || s.records == 0 {
+        t.Fatalf("after the first push: %d records parsed, %d bytes pending", s.records, len(s.pending))
+    }
+    if err := s.push([]byte(text[half:])); err != nil {
+        t.Fatal(err)
+    }
+    got, err := s.finish()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("pushed:\n got %v\nwant %v", got, want)
+    }
+}
+
+func TestPushStreamStrictAcrossDrains(t *testing.T) {
+    text := streamText()
+    s := &pushStream{opts: csvOptions{Delimiter: ',', HasHeader: true, Strict: true}}
+    if err := s.push([]byte(text)); err != nil {
+        t.Fatal(err)
+    }
+    if s.width != 3 {
+        t.Fatalf("width = %d after the first drain; want 3", s.width)
+    }
+    lines := strings.Count(text, "\n")
+    // The short record starts a new drain, where a fresh csv.Reader would accept it.
+    if err := s.push([]byte("1,2\n")); err == nil {
+        t.Fatal("short record in a later push was accepted under strict")
+    } else if m := errorMap(err); m["code"] != codeParseError || m["errorLine"] != lines+1 {
+        t.Errorf("got %v; want a parse_error on line %d", m, lines+1)
+    }
+}
+
+func TestPushStreamStrictInFinish(t *testing.T) {
+    s := &pushStream{opts: csvOptions{Delimiter: ',', Strict: true}}
+    if err := s.push([]byte(streamText())); err != nil {
+        t.Fatal(err)
+    }
+    // An unterminated last record is only parsed by finish, in its own drain.
+    if err := s.push([]byte("1,2,3,4")); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := s.finish(); err == nil {
+        t.Error("finish accepted a trailing record of the wrong width under strict")
+    }
+    if _, err := pushInPieces(streamText()+"1,2\n", detectSampleBytes, csvOptions{Delimiter: ','}); err != nil {
+        t.Errorf("ragged record without strict: %v", err)
+    }
+}
+
+func TestPushStreamChecksum(t *testing.T) {
+    // The BOM is part of the bytes fed, so it is part of the digest too.
+    text := "\ufeff" + streamText()
+    crc := crc32.ChecksumIEEE([]byte(text))
+    sum := sha256.Sum256([]byte(text))
+    tests := []struct {
+        name string
+        want string
+    }{
+        {"crc32", hex.EncodeToString([]byte{byte(crc >> 24), byte(crc >> 16), byte(crc >> 8), byte(crc)})},
+        {"sha256", hex.EncodeToString(sum[:])},
+    }
+    for _, tt := range tests {
+        for _, size := range []int{len(text), 4099, 1} {
+            s := &pushStream{opts: csvOptions{Delimiter: ',', HasHeader: true}, checksum: newChecksum(tt.name)}
+            for start := 0; start < len(text); start += size {
+                if err := s.push([]byte(text[start:min(start+size, len(text))])); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            result, err := s.finish()
+            if err != nil {
+                t.Fatal(err)
+            }
+            if result["checksum"] != tt.want {
+                t.Errorf("%s in %d-byte chunks = %v; want %s", tt.name, size, result["checksum"], tt.want)
+            }
+        }
+    }
+    result, err := pushInPieces(text, 4099, csvOptions{Delimiter: ','})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, ok := result["checksum"]; ok {
+        t.Error("checksum reported without the option")
+    }
+}
+
+func TestChecksumValidation(t *testing.T) {
+    for _, name := range []string{"", "crc32", "sha256"} {
+        if err := (csvOptions{Checksum: name}).validate(); err != nil {
+            t.Errorf("checksum %q rejected: %v", name, err)
+        }
+    }
+    if err := (csvOptions{Checksum: "md5"}).validate(); err == nil || err.Error() != `unknown checksum "md5" (want crc32 or sha256)` {
+        t.Errorf("validate with md5 = %v", err)
+    }
+}
@@ -0,0 +1,656 @@
+package main
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/csv"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "math/rand/v2"
+    "slices"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// csvOptions controls how a CSV payload is read. The zero value matches csv.Reader's defaults.
+// From JavaScript the fields are set through an options object using the keys in brackets.
+type csvOptions struct {
+    // Delimiter is the field separator; 0 means comma. [delimiter]
+    Delimiter rune
+    // HasHeader treats the first record as column labels rather than data. [header]
+    HasHeader bool
+    // HeaderUnset records that the caller gave no header flag at all, letting the summary
+    // wrappers guess HasHeader with hasHeaderHeuristic.
+    HeaderUnset bool
+    // Strict requires every record to have as many fields as the first one. [strict]
+    Strict bool
+    // Stats adds min/max/mean/stddev for fully numeric columns under "stats". [stats]
+    Stats bool
+    // TrimSpace drops leading whitespace while parsing and trailing whitespace before
+    // type inference and stats. [trimSpace]
+    TrimSpace bool
+    // Comment skips lines starting with this rune; 0 disables comments. [comment]
+    Comment rune
+    // Dedupe adds "uniqueRows" and "duplicateRows" counts. [dedupe]
+    Dedupe bool
+    // DedupeKey restricts duplicate detection to these column indices; setting it
+    // implies Dedupe. [dedupeKey]
+    DedupeKey []int
+    // Precision rounds the floats in "stats", and the cells wasmApplyExpr writes, to this
+    // many decimal places; nil keeps full precision. [precision]
+    Precision *int
+    // DecimalSeparator is the decimal mark numbers use, '.' (the default) or ','. With ','
+    // values such as "1.234,56" are read as 1234.56 for type inference and stats.
+    // [decimalSeparator]
+    DecimalSeparator rune
+    // MaxFieldBytes aborts parsing with a parse_error identifying the row once any single
+    // field exceeds this many bytes; zero disables the limit. [maxFieldBytes]
+    MaxFieldBytes int
+    // ProfileCSV makes wasmInferSchema return its per-column profile, stats included,
+    // as a CSV table instead of an array of objects. [profileCSV]
+    ProfileCSV bool
+    // SampleFraction, between 0 and 1, feeds only that share of data rows (picked by a
+    // generator seeded with SampleSeed, so reruns agree) to the per-column figures for
+    // fast approximate profiling. Row counts and dedupe stay exact, empty counts and
+    // stats counts are scaled up to the full row count, and the result is marked
+    // "approximate". 0 and 1 both mean exact. [sampleFraction]
+    SampleFraction float64
+    // SampleSeed seeds the SampleFraction row picker. [sampleSeed]
+    SampleSeed int64
+    // LineNumbers makes wasmFilterRows and wasmSearchAny prepend a "__line__" column
+    // holding the 1-based physical line each kept row starts on. [lineNumbers]
+    LineNumbers bool
+    // FlagEmpty makes the column validators (wasmValidateEmails, wasmRegexValidate and
+    // wasmEnumValidate) test empty cells instead of skipping them. [flagEmpty]
+    FlagEmpty bool
+    // NullTokens are cell values, such as "NA" or "NULL", that count as empty for type
+    // inference, empty counts, stats and the other per-column figures. Matching is exact
+    // after any trimming. [nullTokens]
+    NullTokens []string
+    // NullIgnoreCase matches NullTokens case-insensitively. [nullIgnoreCase]
+    NullIgnoreCase bool
+    // SkipBlankRows leaves out data rows whose every field is blank and reports how many
+    // were dropped under "blankRowsSkipped". [skipBlankRows]
+    SkipBlankRows bool
+    // DropTrailingEmpty trims empty fields off the end of every record before columns,
+    // types and stats are computed, so "a,b," counts as two fields. [dropTrailingEmpty]
+    DropTrailingEmpty bool
+    // Cardinality adds distinct value counts per column under "cardinality". [cardinality]
+    Cardinality bool
+    // CandidateKeys adds "candidateKeys", the indices of columns where every data row
+    // has a distinct non-blank value. Unique columns keep every value in memory until
+    // the end. [candidateKeys]
+    CandidateKeys bool
+    // CardinalityCap is the most distinct values tracked per column before it is
+    // reported as -1; non-positive means defaultCardinalityCap. [cardinalityCap]
+    CardinalityCap int
+    // Columns restricts the per-column figures (types, empty counts, widths, stats,
+    // cardinality) to these record indices, in this order, reported back as
+    // "selectedColumns"; rows are still counted in full. Stats stay keyed by the original
+    // index. nil tracks every column. [columns]
+    Columns []int
+    // IgnoreCase makes value predicates such as wasmCountWhere, and the comparison in
+    // wasmColumnMatch, fold case. [ignoreCase]
+    IgnoreCase bool
+    // Negate makes wasmFilterRows keep the rows that do not match. [negate]
+    Negate bool
+    // Checksum makes wasmStreamFinish report the hex digest of every byte pushed under
+    // "checksum", using "crc32" or "sha256"; empty disables it. [checksum]
+    Checksum string
+    // IncludeTiming adds "parseMillis", the wall-clock time spent reading and
+    // summarizing. [includeTiming]
+    IncludeTiming bool
+    // MaxRows stops reading after this many data rows and marks the result
+    // "truncated"; zero or negative means unlimited. [maxRows]
+    MaxRows int
+    // Progress, when set, receives the number of records processed so far at most
+    // every progressInterval during streaming summaries. [onProgress]
+    Progress func(processed int)
+    // Chunk, when set, receives the output of wasmRequote and wasmCanonicalize in
+    // pieces of ChunkRows records instead of one returned string. [onChunk]
+    Chunk func(chunk string)
+    // CancelToken is a wasmNewToken id; once wasmCancel flags it, streaming summaries
+    // and profiles stop with a "cancelled" error. Zero means none. [cancelToken]
+    CancelToken int
+    // ChunkRows is the number of records per Chunk call; zero means
+    // defaultChunkRows. [chunkRows]
+    ChunkRows int
+}
+
+// maxPrecision is the most decimal places a float64 can meaningfully be rounded to.
+const maxPrecision = 15
+
+// validate rejects option combinations csv.Reader would trip over mid-parse.
+func (o csvOptions) validate() error {
+    delimiter := o.Delimiter
+    if delimiter == 0 {
+        delimiter = ','
+    }
+    if o.Comment != 0 && o.Comment == delimiter {
+        return errors.New("comment character must differ from the delimiter")
+    }
+    for _, col := range o.Columns {
+        if col < 0 {
+            return fmt.Errorf("column index %d out of range", col)
+        }
+    }
+    if o.Precision != nil && (*o.Precision < 0 || *o.Precision > maxPrecision) {
+        return fmt.Errorf("precision must be between 0 and %d", maxPrecision)
+    }
+    if o.CancelToken != 0 {
+        if err := lookupToken(o.CancelToken); err != nil {
+            return err
+        }
+    }
+    if o.ChunkRows < 0 {
+        return fmt.Errorf("chunkRows must not be negative, got %d", o.ChunkRows)
+    }
+    if !(o.SampleFraction >= 0 && o.SampleFraction <= 1) {
+        return fmt.Errorf("sampleFraction must be between 0 and 1, got %v", o.SampleFraction)
+    }
+    if o.Checksum != "" && o.Checksum != "crc32" && o.Checksum != "sha256" {
+        return fmt.Errorf("unknown checksum %q (want crc32 or sha256)", o.Checksum)
+    }
+    switch o.DecimalSeparator {
+    case 0, '.':
+    case ',':
+        if delimiter == ',' {
+            return errors.New("decimal separator ',' requires a delimiter other than ','")
+        }
+    default:
+        return errors.New("decimal separator must be '.' or ','")
+    }
+    return nil
+}
+
+// isNullToken reports whether value is one of o.NullTokens.
+func (o csvOptions) isNullToken(value string) bool {
+    for _, token := range o.NullTokens {
+        if value == token || o.NullIgnoreCase && strings.EqualFold(value, token) {
+            return true
+        }
+    }
+    return false
+}
+
+// utf8BOM is the byte-order mark Excel and friends prepend to UTF-8 exports.
+const utf8BOM = "\ufeff"
+
+// stripBOM returns r with a leading UTF-8 byte-order mark skipped, if present.
+func stripBOM(r io.Reader) io.Reader {
+    buffered := bufio.NewReader(r)
+    if prefix, err := buffered.Peek(len(utf8BOM)); err == nil && string(prefix) == utf8BOM {
+        buffered.Discard(len(utf8BOM))
+    }
+    return buffered
+}
+
+// newCSVReader builds a csv.Reader over r configured from opts. A leading BOM is
+// stripped so it never leaks into the first header. Records may be ragged unless
+// opts.Strict is set, in which case a mismatched record yields a *csv.ParseError.
+func newCSVReader(r io.Reader, opts csvOptions) *csv.Reader {
+    r = stripBOM(r)
+    if opts.MaxFieldBytes > 0 {
+        r = newFieldLimitReader(r, opts.MaxFieldBytes, opts.Delimiter)
+    }
+    reader := csv.NewReader(r)
+    if opts.Delimiter != 0 {
+        reader.Comma = opts.Delimiter
+    }
+    reader.FieldsPerRecord = -1
+    reader.TrimLeadingSpace = opts.TrimSpace
+    reader.Comment = opts.Comment
+    if opts.Strict {
+        reader.FieldsPerRecord = 0
+    }
+    return reader
+}
+
+// columnAccumulator holds the running aggregates for one column.
+type columnAccumulator struct {
+    types typeTracker
+    stats statsTracker
+    empty emptyTracker
+    card  cardinalityTracker
+    width widthTracker
+    key   keyTracker
+    // bytes is the UTF-8 length of every cell seen, before any trimming.
+    bytes int
+}
+
+// summaryAccumulator gathers summary figures one record at a time so that no more than
+// the current record is ever held in memory.
+type summaryAccumulator struct {
+    opts    csvOptions
+    headers []string
+    // labels are the header cells lined up with cols: headers itself, or the selected
+    // subset when opts.Columns is set.
+    labels    []string
+    sawHeader bool
+    rows      int
+    blankRows int
+    columns   int
+    widths    map[int]int
+    cols      []columnAccumulator
+    dedupe    dedupeTracker
+    // sampler picks the rows observed under opts.SampleFraction; nil means every row.
+    // sampled counts the rows it let through.
+    sampler *rand.Rand
+    sampled int
+}
+
+// newSummaryAccumulator returns an empty accumulator configured from opts.
+func newSummaryAccumulator(opts csvOptions) *summaryAccumulator {
+    if opts.CardinalityCap <= 0 {
+        opts.CardinalityCap = defaultCardinalityCap
+    }
+    a := &summaryAccumulator{
+        opts:   opts,
+        cols:   make([]columnAccumulator, len(opts.Columns)),
+        widths: map[int]int{},
+        dedupe: dedupeTracker{key: opts.DedupeKey},
+    }
+    if opts.SampleFraction > 0 && opts.SampleFraction < 1 {
+        a.sampler = rand.New(rand.NewPCG(uint64(opts.SampleSeed), 0))
+    }
+    return a
+}
+
+// observed is the number of data rows the per-column figures saw.
+func (a *summaryAccumulator) observed() int {
+    if a.sampler != nil {
+        return a.sampled
+    }
+    return a.rows
+}
+
+// scaled extrapolates a count over the observed rows to all rows; it is the identity
+// unless rows are being sampled.
+func (a *summaryAccumulator) scaled(n int) int {
+    if a.sampler == nil || a.sampled == 0 {
+        return n
+    }
+    return int(math.Round(float64(n) * float64(a.rows) / float64(a.sampled)))
+}
+
+// trimTrailingEmpty returns record without its trailing empty fields.
+func trimTrailingEmpty(record []string) []string {
+    end := len(record)
+    for end > 0 && record[end-1] == "" {
+        end--
+    }
+    return record[:end]
+}
+
+// add folds one record into the running summary. The record may be reused by the caller.
+func (a *summaryAccumulator) add(record []string) {
+    if a.opts.DropTrailingEmpty {
+        record = trimTrailingEmpty(record)
+    }
+    a.columns = max(a.columns, len(record))
+    if a.opts.HasHeader && !a.sawHeader {
+        a.headers = slices.Clone(record)
+        a.labels = a.headers
+        if a.opts.Columns != nil {
+            a.labels = make([]string, len(a.opts.Columns))
+            for i, src := range a.opts.Columns {
+                a.labels[i] = cell(a.headers, src)
+            }
+        }
+        a.sawHeader = true
+        return
+    }
+    if a.opts.SkipBlankRows && !slices.ContainsFunc(record, func(v string) bool { return !isBlank(v) }) {
+        a.blankRows++
+        return
+    }
+    a.rows++
+    a.widths[len(record)]++
+    if a.opts.Dedupe {
+        a.dedupe.observe(record)
+    }
+    if a.sampler != nil {
+        if a.sampler.Float64() >= a.opts.SampleFraction {
+            return
+        }
+        a.sampled++
+    }
+    if a.opts.Columns != nil {
+        // Columns a short row does not reach are left unobserved, exactly as below.
+        for i, src := range a.opts.Columns {
+            if src < len(record) {
+                a.observe(i, record[src])
+            }
+        }
+        return
+    }
+    for len(a.cols) < len(record) {
+        a.cols = append(a.cols, columnAccumulator{})
+    }
+    for i, value := range record {
+        a.observe(i, value)
+    }
+}
+
+// observe folds one cell into the trackers of a.cols[i].
+func (a *summaryAccumulator) observe(i int, value string) {
+    a.cols[i].bytes += len(value)
+    if a.opts.TrimSpace {
+        value = strings.TrimSpace(value)
+    }
+    if a.opts.isNullToken(value) {
+        value = ""
+    }
+    col := &a.cols[i]
+    col.empty.observe(value)
+    col.width.observe(value)
+    number := normalizeDecimal(value, a.opts.DecimalSeparator)
+    col.types.observe(number)
+    if a.opts.Stats {
+        col.stats.observe(number)
+    }
+    if a.opts.CandidateKeys {
+        col.key.observe(value)
+    }
+    if a.opts.Cardinality {
+        col.card.observe(value, a.opts.CardinalityCap)
+    }
+}
+
+// source returns the record index that a.cols[i] tracks.
+func (a *summaryAccumulator) source(i int) int {
+    if a.opts.Columns != nil {
+        return a.opts.Columns[i]
+    }
+    return i
+}
+
+// result renders the accumulated figures in the map shape returned to JavaScript.
+func (a *summaryAccumulator) result() map[string]any {
+    types := make([]string, len(a.cols))
+    for i := range a.cols {
+        types[i] = a.cols[i].types.result()
+    }
+    // Every column up to the header width gets an empty count, even if no data row reached it.
+    empties := make([]int, max(len(a.cols), len(a.labels)))
+    for i := range empties {
+        if i < len(a.cols) {
+            empties[i] = a.scaled(a.cols[i].empty.result(a.observed()))
+        } else {
+            empties[i] = a.rows
+        }
+    }
+    rates := make([]float64, len(empties))
+    for i, n := range empties {
+        rates[i] = fillRate(n, a.rows)
+    }
+    // Header labels count toward the widths so a fixed-width preview fits them too.
+    widths := make([]int, len(empties))
+    for i := range widths {
+        if i < len(a.cols) {
+            widths[i] = a.cols[i].width.max
+        }
+        if i < len(a.labels) {
+            widths[i] = max(widths[i], utf8.RuneCountInString(a.labels[i]))
+        }
+    }
+    // Byte sizes are UTF-8 lengths, not rune counts, and include the header cells like the widths.
+    sizes := make([]int, len(empties))
+    for i := range sizes {
+        if i < len(a.cols) {
+            sizes[i] = a.cols[i].bytes
+        }
+        if i < len(a.labels) {
+            sizes[i] += len(a.labels[i])
+        }
+    }
+    result := map[string]any{
+        "columnBytes": sizes,
+        "rows":        a.rows,
+        "columns":     a.columns,
+        "types":       types,
+        "emptyCounts": empties,
+        "fillRates":   rates,
+        "maxWidths":   widths,
+        // Rows per field count; ragged files show more than one entry.
+        "widthDistribution": a.widths,
+    }
+    if a.opts.HasHeader {
+        headers := a.headers
+        if headers == nil {
+            headers = []string{}
+        }
+        result["headers"] = headers
+    }
+    if a.opts.Stats {
+        stats := map[int]Stats{}
+        for i := range a.cols {
+            if s, ok := a.cols[i].stats.result(); ok {
+                if a.opts.Precision != nil {
+                    s = s.rounded(*a.opts.Precision)
+                }
+                s.Count = a.scaled(s.Count)
+                stats[a.source(i)] = s
+            }
+        }
+        result["stats"] = stats
+    }
+    if a.opts.Columns != nil {
+        result["selectedColumns"] = a.opts.Columns
+    }
+    if a.opts.SkipBlankRows {
+        result["blankRowsSkipped"] = a.blankRows
+    }
+    if a.opts.CandidateKeys {
+        keys := []int{}
+        for i := range a.cols {
+            if a.cols[i].key.unique(a.observed()) {
+                keys = append(keys, a.source(i))
+            }
+        }
+        result["candidateKeys"] = keys
+    }
+    if a.opts.Cardinality {
+        cardinality := make([]int, len(a.cols))
+        for i := range a.cols {
+            cardinality[i] = a.cols[i].card.result()
+        }
+        result["cardinality"] = cardinality
+    }
+    if a.opts.Dedupe {
+        for k, v := range a.dedupe.result() {
+            result[k] = v
+        }
+    }
+    if a.sampler != nil {
+        result["approximate"] = true
+        result["sampledRows"] = a.sampled
+    }
+    return result
+}
+
+// readRecords streams records from r to fn, stopping at EOF or the first error.
+// Records are reused between calls, so fn must clone any record it keeps.
+func readRecords(r io.Reader, opts csvOptions, fn func(record []string)) error {
+    return readRecordsAt(r, opts, func(record []string, line int) bool {
+        fn(record)
+        return true
+    })
+}
+
+// readRecordsAt is readRecords with the 1-based line each record starts on. fn returns
+// false to stop reading early.
+func readRecordsAt(r io.Reader, opts csvOptions, fn func(record []string, line int) bool) error {
+    reader := newCSVReader(r, opts)
+    reader.ReuseRecord = true
+    for {
+        record, err := reader.Read()
+        if errors.Is(err, io.EOF) {
+            return nil
+        }
+        if err != nil {
+            return fmt.Errorf("failed to parse csv: %w", err)
+        }
+        line, _ := reader.FieldPos(0)
+        if !fn(record, line) {
+            return nil
+        }
+    }
+}
+
+// readAllRecords parses every record of csvText for helpers that need the whole table.
+func readAllRecords(csvText string, opts csvOptions) ([][]string, error) {
+    rows, err := newCSVReader(strings.NewReader(csvText), opts).ReadAll()
+    if err != nil {
+        return nil, fmt.Errorf("failed to parse csv: %w", err)
+    }
+    return rows, nil
+}
+
+// lineCounter counts the physical lines passing through an io.Reader, noting along the
+// way whether any quote characters or CRLF line endings went by.
+type lineCounter struct {
+    r        io.Reader
+    newlines int
+    bytes    int
+    last     byte
+    quotes   bool
+    crlf     bool
+}
+
+// Read implements io.Reader, tallying newlines as bytes pass through.
+func (c *lineCounter) Read(p []byte) (int, error) {
+    n, err := c.r.Read(p)
+    c.count(p[:n])
+    return n, err
+}
+
+// count tallies p without reading, for callers that are handed bytes directly.
+func (c *lineCounter) count(p []byte) {
+    if len(p) > 0 {
+        c.quotes = c.quotes || bytes.IndexByte(p, '"') >= 0
+        c.crlf = c.crlf || bytes.Contains(p, []byte("\r\n")) || (c.last == '\r' && p[0] == '\n')
+        c.newlines += bytes.Count(p, []byte{'\n'})
+        c.bytes += len(p)
+        c.last = p[len(p)-1]
+    }
+}
+
+// lines returns the number of physical lines seen, counting an unterminated final line.
+func (c *lineCounter) lines() int {
+    if c.bytes > 0 && c.last != '\n' {
+        return c.newlines + 1
+    }
+    return c.newlines
+}
+
+// dialect describes how the text behind c was read: the delimiter, whether any field was
+// quoted, whether lines ended in CRLF and whether the first record was taken as a header,
+// after any detection the wrappers ran. The reader rejects stray quotes, so any quote
+// character in a successful parse means a quoted field, unless it sat in a comment line.
+func (c *lineCounter) dialect(opts csvOptions) map[string]any {
+    delimiter := opts.Delimiter
+    if delimiter == 0 {
+        delimiter = ','
+    }
+    return map[string]any{
+        "delimiter": string(delimiter),
+        "quoted":    c.quotes,
+        "crlf":      c.crlf,
+        "header":    opts.HasHeader,
+    }
+}
+
+// summarizeWith runs the streaming summary over r, additionally handing each record to
+// observe (when non-nil) along with whether it was counted as a data row rather than
+// the header or a skipped blank row.
+func summarizeWith(r io.Reader, opts csvOptions, observe func(record []string, isData bool)) (map[string]any, error) {
+    started := time.Now()
+    counter := &lineCounter{r: r}
+    acc := newSummaryAccumulator(opts)
+    progress := &progressReporter{fn: opts.Progress}
+    records := 0
+    truncatedAt := 0
+    cancelled := false
+    err := readRecordsAt(counter, opts, func(record []string, line int) bool {
+        isData := !opts.HasHeader || acc.sawHeader
+        if isData && opts.MaxRows > 0 && acc.rows >= opts.MaxRows {
+            truncatedAt = line
+            return false
+        }
+        records++
+        progress.tick(records)
+        if opts.cancelled(records) {
+            cancelled = true
+            return false
+        }
+        counted := acc.rows
+        acc.add(record)
+        if observe != nil {
+            // Report what the accumulator counted, so rows dropped by skipBlankRows are
+            // not data rows here either.
+            observe(record, acc.rows > counted)
+        }
+        return true
+    })
+    if err == nil && cancelled {
+        err = errCancelled
+    }
+    if err != nil {
+        return nil, err
+    }
+    result := acc.result()
+    // Quoted fields may span several physical lines, so the two counts can differ.
+    physical := counter.lines()
+    if truncatedAt > 0 {
+        // The reader buffers ahead, so count only the lines before the first unread record.
+        physical = truncatedAt - 1
+        result["truncated"] = true
+    }
+    result["physicalVsLogicalLines"] = map[string]any{
+        "physicalLines":  physical,
+        "logicalRecords": records,
+    }
+    result["dialect"] = counter.dialect(opts)
+    if opts.IncludeTiming {
+        result["parseMillis"] = float64(time.Since(started).Microseconds()) / 1000
+    }
+    return result, nil
+}
+
+// summarizeStream summarizes CSV read from r record by record rather than with ReadAll,
+// so memory use is bounded by the widest record instead of the whole payload.
+// When opts.HasHeader is set the first record is reported under "headers" and is not
+// counted as a row. Rows may be ragged, so "columns" is the widest record seen.
+// "types" holds the inferred type of each column across the data rows, and
+// "physicalVsLogicalLines" contrasts raw line count with parsed record count, and
+// "dialect" reports the delimiter, quoting, line endings and header flag actually used.
+func summarizeStream(r io.Reader, opts csvOptions) (map[string]any, error) {
+    return summarizeWith(r, opts, nil)
+}
+
+// summaryFromCSV summarizes an in-memory CSV payload by streaming it through
+// summarizeStream, so a string input gets the same result shape as every other summary.
+func summaryFromCSV(csvText string, opts csvOptions) (map[string]any, error) {
+    return summarizeStream(strings.NewReader(csvText), opts)
+}
+
+// previewCSV returns the usual summary plus the first n data rows under "preview".
+// A non-positive n yields an empty preview. The header row, and rows dropped by
+// opts.SkipBlankRows, are never part of the preview.
+func previewCSV(csvText string, n int, opts csvOptions) (map[string]any, error) {
+    preview := [][]string{}
+    result, err := summarizeWith(strings.NewReader(csvText), opts, func(record []string, isData bool) {
+        if isData && len(preview) < n {
+            preview = append(preview, slices.Clone(record))
+        }
+    })
+    if err != nil {
+        return nil, err
+    }
+    result["preview"] = preview
+    return result, nil
+}
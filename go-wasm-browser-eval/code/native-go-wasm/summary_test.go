@@ -0,0 +1,540 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "reflect"
+    "runtime"
+    "strings"
+    "testing"
+    "testing/iotest"
+)
+
+func TestSummaryFromCSVDelimiters(t *testing.T) {
+    tests := []struct {
+        name      string
+        text      string
+        delimiter rune
+    }{
+        {"default comma", "a,b,c\n1,2,3\n", 0},
+        {"tab", "a\tb\tc\n1\t2\t3\n", '\t'},
+        {"semicolon", "a;b;c\n1;2,5;3\n", ';'},
+        {"pipe", "a|b|c\n1|2|3\n", '|'},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := summaryFromCSV(tt.text, csvOptions{Delimiter: tt.delimiter})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if result["rows"] != 2 || result["columns"] != 3 {
+                t.Errorf("rows, columns = %v, %v; want 2, 3", result["rows"], result["columns"])
+            }
+        })
+    }
+}
+
+func TestSummaryFromCSVWrongDelimiter(t *testing.T) {
+    result, err := summaryFromCSV("a;b;c\n1;2;3\n", csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if result["columns"] != 1 {
+        t.Errorf("columns = %v; want 1 when the comma default meets semicolons", result["columns"])
+    }
+}
+
+func TestSummaryHeaders(t *testing.T) {
+    tests := []struct {
+        name    string
+        text    string
+        header  bool
+        headers any
+        rows    int
+        columns int
+    }{
+        {"header row", "a,b\n1,2\n", true, []string{"a", "b"}, 1, 2},
+        {"no header", "a,b\n1,2\n", false, nil, 2, 2},
+        {"empty input", "", true, []string{}, 0, 0},
+        {"ragged header", "a,b\n1,2,3\n", true, []string{"a", "b"}, 1, 3},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := summaryFromCSV(tt.text, csvOptions{HasHeader: tt.header})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(result["headers"], tt.headers) {
+                t.Errorf("headers = %#v; want %#v", result["headers"], tt.headers)
+            }
+            if result["rows"] != tt.rows || result["columns"] != tt.columns {
+                t.Errorf("rows, columns = %v, %v; want %d, %d", result["rows"], result["columns"], tt.rows, tt.columns)
+            }
+        })
+    }
+}
+
+// tinyRows is an io.Reader yielding n records of "1,2\n" without ever holding them all.
+type tinyRows struct {
+    n       int
+    pending []byte
+}
+
+func (r *tinyRows) Read(p []byte) (int, error) {
+    written := 0
+    for written < len(p) {
+        if len(r.pending) == 0 {
+            if r.n == 0 {
+                break
+            }
+            r.n--
+            r.pending = []byte("1,2\n")
+        }
+        k := copy(p[written:], r.pending)
+        r.pending = r.pending[k:]
+        written += k
+    }
+    if written == 0 {
+        return 0, io.EOF
+    }
+    return written, nil
+}
+
+func TestSummarizeStreamConstantMemory(t *testing.T) {
+    const rows = 2_000_000
+    var peak uint64
+    var stats runtime.MemStats
+    seen := 0
+    result, err := summarizeWith(&tinyRows{n: rows}, csvOptions{}, func(record []string, isData bool) {
+        seen++
+        if seen%250_000 == 0 {
+            runtime.GC()
+            runtime.ReadMemStats(&stats)
+            peak = max(peak, stats.HeapAlloc)
+        }
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    if result["rows"] != rows {
+        t.Errorf("rows = %v; want %d", result["rows"], rows)
+    }
+    // The input is 8 MB and holding it as records would take several times that; the
+    // live heap while streaming stays well under one megabyte.
+    if peak > 1<<20 {
+        t.Errorf("peak heap %d bytes while streaming %d rows; want it bounded", peak, rows)
+    }
+}
+
+func TestPreviewCSV(t *testing.T) {
+    const text = "a,b\n1,2\n3,4\n5,6\n"
+    tests := []struct {
+        name string
+        text string
+        n    int
+        want [][]string
+        rows int
+    }{
+        {"first rows", text, 2, [][]string{{"1", "2"}, {"3", "4"}}, 3},
+        {"n past the end", text, 10, [][]string{{"1", "2"}, {"3", "4"}, {"5", "6"}}, 3},
+        {"zero", text, 0, [][]string{}, 3},
+        {"negative", text, -1, [][]string{}, 3},
+        {"header only", "a,b\n", 5, [][]string{}, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := previewCSV(tt.text, tt.n, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(result["preview"], tt.want) {
+                t.Errorf("preview = %v; want %v", result["preview"], tt.want)
+            }
+            if result["rows"] != tt.rows {
+                t.Errorf("rows = %v; want %d", result["rows"], tt.rows)
+            }
+        })
+    }
+}
+
+func TestSummaryFromCSVStripsBOM(t *testing.T) {
+    for _, input := range []string{"\ufeffName,n\nx,1\n", "\ufeff\"Name\",n\nx,1\n"} {
+        summary, err := summaryFromCSV(input, csvOptions{HasHeader: true})
+        if err != nil {
+            t.Fatalf("%q: %v", input, err)
+        }
+        if got := summary["headers"].([]string); got[0] != "Name" {
+            t.Errorf("%q: first header = %q; want Name", input, got[0])
+        }
+    }
+}
+
+func TestSummaryMultilineField(t *testing.T) {
+    text := "id,desc\n1,\"line one\nline two\nline three\"\n2,plain\n"
+    summary, err := summaryFromCSV(text, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if summary["rows"] != 2 {
+        t.Errorf("rows = %v; want 2 records, not one per physical line", summary["rows"])
+    }
+    want := map[string]any{"logicalRecords": 3, "physicalLines": 5}
+    if got := summary["physicalVsLogicalLines"]; !reflect.DeepEqual(got, want) {
+        t.Errorf("physicalVsLogicalLines = %v; want %v", got, want)
+    }
+}
+
+func TestSummaryComment(t *testing.T) {
+    summary, err := summaryFromCSV("# exported 2024-01-02\na,b\n#1,skipped\n1,2\n3,4\n", csvOptions{HasHeader: true, Comment: '#'})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if summary["rows"] != 2 {
+        t.Errorf("rows = %v; want 2 with comment lines excluded", summary["rows"])
+    }
+    if _, err := summaryFromCSV("a;b\n", csvOptions{Delimiter: ';', Comment: ';'}); err == nil {
+        t.Error("comment equal to the delimiter accepted")
+    }
+}
+
+// readCounter counts the bytes pulled from r.
+type readCounter struct {
+    r io.Reader
+    n int
+}
+
+func (c *readCounter) Read(p []byte) (int, error) {
+    n, err := c.r.Read(p)
+    c.n += n
+    return n, err
+}
+
+func TestSummarizeStreamMaxRows(t *testing.T) {
+    text := "n\n" + strings.Repeat("1\n", 100000)
+    tests := []struct {
+        name      string
+        maxRows   int
+        rows      int
+        truncated bool
+    }{
+        {"capped", 10, 10, true},
+        {"limit above row count", 200000, 100000, false},
+        {"zero is unlimited", 0, 100000, false},
+        {"negative is unlimited", -1, 100000, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            r := &readCounter{r: strings.NewReader(text)}
+            summary, err := summarizeStream(r, csvOptions{HasHeader: true, MaxRows: tt.maxRows})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if summary["rows"] != tt.rows || (summary["truncated"] == true) != tt.truncated {
+                t.Errorf("rows, truncated = %v, %v; want %d, %v", summary["rows"], summary["truncated"], tt.rows, tt.truncated)
+            }
+            if tt.truncated && r.n > 64<<10 {
+                t.Errorf("read %d of %d bytes after hitting the limit", r.n, len(text))
+            }
+        })
+    }
+}
+
+func TestSummarySkipBlankRows(t *testing.T) {
+    tests := []struct {
+        name    string
+        text    string
+        rows    int
+        skipped int
+    }{
+        {"trailing blank lines", "a,b\n1,2\n,\n,\n", 1, 2},
+        {"row of only commas", "a,b,c\n,,\n1,,\n", 1, 1},
+        {"whitespace-only cells", "a,b\n , \n1,2\n", 1, 1},
+        {"nothing to skip", "a,b\n1,2\n", 1, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            summary, err := summaryFromCSV(tt.text, csvOptions{HasHeader: true, SkipBlankRows: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if summary["rows"] != tt.rows || summary["blankRowsSkipped"] != tt.skipped {
+                t.Errorf("rows, blankRowsSkipped = %v, %v; want %d, %d", summary["rows"], summary["blankRowsSkipped"], tt.rows, tt.skipped)
+            }
+        })
+    }
+    summary, err := summaryFromCSV("a,b\n1,2\n,\n", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if summary["rows"] != 2 {
+        t.Errorf("without skipBlankRows rows = %v; want blank rows counted", summary["rows"])
+    }
+}
+
+func TestPreviewCSVSkipBlankRows(t *testing.T) {
+    result, err := previewCSV("a,b\n,\n1,2\n,\n3,4\n", 10, csvOptions{HasHeader: true, SkipBlankRows: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    preview := result["preview"].([][]string)
+    if want := [][]string{{"1", "2"}, {"3", "4"}}; !reflect.DeepEqual(preview, want) {
+        t.Errorf("preview = %q; want %q without the blank rows", preview, want)
+    }
+    if result["blankRowsSkipped"] != 2 || result["rows"] != len(preview) {
+        t.Errorf("blankRowsSkipped = %v, rows = %v; want 2 skipped and rows matching the %d previewed",
+            result["blankRowsSkipped"], result["rows"], len(preview))
+    }
+}
+
+func TestSummaryWidthDistribution(t *testing.T) {
+    tests := []struct {
+        name   string
+        text   string
+        header bool
+        want   map[int]int
+    }{
+        {"rows of 3, 3 and 5 fields", "1,2,3\n4,5,6\n7,8,9,10,11\n", false, map[int]int{3: 2, 5: 1}},
+        {"header row left out", "a,b,c\n1,2,3\n4,5,6,7,8\n", true, map[int]int{3: 1, 5: 1}},
+        {"well formed", "a,b\n1,2\n3,4\n", true, map[int]int{2: 2}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            summary, err := summaryFromCSV(tt.text, csvOptions{HasHeader: tt.header})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got := summary["widthDistribution"]; !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("widthDistribution = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSummaryIncludeTiming(t *testing.T) {
+    for _, include := range []bool{false, true} {
+        summary, err := summaryFromCSV("a\n1\n", csvOptions{IncludeTiming: include})
+        if err != nil {
+            t.Fatal(err)
+        }
+        millis, ok := summary["parseMillis"]
+        if ok != include {
+            t.Fatalf("includeTiming=%v: parseMillis present = %v", include, ok)
+        }
+        if include {
+            if ms, isFloat := millis.(float64); !isFloat || ms < 0 {
+                t.Errorf("parseMillis = %#v; want a non-negative number", millis)
+            }
+        }
+    }
+}
+
+// wideCSV returns a header and rows records of width fields, every cell distinct so
+// cardinality tracking has something to hold on to.
+func wideCSV(rows, width int) string {
+    var b strings.Builder
+    for r := 0; r <= rows; r++ {
+        for c := 0; c < width; c++ {
+            if c > 0 {
+                b.WriteByte(',')
+            }
+            if r == 0 {
+                fmt.Fprintf(&b, "c%d", c)
+            } else {
+                fmt.Fprintf(&b, "%d", r*width+c)
+            }
+        }
+        b.WriteByte('\n')
+    }
+    return b.String()
+}
+
+// allocatedBy returns the bytes fn allocates on the heap.
+func allocatedBy(fn func()) uint64 {
+    var before, after runtime.MemStats
+    runtime.GC()
+    runtime.ReadMemStats(&before)
+    fn()
+    runtime.ReadMemStats(&after)
+    return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestSummaryColumnsAllocatesLess(t *testing.T) {
+    text := wideCSV(2000, 200)
+    opts := csvOptions{HasHeader: true, Stats: true, Cardinality: true}
+    var full, selected map[string]any
+    var fullErr, selectedErr error
+    fullBytes := allocatedBy(func() { full, fullErr = summarizeStream(strings.NewReader(text), opts) })
+    opts.Columns = []int{3, 150}
+    selectedBytes := allocatedBy(func() { selected, selectedErr = summarizeStream(strings.NewReader(text), opts) })
+    if fullErr != nil || selectedErr != nil {
+        t.Fatal(fullErr, selectedErr)
+    }
+    if selected["rows"] != full["rows"] || selected["rows"] != 2000 {
+        t.Errorf("rows = %v with columns, %v without; want 2000", selected["rows"], full["rows"])
+    }
+    if got := selected["selectedColumns"]; !reflect.DeepEqual(got, []int{3, 150}) {
+        t.Errorf("selectedColumns = %v; want [3 150]", got)
+    }
+    t.Logf("full parse %d bytes, two columns %d bytes", fullBytes, selectedBytes)
+    if selectedBytes*2 > fullBytes {
+        t.Errorf("two of 200 columns allocated %d bytes against %d for all; want under half", selectedBytes, fullBytes)
+    }
+}
+
+func TestSummaryDropTrailingEmpty(t *testing.T) {
+    const text = "a,b,\n1,2,\n3,4,\n"
+    tests := []struct {
+        drop    bool
+        columns int
+        headers []string
+        types   []string
+    }{
+        {false, 3, []string{"a", "b", ""}, []string{"integer", "integer", "string"}},
+        {true, 2, []string{"a", "b"}, []string{"integer", "integer"}},
+    }
+    for _, tt := range tests {
+        result, err := summaryFromCSV(text, csvOptions{HasHeader: true, DropTrailingEmpty: tt.drop})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if result["columns"] != tt.columns {
+            t.Errorf("dropTrailingEmpty=%v: columns = %v; want %d", tt.drop, result["columns"], tt.columns)
+        }
+        if !reflect.DeepEqual(result["headers"], tt.headers) || !reflect.DeepEqual(result["types"], tt.types) {
+            t.Errorf("dropTrailingEmpty=%v: headers %v, types %v; want %v, %v", tt.drop, result["headers"], result["types"], tt.headers, tt.types)
+        }
+    }
+}
+
+func TestSummaryDropTrailingEmptyKeepsInnerBlanks(t *testing.T) {
+    result, err := summaryFromCSV("1,,3,,\n4,,,,\n", csvOptions{DropTrailingEmpty: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    // The inner blank of "1,,3" stays a field; only the run at the end of a record goes.
+    if result["columns"] != 3 {
+        t.Errorf("columns = %v; want 3", result["columns"])
+    }
+    if got := result["widthDistribution"]; !reflect.DeepEqual(got, map[int]int{3: 1, 1: 1}) {
+        t.Errorf("widthDistribution = %v; want map[1:1 3:1]", got)
+    }
+}
+
+func TestSummaryDialect(t *testing.T) {
+    const crlf = "name;city\r\nann;\"Oslo; Norway\"\r\nbob;Rome\r\n"
+    tests := []struct {
+        name string
+        r    io.Reader
+        opts csvOptions
+        want map[string]any
+    }{
+        {
+            "crlf semicolon",
+            strings.NewReader(crlf),
+            csvOptions{Delimiter: ';', HasHeader: true},
+            map[string]any{"delimiter": ";", "quoted": true, "crlf": true, "header": true},
+        },
+        {
+            // A CRLF split between two reads still counts.
+            "one byte at a time",
+            iotest.OneByteReader(strings.NewReader(crlf)),
+            csvOptions{Delimiter: ';', HasHeader: true},
+            map[string]any{"delimiter": ";", "quoted": true, "crlf": true, "header": true},
+        },
+        {
+            "defaults",
+            strings.NewReader("a,b\n1,2\n"),
+            csvOptions{},
+            map[string]any{"delimiter": ",", "quoted": false, "crlf": false, "header": false},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := summarizeStream(tt.r, tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(result["dialect"], tt.want) {
+                t.Errorf("dialect = %v; want %v", result["dialect"], tt.want)
+            }
+        })
+    }
+}
+
+func TestSummaryColumnBytes(t *testing.T) {
+    // 名前 and 東京 are six bytes each and Zoë four, though each is two or three runes.
+    const text = "名前,b\nZoë, 1\n東京,22\n"
+    tests := []struct {
+        name string
+        opts csvOptions
+        want []int
+    }{
+        {"header counted", csvOptions{HasHeader: true}, []int{16, 5}},
+        {"no header", csvOptions{}, []int{16, 5}},
+        {"selected column", csvOptions{HasHeader: true, Columns: []int{1}}, []int{5}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            result, err := summaryFromCSV(text, tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(result["columnBytes"], tt.want) {
+                t.Errorf("columnBytes = %v; want %v", result["columnBytes"], tt.want)
+            }
+        })
+    }
+}
+
+func TestSummarySampleFraction(t *testing.T) {
+    var b strings.Builder
+    b.WriteString("id,group\n")
+    for i := range 1000 {
+        fmt.Fprintf(&b, "%d,g%d\n", i, i%50)
+    }
+    text := b.String()
+    base := csvOptions{HasHeader: true, Stats: true, Cardinality: true}
+    exact, err := summaryFromCSV(text, base)
+    if err != nil {
+        t.Fatal(err)
+    }
+    full := base
+    full.SampleFraction = 1
+    got, err := summaryFromCSV(text, full)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(got, exact) {
+        t.Errorf("fraction 1:\n got %v\nwant %v", got, exact)
+    }
+    sampled := base
+    sampled.SampleFraction = 0.1
+    sampled.SampleSeed = 3
+    partial, err := summaryFromCSV(text, sampled)
+    if err != nil {
+        t.Fatal(err)
+    }
+    n, _ := partial["sampledRows"].(int)
+    if partial["approximate"] != true || n == 0 || n >= 500 {
+        t.Fatalf("approximate = %v, sampledRows = %v; want a marked result over far fewer rows", partial["approximate"], partial["sampledRows"])
+    }
+    if partial["rows"] != 1000 {
+        t.Errorf("rows = %v; want the exact 1000", partial["rows"])
+    }
+    // Stats counts are scaled back up to the full row count.
+    if s := partial["stats"].(map[int]Stats)[0]; s.Count != 1000 {
+        t.Errorf("scaled stats count = %d; want 1000", s.Count)
+    }
+    again, err := summaryFromCSV(text, sampled)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !reflect.DeepEqual(again, partial) {
+        t.Error("the same seed picked a different sample")
+    }
+    for _, bad := range []float64{-0.1, 1.5} {
+        if err := (csvOptions{SampleFraction: bad}).validate(); err == nil {
+            t.Errorf("sampleFraction %v accepted", bad)
+        }
+    }
+}
@@ -0,0 +1,52 @@
+//go:build !tinygo
+
+package main
+
+import (
+    "runtime"
+    "sync"
+)
+
+// parallelStats reports that tableStats fans columns out over a worker pool.
+const parallelStats = true
+
+// tableStats computes per-column stats for sources with a pool of runtime.NumCPU()
+// goroutines, each taking whole columns. Under js/wasm NumCPU is 1 today, so the pool
+// runs one worker until the runtime gains threads.
+func tableStats(rows [][]string, sources []int, opts csvOptions) map[int]Stats {
+    return pooledTableStats(rows, sources, opts, runtime.NumCPU())
+}
+
+// pooledTableStats is tableStats with up to workers goroutines. Results land in a slot
+// per source and are merged in source order, so the output matches serialTableStats
+// exactly whatever the pool size.
+func pooledTableStats(rows [][]string, sources []int, opts csvOptions, workers int) map[int]Stats {
+    type slot struct {
+        stats Stats
+        ok    bool
+    }
+    slots := make([]slot, len(sources))
+    jobs := make(chan int)
+    var wg sync.WaitGroup
+    for range max(1, min(workers, len(sources))) {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for i := range jobs {
+                slots[i].stats, slots[i].ok = columnStatsOf(rows, sources[i], opts)
+            }
+        }()
+    }
+    for i := range sources {
+        jobs <- i
+    }
+    close(jobs)
+    wg.Wait()
+    stats := map[int]Stats{}
+    for i, src := range sources {
+        if slots[i].ok {
+            stats[src] = slots[i].stats
+        }
+    }
+    return stats
+}
@@ -0,0 +1,106 @@
+//go:build !tinygo
+
+package main
+
+import (
+    "fmt"
+    "math/rand"
+    "reflect"
+    "runtime"
+    "testing"
+)
+
+// wideRows builds rows of width columns cycling through integer, float, decimal-comma,
+// text and sparse columns, so the parallel and serial paths see every kind the stats
+// tracker treats differently.
+func wideRows(n, width int) [][]string {
+    rng := rand.New(rand.NewSource(1))
+    rows := make([][]string, n)
+    for r := range rows {
+        row := make([]string, width)
+        for c := range row {
+            switch c % 5 {
+            case 0:
+                row[c] = fmt.Sprint(rng.Intn(1000) - 500)
+            case 1:
+                row[c] = fmt.Sprintf(" %.3f ", rng.NormFloat64()*1e6)
+            case 2:
+                row[c] = fmt.Sprintf("%d,%02d", rng.Intn(100), rng.Intn(100))
+            case 3:
+                row[c] = fmt.Sprintf("id-%d", rng.Intn(50))
+            case 4:
+                if rng.Intn(3) == 0 {
+                    row[c] = "NA"
+                } else {
+                    row[c] = fmt.Sprint(rng.Float64())
+                }
+            }
+        }
+        // An occasional short row leaves the later columns unobserved.
+        if r%17 == 0 {
+            row = row[:width/2]
+        }
+        rows[r] = row
+    }
+    return rows
+}
+
+func TestTableStatsMatchesSerial(t *testing.T) {
+    // The sandbox may report a single CPU, so the pool size is forced up; the file has
+    // several columns per worker plus a remainder, so every goroutine has work and some
+    // take more than others.
+    workers := max(8, runtime.NumCPU())
+    width := 4*workers + 3
+    rows := wideRows(500, width)
+    all := make([]int, width)
+    for i := range all {
+        all[i] = i
+    }
+    precision := 4
+    tests := []struct {
+        name    string
+        sources []int
+        opts    csvOptions
+    }{
+        {"every column", all, csvOptions{}},
+        {"trimmed with null tokens", all, csvOptions{TrimSpace: true, NullTokens: []string{"NA"}}},
+        {"decimal comma and precision", all, csvOptions{TrimSpace: true, DecimalSeparator: ',', Precision: &precision}},
+        {"reordered subset", []int{width - 1, 0, width / 2, 1, 6}, csvOptions{TrimSpace: true}},
+        {"no sources", nil, csvOptions{}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            want := serialTableStats(rows, tt.sources, tt.opts)
+            if len(tt.sources) > 0 && len(want) == 0 {
+                t.Fatal("no column came out numeric; the comparison would be vacuous")
+            }
+            for range 5 {
+                if got := pooledTableStats(rows, tt.sources, tt.opts, workers); !reflect.DeepEqual(got, want) {
+                    t.Fatalf("%d workers: %v\nserialTableStats: %v", workers, got, want)
+                }
+            }
+            if got := tableStats(rows, tt.sources, tt.opts); !reflect.DeepEqual(got, want) {
+                t.Errorf("tableStats = %v\nserialTableStats = %v", got, want)
+            }
+        })
+    }
+}
+
+func BenchmarkTableStats(b *testing.B) {
+    rows := wideRows(2000, 200)
+    sources := make([]int, 200)
+    for i := range sources {
+        sources[i] = i
+    }
+    opts := csvOptions{TrimSpace: true}
+    b.Run("parallel", func(b *testing.B) {
+        for b.Loop() {
+            tableStats(rows, sources, opts)
+        }
+    })
+    b.Run("serial", func(b *testing.B) {
+        for b.Loop() {
+            serialTableStats(rows, sources, opts)
+        }
+    })
+}
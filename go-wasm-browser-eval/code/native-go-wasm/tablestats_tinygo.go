@@ -0,0 +1,12 @@
+//go:build tinygo
+
+package main
+
+// parallelStats reports that tableStats runs serially under TinyGo.
+const parallelStats = false
+
+// tableStats is serialTableStats under TinyGo, whose goroutines share a single thread
+// and would only add scheduling overhead.
+func tableStats(rows [][]string, sources []int, opts csvOptions) map[int]Stats {
+    return serialTableStats(rows, sources, opts)
+}
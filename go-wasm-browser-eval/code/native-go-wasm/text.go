@@ -0,0 +1,74 @@
+package main
+
+import (
+    "encoding/base64"
+    "fmt"
+    "maps"
+    "mime"
+    "slices"
+    "strings"
+)
+
+// escapeMediaType percent-encodes every byte of a media type or parameter value outside a
+// conservative safe set, so characters such as ',', '#', '%', quotes and spaces cannot
+// end the header of a data: URL early or confuse a URL parser.
+func escapeMediaType(value string) string {
+    var out strings.Builder
+    for i := 0; i < len(value); i++ {
+        b := value[i]
+        if 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || strings.IndexByte("/+-._~", b) >= 0 {
+            out.WriteByte(b)
+        } else {
+            fmt.Fprintf(&out, "%%%02X", b)
+        }
+    }
+    return out.String()
+}
+
+// toDataURL returns text as a base64 data: URL of the given MIME type, for an anchor href
+// that saves results without a server. An empty mime means "text/csv"; anything that
+// does not parse as a media type is a bad_argument. Parameters are written in sorted
+// order as ;key=value with the value percent-encoded instead of quoted.
+func toDataURL(text, mediaType string) (string, error) {
+    if mediaType == "" {
+        mediaType = "text/csv"
+    }
+    kind, params, err := mime.ParseMediaType(mediaType)
+    if err != nil {
+        return "", badArgument("invalid MIME type %q: %v", mediaType, err)
+    }
+    var url strings.Builder
+    url.WriteString("data:")
+    url.WriteString(escapeMediaType(kind))
+    for _, key := range slices.Sorted(maps.Keys(params)) {
+        url.WriteString(";" + escapeMediaType(key) + "=" + escapeMediaType(params[key]))
+    }
+    url.WriteString(";base64,")
+    url.WriteString(base64.StdEncoding.EncodeToString([]byte(text)))
+    return url.String(), nil
+}
+
+// escapeField returns the RFC 4180 form of a single field: wrapped in double quotes, with
+// inner quotes doubled, when it contains a comma, quote, CR or LF, and unchanged otherwise.
+func escapeField(value string) string {
+    if !strings.ContainsAny(value, ",\"\r\n") {
+        return value
+    }
+    return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+}
+
+// unescapeField reverses escapeField. An unquoted value is returned as is; a quoted one
+// must end in a closing quote and double every inner quote.
+func unescapeField(value string) (string, error) {
+    if value == "" || value[0] != '"' {
+        return value, nil
+    }
+    if len(value) < 2 || value[len(value)-1] != '"' {
+        return "", badArgument("quoted field is missing its closing quote")
+    }
+    inner := value[1 : len(value)-1]
+    if strings.Count(inner, `"`) != 2*strings.Count(inner, `""`) {
+        return "", badArgument("quoted field contains an unescaped quote")
+    }
+    return strings.ReplaceAll(inner, `""`, `"`), nil
+}
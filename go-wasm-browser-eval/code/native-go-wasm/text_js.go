@@ -0,0 +1,123 @@
+//go:build js && wasm
+
+package main
+
+import (
+    "encoding/base64"
+    "strings"
+    "syscall/js"
+    "unicode/utf8"
+
+    "github.com/rivo/uniseg"
+    "golang.org/x/text/cases"
+    "golang.org/x/text/language"
+    "golang.org/x/text/unicode/norm"
+)
+
+// titleCaser applies Unicode title-casing without locale-specific rules, unlike the
+// deprecated strings.Title which only looks at ASCII word boundaries.
+var titleCaser = cases.Title(language.Und)
+
+// wrapLowercase mirrors wrapUppercase using strings.ToLower.
+func wrapLowercase(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return ""
+    }
+    return strings.ToLower(args[0].String())
+}
+
+// wrapTitlecase mirrors wrapUppercase using golang.org/x/text/cases.
+func wrapTitlecase(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return ""
+    }
+    return titleCaser.String(args[0].String())
+}
+
+// wrapStringInfo reports a string's length three ways for UI character limits:
+// wasmStringInfo(value) returns {bytes, runes, graphemes}. Graphemes follow Unicode
+// text segmentation, so combining marks and emoji ZWJ sequences count as one.
+func wrapStringInfo(this js.Value, args []js.Value) any {
+    value := ""
+    if len(args) > 0 {
+        value = args[0].String()
+    }
+    return map[string]any{
+        "bytes":     len(value),
+        "runes":     utf8.RuneCountInString(value),
+        "graphemes": uniseg.GraphemeClusterCount(value),
+    }
+}
+
+// wrapBase64Encode encodes a string's UTF-8 bytes with standard Base64: wasmBase64Encode(text).
+func wrapBase64Encode(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return ""
+    }
+    return base64.StdEncoding.EncodeToString([]byte(args[0].String()))
+}
+
+// wrapBase64Decode reverses wrapBase64Encode. Malformed input yields a bad_argument
+// error map rather than a partially decoded string: wasmBase64Decode(text).
+func wrapBase64Decode(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return ""
+    }
+    decoded, err := base64.StdEncoding.DecodeString(args[0].String())
+    if err != nil {
+        return errorResult(codeBadArgument, "invalid base64: "+err.Error())
+    }
+    return string(decoded)
+}
+
+// wrapToDataURL exposes toDataURL as wasmToDataURL(text, mime?).
+func wrapToDataURL(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a string")
+    }
+    mediaType := ""
+    if !isMissing(args, 1) {
+        mediaType = args[1].String()
+    }
+    url, err := toDataURL(args[0].String(), mediaType)
+    if err != nil {
+        return errorMap(err)
+    }
+    return url
+}
+
+// wrapNormalizeNFC mirrors wrapUppercase using Unicode canonical composition (NFC).
+func wrapNormalizeNFC(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return ""
+    }
+    return norm.NFC.String(args[0].String())
+}
+
+// wrapNormalizeNFD mirrors wrapUppercase using Unicode canonical decomposition (NFD).
+func wrapNormalizeNFD(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return ""
+    }
+    return norm.NFD.String(args[0].String())
+}
+
+// wrapEscapeField exposes escapeField to JavaScript as wasmEscapeField(value).
+func wrapEscapeField(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return ""
+    }
+    return escapeField(args[0].String())
+}
+
+// wrapUnescapeField exposes unescapeField to JavaScript as wasmUnescapeField(value).
+func wrapUnescapeField(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return ""
+    }
+    value, err := unescapeField(args[0].String())
+    if err != nil {
+        return errorMap(err)
+    }
+    return value
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+    "encoding/base64"
+    "strings"
+    "testing"
+)
+
+func TestEscapeFieldRoundTrip(t *testing.T) {
+    tests := []struct {
+        value, escaped string
+    }{
+        {"plain", "plain"},
+        {"", ""},
+        {"a,b", `"a,b"`},
+        {`say "hi"`, `"say ""hi"""`},
+        {"line\rbreak", "\"line\rbreak\""},
+        {"line\nbreak", "\"line\nbreak\""},
+        {"\r\n", "\"\r\n\""},
+        {`"`, `""""`},
+    }
+    for _, tt := range tests {
+        if got := escapeField(tt.value); got != tt.escaped {
+            t.Errorf("escapeField(%q) = %q; want %q", tt.value, got, tt.escaped)
+        }
+        if got, err := unescapeField(tt.escaped); err != nil || got != tt.value {
+            t.Errorf("unescapeField(%q) = %q, %v; want %q", tt.escaped, got, err, tt.value)
+        }
+    }
+}
+
+func TestUnescapeFieldRejectsMalformedQuoting(t *testing.T) {
+    tests := []struct {
+        value, message string
+    }{
+        {`"abc`, "quoted field is missing its closing quote"},
+        {`"`, "quoted field is missing its closing quote"},
+        {`"a"b"`, "quoted field contains an unescaped quote"},
+    }
+    for _, tt := range tests {
+        got, err := unescapeField(tt.value)
+        if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.message {
+            t.Errorf("unescapeField(%q) = %q, %v; want bad_argument %q", tt.value, got, err, tt.message)
+        }
+    }
+}
+
+func TestToDataURL(t *testing.T) {
+    tests := []struct {
+        name, text, mime, prefix string
+    }{
+        {"default mime", "a,b\n1,2\n", "", "data:text/csv;base64,"},
+        {"json", `{"ok":true}`, "application/json", "data:application/json;base64,"},
+        {"parameters sorted and escaped", "Zoë", `text/plain; name="a b,#%"; charset=utf-8`, "data:text/plain;charset=utf-8;name=a%20b%2C%23%25;base64,"},
+        {"binary-looking bytes", "\x00\xff\n", "text/csv", "data:text/csv;base64,"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            url, err := toDataURL(tt.text, tt.mime)
+            if err != nil {
+                t.Fatal(err)
+            }
+            payload, ok := strings.CutPrefix(url, tt.prefix)
+            if !ok {
+                t.Fatalf("toDataURL = %q; want the prefix %q", url, tt.prefix)
+            }
+            decoded, err := base64.StdEncoding.DecodeString(payload)
+            if err != nil || string(decoded) != tt.text {
+                t.Errorf("payload decodes to %q, %v; want %q", decoded, err, tt.text)
+            }
+        })
+    }
+    _, err := toDataURL("x", "not a mime")
+    if m := errorMap(err); m["code"] != codeBadArgument {
+        t.Errorf("invalid MIME type: got %v", m)
+    }
+}
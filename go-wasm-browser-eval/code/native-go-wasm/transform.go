@@ -0,0 +1,1119 @@
+package main
+
+import (
+    "cmp"
+    "encoding/csv"
+    "fmt"
+    "math"
+    "slices"
+    "strconv"
+    "strings"
+    "unicode"
+    "unicode/utf8"
+)
+
+// tableWidth returns the field count of the widest row.
+func tableWidth(rows [][]string) int {
+    width := 0
+    for _, row := range rows {
+        width = max(width, len(row))
+    }
+    return width
+}
+
+// isRectangular reports whether every record of csvText has as many fields as the first
+// one, and otherwise the 1-based line on which the first offending record starts (0 when
+// rectangular). It streams, stopping at the first mismatch.
+func isRectangular(csvText string, opts csvOptions) (bool, int, error) {
+    width, bad := -1, 0
+    err := readRecordsAt(strings.NewReader(csvText), opts, func(record []string, line int) bool {
+        if width < 0 {
+            width = len(record)
+            return true
+        }
+        if len(record) != width {
+            bad = line
+            return false
+        }
+        return true
+    })
+    if err != nil {
+        return false, 0, err
+    }
+    return bad == 0, bad, nil
+}
+
+// widthDistribution maps each field count to the number of rows with that many fields.
+// A rectangular table yields a single entry.
+func widthDistribution(rows [][]string) map[int]int {
+    widths := map[int]int{}
+    for _, row := range rows {
+        widths[len(row)]++
+    }
+    return widths
+}
+
+// rectangularize pads and trims the data rows of csvText to one width: the header's when
+// opts.HasHeader is set, the widest row's otherwise. Short rows are padded with fill.
+// Longer rows are truncated, or rejected with a bad_argument error naming the line they
+// start on when errorOnLong is set.
+func rectangularize(csvText, fill string, errorOnLong bool, opts csvOptions) (string, error) {
+    header, rows, lines, err := splitHeaderLines(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    width := len(header)
+    if header == nil {
+        width = tableWidth(rows)
+    }
+    out := make([][]string, 0, len(rows)+1)
+    if header != nil {
+        out = append(out, header)
+    }
+    for r, row := range rows {
+        if len(row) > width {
+            if errorOnLong {
+                return "", badArgument("line %d has %d fields, more than the %d of the header", lines[r], len(row), width)
+            }
+            row = row[:width]
+        }
+        for len(row) < width {
+            row = append(row, fill)
+        }
+        out = append(out, row)
+    }
+    return encodeCSV(out, false)
+}
+
+// cell returns row[i], or "" when a short row does not reach column i.
+func cell(row []string, i int) string {
+    if i < len(row) {
+        return row[i]
+    }
+    return ""
+}
+
+// checkColumn returns an error when col is not a valid index into a table of width columns.
+func checkColumn(col, width int) error {
+    if col < 0 || col >= width {
+        return badArgument("column index %d out of range (table has %d columns)", col, width)
+    }
+    return nil
+}
+
+// splitHeader parses csvText and separates the header row (when opts.HasHeader is set)
+// from the data rows.
+func splitHeader(csvText string, opts csvOptions) (header []string, rows [][]string, err error) {
+    rows, err = readAllRecords(csvText, opts)
+    if err != nil {
+        return nil, nil, err
+    }
+    if opts.HasHeader && len(rows) > 0 {
+        return rows[0], rows[1:], nil
+    }
+    return nil, rows, nil
+}
+
+// splitHeaderLines is splitHeader that also returns the 1-based physical line each data
+// row starts on, for helpers that report where rows came from.
+func splitHeaderLines(csvText string, opts csvOptions) (header []string, rows [][]string, lines []int, err error) {
+    err = readRecordsAt(strings.NewReader(csvText), opts, func(record []string, line int) bool {
+        if opts.HasHeader && header == nil {
+            header = slices.Clone(record)
+            return true
+        }
+        rows = append(rows, slices.Clone(record))
+        lines = append(lines, line)
+        return true
+    })
+    if err != nil {
+        return nil, nil, nil, err
+    }
+    return header, rows, lines, nil
+}
+
+// lineColumn is the header of the column opts.LineNumbers prepends.
+const lineColumn = "__line__"
+
+// withLine prepends line to row as its own cell.
+func withLine(row []string, line int) []string {
+    return append([]string{strconv.Itoa(line)}, row...)
+}
+
+// selectColumns projects every row of csvText onto indices, in the order given.
+// Indices may repeat; any index outside the widest row is an error.
+func selectColumns(csvText string, indices []int) ([][]string, error) {
+    rows, err := readAllRecords(csvText, csvOptions{})
+    if err != nil {
+        return nil, err
+    }
+    width := tableWidth(rows)
+    for _, idx := range indices {
+        if err := checkColumn(idx, width); err != nil {
+            return nil, err
+        }
+    }
+    out := make([][]string, len(rows))
+    for r, row := range rows {
+        projected := make([]string, len(indices))
+        for j, idx := range indices {
+            projected[j] = cell(row, idx)
+        }
+        out[r] = projected
+    }
+    return out, nil
+}
+
+// sortByColumn reorders the data rows of csvText by col and re-encodes them, keeping the
+// header row (when opts.HasHeader is set) at the top. The sort is stable. With numeric set
+// values compare as float64; cells that do not parse sort after every number in either
+// direction and compare as strings, in the requested order, among themselves.
+func sortByColumn(csvText string, col int, numeric, descending bool, opts csvOptions) (string, error) {
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return "", err
+    }
+    direction := 1
+    if descending {
+        direction = -1
+    }
+    slices.SortStableFunc(rows, func(a, b []string) int {
+        x, y := cell(a, col), cell(b, col)
+        if numeric {
+            fx, errX := strconv.ParseFloat(x, 64)
+            fy, errY := strconv.ParseFloat(y, 64)
+            switch {
+            case errX == nil && errY == nil:
+                return direction * cmp.Compare(fx, fy)
+            case errX == nil:
+                return -1
+            case errY == nil:
+                return 1
+            }
+        }
+        return direction * strings.Compare(x, y)
+    })
+    if header != nil {
+        rows = append([][]string{header}, rows...)
+    }
+    return encodeCSV(rows, false)
+}
+
+// remapColumn rewrites the cells of col found in mapping to their mapped value, leaving
+// other cells untouched, and re-encodes the table. The header row (when opts.HasHeader
+// is set) is not remapped.
+func remapColumn(csvText string, col int, mapping map[string]string, opts csvOptions) (string, error) {
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return "", err
+    }
+    for _, row := range rows {
+        if col < len(row) {
+            if replacement, ok := mapping[row[col]]; ok {
+                row[col] = replacement
+            }
+        }
+    }
+    if header != nil {
+        rows = append([][]string{header}, rows...)
+    }
+    return encodeCSV(rows, false)
+}
+
+// mapColumn replaces every cell of col with fn's result for it and re-encodes the table.
+// The header row (when opts.HasHeader is set) is not mapped, and rows too short to reach
+// col are left alone. The first error from fn stops the mapping and is returned with the
+// line of the row that caused it.
+func mapColumn(csvText string, col int, fn func(string) (string, error), opts csvOptions) (string, error) {
+    header, rows, lines, err := splitHeaderLines(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return "", err
+    }
+    for r, row := range rows {
+        if col >= len(row) {
+            continue
+        }
+        mapped, err := fn(row[col])
+        if err != nil {
+            return "", badArgument("line %d: %v", lines[r], err)
+        }
+        row[col] = mapped
+    }
+    if header != nil {
+        rows = append([][]string{header}, rows...)
+    }
+    return encodeCSV(rows, false)
+}
+
+// renameHeaders rewrites the header cells found in mapping to their new names and
+// re-encodes the table with data rows untouched; the first record is the header whatever
+// opts.HasHeader says. A rename that would leave two columns with the same name is an
+// error, though names may be swapped.
+func renameHeaders(csvText string, mapping map[string]string, opts csvOptions) (string, error) {
+    rows, err := readAllRecords(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    if len(rows) == 0 {
+        return "", badArgument("no header row to rename")
+    }
+    renamed := make([]string, len(rows[0]))
+    seen := make(map[string]int, len(renamed))
+    for i, name := range rows[0] {
+        if to, ok := mapping[name]; ok {
+            name = to
+        }
+        if j, dup := seen[name]; dup {
+            if _, ok := mapping[rows[0][i]]; ok {
+                return "", badArgument("renaming %q to %q clashes with column %d", rows[0][i], name, j)
+            }
+            if _, ok := mapping[rows[0][j]]; ok {
+                return "", badArgument("renaming %q to %q clashes with column %d", rows[0][j], name, i)
+            }
+        }
+        seen[name] = i
+        renamed[i] = name
+    }
+    rows[0] = renamed
+    return encodeCSV(rows, false)
+}
+
+// coalesceColumns appends a column joining the non-empty cells of cols with sep, so a
+// missing part leaves no stray separator ("Ada", "", "Lovelace" gives "Ada Lovelace").
+// Rows are padded to the table width first so the new column lines up. With drop the
+// source columns are removed. When opts.HasHeader is set the new column is headed
+// newHeader.
+func coalesceColumns(csvText string, cols []int, sep, newHeader string, drop bool, opts csvOptions) (string, error) {
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    width := max(len(header), tableWidth(rows))
+    for _, col := range cols {
+        if err := checkColumn(col, width); err != nil {
+            return "", err
+        }
+    }
+    // reshape pads a row to the width, optionally drops cols and appends extra.
+    reshape := func(row []string, extra string) []string {
+        out := make([]string, 0, width+1)
+        for i := range width {
+            if !drop || !slices.Contains(cols, i) {
+                out = append(out, cell(row, i))
+            }
+        }
+        return append(out, extra)
+    }
+    out := make([][]string, 0, len(rows)+1)
+    if header != nil {
+        out = append(out, reshape(header, newHeader))
+    }
+    parts := make([]string, 0, len(cols))
+    for _, row := range rows {
+        parts = parts[:0]
+        for _, col := range cols {
+            if v := cell(row, col); v != "" {
+                parts = append(parts, v)
+            }
+        }
+        out = append(out, reshape(row, strings.Join(parts, sep)))
+    }
+    return encodeCSV(out, false)
+}
+
+// splitColumn replaces col with maxParts columns holding the pieces of each cell split
+// on sep; the last piece keeps any remainder, as with strings.SplitN. Rows are padded to
+// the table width and missing pieces are empty, so the result is rectangular. When
+// opts.HasHeader is set the new columns are headed "<orig>_1", "<orig>_2" and so on.
+func splitColumn(csvText string, col int, sep string, maxParts int, opts csvOptions) (string, error) {
+    if sep == "" {
+        return "", badArgument("separator must not be empty")
+    }
+    if maxParts < 1 {
+        return "", badArgument("maxParts must be positive, got %d", maxParts)
+    }
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    width := max(len(header), tableWidth(rows))
+    if err := checkColumn(col, width); err != nil {
+        return "", err
+    }
+    // reshape pads row to the width and swaps col for parts, padded to maxParts.
+    reshape := func(row, parts []string) []string {
+        out := make([]string, 0, width-1+maxParts)
+        for i := range width {
+            if i != col {
+                out = append(out, cell(row, i))
+                continue
+            }
+            for p := range maxParts {
+                out = append(out, cell(parts, p))
+            }
+        }
+        return out
+    }
+    out := make([][]string, 0, len(rows)+1)
+    if header != nil {
+        names := make([]string, maxParts)
+        for p := range names {
+            names[p] = cell(header, col) + "_" + strconv.Itoa(p+1)
+        }
+        out = append(out, reshape(header, names))
+    }
+    for _, row := range rows {
+        var parts []string
+        if value := cell(row, col); value != "" {
+            parts = strings.SplitN(value, sep, maxParts)
+        }
+        out = append(out, reshape(row, parts))
+    }
+    return encodeCSV(out, false)
+}
+
+// redactColumns replaces every non-empty cell of cols with mask repeated once per rune
+// of the cell, so masked previews keep their shape. Empty cells and the header row (when
+// opts.HasHeader is set) are left as they are.
+func redactColumns(csvText string, cols []int, mask rune, opts csvOptions) (string, error) {
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    width := max(len(header), tableWidth(rows))
+    for _, col := range cols {
+        if err := checkColumn(col, width); err != nil {
+            return "", err
+        }
+    }
+    for _, row := range rows {
+        for _, col := range cols {
+            if col < len(row) && row[col] != "" {
+                row[col] = strings.Repeat(string(mask), utf8.RuneCountInString(row[col]))
+            }
+        }
+    }
+    if header != nil {
+        rows = append([][]string{header}, rows...)
+    }
+    return encodeCSV(rows, false)
+}
+
+// fillDown replaces the empty cells of cols with the nearest non-empty value above them
+// in the same column, as spreadsheet exports leave repeated group labels blank. Rows too
+// short to reach a column are padded to it. Empty cells before a column's first value
+// stay empty, and the header row (when opts.HasHeader is set) is neither filled nor
+// used as a value.
+func fillDown(csvText string, cols []int, opts csvOptions) (string, error) {
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    width := max(len(header), tableWidth(rows))
+    for _, col := range cols {
+        if err := checkColumn(col, width); err != nil {
+            return "", err
+        }
+    }
+    last := make([]string, len(cols))
+    for r, row := range rows {
+        for i, col := range cols {
+            switch {
+            case cell(row, col) != "":
+                last[i] = row[col]
+            case last[i] != "":
+                for len(row) <= col {
+                    row = append(row, "")
+                }
+                row[col] = last[i]
+            }
+        }
+        rows[r] = row
+    }
+    if header != nil {
+        rows = append([][]string{header}, rows...)
+    }
+    return encodeCSV(rows, false)
+}
+
+// cleanControlChars replaces the Unicode control characters (category Cc, such as NUL
+// or vertical tab) in every cell with replacement, which may be empty to strip them. CR,
+// LF and tab are kept, since the CSV writer quotes fields containing line breaks and tabs
+// are legitimate content in comma-separated files.
+func cleanControlChars(csvText, replacement string, opts csvOptions) (string, error) {
+    rows, err := readAllRecords(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    clean := func(r rune) bool {
+        return unicode.IsControl(r) && r != '\r' && r != '\n' && r != '\t'
+    }
+    for _, row := range rows {
+        for i, value := range row {
+            if strings.IndexFunc(value, clean) < 0 {
+                continue
+            }
+            var out strings.Builder
+            for _, r := range value {
+                if clean(r) {
+                    out.WriteString(replacement)
+                } else {
+                    out.WriteRune(r)
+                }
+            }
+            row[i] = out.String()
+        }
+    }
+    return encodeCSV(rows, false)
+}
+
+// applyExpr rewrites the numeric cells of col as cell <op> operand, with op one of add,
+// sub, mul or div, for quick unit conversions. Non-numeric cells are left unchanged and
+// counted under "skipped"; blanks are left alone without being counted. The header row
+// (when opts.HasHeader is set) is skipped, and the new table is returned under "csv".
+func applyExpr(csvText string, col int, op string, operand float64, opts csvOptions) (map[string]any, error) {
+    var apply func(x float64) float64
+    switch op {
+    case "add":
+        apply = func(x float64) float64 { return x + operand }
+    case "sub":
+        apply = func(x float64) float64 { return x - operand }
+    case "mul":
+        apply = func(x float64) float64 { return x * operand }
+    case "div":
+        if operand == 0 {
+            return nil, badArgument("division by zero")
+        }
+        apply = func(x float64) float64 { return x / operand }
+    default:
+        return nil, badArgument("unknown operation %q (want add, sub, mul or div)", op)
+    }
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return nil, err
+    }
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return nil, err
+    }
+    // Results keep full precision unless opts.Precision asks for fixed decimals.
+    digits := -1
+    if opts.Precision != nil {
+        digits = *opts.Precision
+    }
+    skipped := 0
+    for _, row := range rows {
+        if col >= len(row) || strings.TrimSpace(row[col]) == "" {
+            continue
+        }
+        x, err := strconv.ParseFloat(strings.TrimSpace(row[col]), 64)
+        if err != nil {
+            skipped++
+            continue
+        }
+        row[col] = strconv.FormatFloat(apply(x), 'f', digits, 64)
+    }
+    if header != nil {
+        rows = append([][]string{header}, rows...)
+    }
+    text, err := encodeCSV(rows, false)
+    if err != nil {
+        return nil, err
+    }
+    return map[string]any{"csv": text, "skipped": skipped}, nil
+}
+
+// decimalPlaces counts the digits after the decimal point of a plain number such as
+// "3.250"; exponent forms count as zero.
+func decimalPlaces(number string) int {
+    if strings.ContainsAny(number, "eE") {
+        return 0
+    }
+    if dot := strings.IndexByte(number, '.'); dot >= 0 {
+        return len(number) - dot - 1
+    }
+    return 0
+}
+
+// imputeMean fills the empty cells of col with the mean of its numeric cells, written
+// with as many decimals as the most precise of them (or opts.Precision when set). Rows
+// too short to reach col are padded to it, non-numeric cells are left as they are and
+// the header row (when opts.HasHeader is set) is skipped. The new table is returned
+// under "csv" and the number of cells written under "filled".
+func imputeMean(csvText string, col int, opts csvOptions) (map[string]any, error) {
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return nil, err
+    }
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return nil, err
+    }
+    sum, count, digits := 0.0, 0, 0
+    for _, row := range rows {
+        value := strings.TrimSpace(cell(row, col))
+        if x, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(x) && !math.IsInf(x, 0) {
+            sum += x
+            count++
+            digits = max(digits, decimalPlaces(value))
+        }
+    }
+    if count == 0 {
+        return nil, badArgument("column %d has no numeric values", col)
+    }
+    if opts.Precision != nil {
+        digits = *opts.Precision
+    }
+    mean := strconv.FormatFloat(sum/float64(count), 'f', digits, 64)
+    filled := 0
+    for r, row := range rows {
+        if strings.TrimSpace(cell(row, col)) != "" {
+            continue
+        }
+        for len(row) <= col {
+            row = append(row, "")
+        }
+        row[col] = mean
+        rows[r] = row
+        filled++
+    }
+    if header != nil {
+        rows = append([][]string{header}, rows...)
+    }
+    text, err := encodeCSV(rows, false)
+    if err != nil {
+        return nil, err
+    }
+    return map[string]any{"csv": text, "filled": filled}, nil
+}
+
+// rowDeltas appends a column holding each row's numeric col value minus the previous
+// row's, for time-series differences. The first data row, and any row where either value
+// is not a number, gets an empty delta. Deltas are written with as many decimals as the
+// more precise operand (or opts.Precision when set) so 0.3 - 0.1 reads 0.2. Rows are
+// padded to the table width so the new column lines up; when opts.HasHeader is set the
+// header row is skipped and the new column is headed "<col header>_delta".
+func rowDeltas(csvText string, col int, opts csvOptions) (string, error) {
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    width := max(len(header), tableWidth(rows))
+    if err := checkColumn(col, width); err != nil {
+        return "", err
+    }
+    pad := func(row []string, extra string) []string {
+        out := make([]string, width, width+1)
+        for i := range out {
+            out[i] = cell(row, i)
+        }
+        return append(out, extra)
+    }
+    out := make([][]string, 0, len(rows)+1)
+    if header != nil {
+        out = append(out, pad(header, cell(header, col)+"_delta"))
+    }
+    prev, prevOK := "", false
+    for _, row := range rows {
+        value := strings.TrimSpace(cell(row, col))
+        x, err := strconv.ParseFloat(value, 64)
+        ok := err == nil && !math.IsNaN(x) && !math.IsInf(x, 0)
+        delta := ""
+        if ok && prevOK {
+            p, _ := strconv.ParseFloat(prev, 64)
+            digits := max(decimalPlaces(value), decimalPlaces(prev))
+            if opts.Precision != nil {
+                digits = *opts.Precision
+            }
+            delta = strconv.FormatFloat(x-p, 'f', digits, 64)
+        }
+        out = append(out, pad(row, delta))
+        prev, prevOK = value, ok
+    }
+    return encodeCSV(out, false)
+}
+
+// columnDiff appends a column holding colA minus colB for each row where both cells are
+// numbers, and an empty cell otherwise. The difference is written with as many decimals
+// as the more precise operand (or opts.Precision when set), as in rowDeltas. Rows are
+// padded to the table width so the new column lines up; when opts.HasHeader is set the
+// header row is skipped and the new column is headed newHeader.
+func columnDiff(csvText string, colA, colB int, newHeader string, opts csvOptions) (string, error) {
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    width := max(len(header), tableWidth(rows))
+    for _, col := range []int{colA, colB} {
+        if err := checkColumn(col, width); err != nil {
+            return "", err
+        }
+    }
+    pad := func(row []string, extra string) []string {
+        out := make([]string, width, width+1)
+        for i := range out {
+            out[i] = cell(row, i)
+        }
+        return append(out, extra)
+    }
+    // number parses a trimmed cell, rejecting NaN and infinities.
+    number := func(value string) (float64, bool) {
+        x, err := strconv.ParseFloat(value, 64)
+        return x, err == nil && !math.IsNaN(x) && !math.IsInf(x, 0)
+    }
+    out := make([][]string, 0, len(rows)+1)
+    if header != nil {
+        out = append(out, pad(header, newHeader))
+    }
+    for _, row := range rows {
+        a, b := strings.TrimSpace(cell(row, colA)), strings.TrimSpace(cell(row, colB))
+        x, okA := number(a)
+        y, okB := number(b)
+        diff := ""
+        if okA && okB {
+            digits := max(decimalPlaces(a), decimalPlaces(b))
+            if opts.Precision != nil {
+                digits = *opts.Precision
+            }
+            diff = strconv.FormatFloat(x-y, 'f', digits, 64)
+        }
+        out = append(out, pad(row, diff))
+    }
+    return encodeCSV(out, false)
+}
+
+// movingAverage appends a column holding the mean of col over a trailing window of rows
+// ending at each row, for smoothing a series. Rows before the window fills, and rows
+// whose window holds a blank or non-numeric cell, get an empty value. Averages are
+// rounded to two more decimals than the most precise value in the window (or to
+// opts.Precision when set). Rows are padded to the table width so the new column lines
+// up; when opts.HasHeader is set the header row is skipped and the new column is headed
+// "<col header>_ma<window>".
+func movingAverage(csvText string, col, window int, opts csvOptions) (string, error) {
+    if window < 1 {
+        return "", badArgument("window must be positive, got %d", window)
+    }
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    width := max(len(header), tableWidth(rows))
+    if err := checkColumn(col, width); err != nil {
+        return "", err
+    }
+    pad := func(row []string, extra string) []string {
+        out := make([]string, width, width+1)
+        for i := range out {
+            out[i] = cell(row, i)
+        }
+        return append(out, extra)
+    }
+    out := make([][]string, 0, len(rows)+1)
+    if header != nil {
+        out = append(out, pad(header, cell(header, col)+"_ma"+strconv.Itoa(window)))
+    }
+    values := make([]string, 0, len(rows))
+    for r, row := range rows {
+        values = append(values, strings.TrimSpace(cell(row, col)))
+        avg := ""
+        if r+1 >= window {
+            sum, digits, ok := 0.0, 0, true
+            for _, value := range values[r+1-window:] {
+                x, err := strconv.ParseFloat(value, 64)
+                if err != nil || math.IsNaN(x) || math.IsInf(x, 0) {
+                    ok = false
+                    break
+                }
+                sum += x
+                digits = max(digits, decimalPlaces(value))
+            }
+            if ok {
+                mean := sum / float64(window)
+                if opts.Precision != nil {
+                    avg = strconv.FormatFloat(mean, 'f', *opts.Precision, 64)
+                } else {
+                    scale := math.Pow10(digits + 2)
+                    avg = strconv.FormatFloat(math.Round(mean*scale)/scale, 'f', -1, 64)
+                }
+            }
+        }
+        out = append(out, pad(row, avg))
+    }
+    return encodeCSV(out, false)
+}
+
+// sliceCSV returns the records [startRow, endRow) and fields [startCol, endCol) of
+// csvText as CSV, like a spreadsheet selection. Rows count from the first record, header
+// included. Bounds outside the table are clamped to it and short rows are padded with
+// empty fields; a start past its end is an error.
+func sliceCSV(csvText string, startRow, endRow, startCol, endCol int, opts csvOptions) (string, error) {
+    if startRow > endRow || startCol > endCol {
+        return "", badArgument("slice start must not exceed end (rows %d:%d, columns %d:%d)", startRow, endRow, startCol, endCol)
+    }
+    rows, err := readAllRecords(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    clamp := func(v, limit int) int { return min(max(v, 0), limit) }
+    startRow, endRow = clamp(startRow, len(rows)), clamp(endRow, len(rows))
+    width := tableWidth(rows)
+    startCol, endCol = clamp(startCol, width), clamp(endCol, width)
+    out := make([][]string, 0, endRow-startRow)
+    for _, row := range rows[startRow:endRow] {
+        fields := make([]string, endCol-startCol)
+        for j := range fields {
+            fields[j] = cell(row, startCol+j)
+        }
+        out = append(out, fields)
+    }
+    return encodeCSV(out, false)
+}
+
+// reverseRows returns csvText with its data rows in reverse order, as for reading a log
+// newest-first. The header row (when opts.HasHeader is set) stays on top, so a
+// header-only file comes back unchanged and an empty one as "".
+func reverseRows(csvText string, opts csvOptions) (string, error) {
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return "", err
+    }
+    slices.Reverse(rows)
+    if header != nil {
+        rows = append([][]string{header}, rows...)
+    }
+    return encodeCSV(rows, false)
+}
+
+// canonicalizeCSV re-emits csvText in one canonical form so files that parse to the same
+// records compare byte-for-byte: comma-delimited, fields quoted only when they need it,
+// LF line endings, no byte-order mark and a single newline after the last record.
+// opts.Delimiter and opts.Comment describe the input only. With opts.Chunk set the
+// output goes to the callback and "" is returned; chunks already sent are not taken
+// back if a later record fails to parse.
+func canonicalizeCSV(csvText string, opts csvOptions) (string, error) {
+    out := newChunkedOutput(opts)
+    writer := csv.NewWriter(&out.buf)
+    var writeErr error
+    err := readRecordsAt(strings.NewReader(csvText), opts, func(record []string, line int) bool {
+        if writeErr = writer.Write(record); writeErr != nil {
+            return false
+        }
+        writer.Flush()
+        out.endRecord()
+        return true
+    })
+    if err == nil {
+        err = writeErr
+    }
+    if err != nil {
+        return "", err
+    }
+    return out.finish(), nil
+}
+
+// requote re-emits csvText under a quoting policy: "minimal" quotes only the fields that
+// need it (encoding/csv's behaviour), "all" quotes every field and "nonnumeric" quotes
+// every field that is not an integer or float under classifyValue, empty fields
+// included. csv.Writer only does minimal quoting, so the other policies are written
+// here; numeric fields that contain the delimiter are still quoted. opts.Delimiter is
+// used for both input and output, and records end in LF. opts.Chunk streams the output
+// as in canonicalizeCSV.
+func requote(csvText, policy string, opts csvOptions) (string, error) {
+    var quote func(field string) bool
+    switch policy {
+    case "minimal":
+    case "all":
+        quote = func(string) bool { return true }
+    case "nonnumeric":
+        quote = func(field string) bool {
+            kind := classifyValue(field)
+            return field == "" || kind != typeInteger && kind != typeFloat
+        }
+    default:
+        return "", badArgument("unknown quoting policy %q (want minimal, all or nonnumeric)", policy)
+    }
+    delimiter := opts.Delimiter
+    if delimiter == 0 {
+        delimiter = ','
+    }
+    out := newChunkedOutput(opts)
+    var writeErr error
+    err := readRecordsAt(strings.NewReader(csvText), opts, func(row []string, line int) bool {
+        if quote == nil {
+            var record string
+            if record, writeErr = encodeRecord(row, opts); writeErr != nil {
+                return false
+            }
+            out.buf.WriteString(record)
+            out.endRecord()
+            return true
+        }
+        for i, field := range row {
+            if i > 0 {
+                out.buf.WriteRune(delimiter)
+            }
+            if quote(field) || strings.ContainsRune(field, delimiter) {
+                out.buf.WriteString(`"` + strings.ReplaceAll(field, `"`, `""`) + `"`)
+            } else {
+                out.buf.WriteString(field)
+            }
+        }
+        out.buf.WriteByte('\n')
+        out.endRecord()
+        return true
+    })
+    if err == nil {
+        err = writeErr
+    }
+    if err != nil {
+        return "", err
+    }
+    return out.finish(), nil
+}
+
+// transposeCSV swaps the rows and columns of csvText and re-encodes the result as CSV.
+// Every row must have the same number of fields, since transpose is undefined otherwise.
+// With crlf set, records end in \r\n instead of \n.
+func transposeCSV(csvText string, crlf bool) (string, error) {
+    rows, err := readAllRecords(csvText, csvOptions{})
+    if err != nil {
+        return "", err
+    }
+    if len(rows) == 0 {
+        return "", nil
+    }
+    width := len(rows[0])
+    for i, row := range rows {
+        if len(row) != width {
+            return "", badArgument("cannot transpose ragged csv: row %d has %d fields, expected %d", i+1, len(row), width)
+        }
+    }
+    out := make([][]string, width)
+    for c := range out {
+        out[c] = make([]string, len(rows))
+        for r, row := range rows {
+            out[c][r] = row[c]
+        }
+    }
+    return encodeCSV(out, crlf)
+}
+
+// encodeCSV writes rows as RFC 4180 CSV, ending records in \r\n when crlf is set.
+func encodeCSV(rows [][]string, crlf bool) (string, error) {
+    var out strings.Builder
+    writer := csv.NewWriter(&out)
+    writer.UseCRLF = crlf
+    if err := writer.WriteAll(rows); err != nil {
+        return "", err
+    }
+    return out.String(), nil
+}
+
+// chunkCSV splits csvText on record boundaries into CSV strings of at most maxBytes each,
+// repeating the first record as a header at the top of every chunk. Records are
+// re-encoded, so a quoted field is never split; a record too large to fit alongside the
+// header gets a chunk of its own that exceeds maxBytes.
+func chunkCSV(csvText string, maxBytes int, opts csvOptions) ([]string, error) {
+    if maxBytes <= 0 {
+        return nil, badArgument("maxBytes must be positive, got %d", maxBytes)
+    }
+    rows, err := readAllRecords(csvText, opts)
+    if err != nil {
+        return nil, err
+    }
+    if len(rows) == 0 {
+        return []string{}, nil
+    }
+    header, err := encodeRecord(rows[0], opts)
+    if err != nil {
+        return nil, err
+    }
+    var chunks []string
+    var current strings.Builder
+    for _, row := range rows[1:] {
+        line, err := encodeRecord(row, opts)
+        if err != nil {
+            return nil, err
+        }
+        if current.Len() > 0 && current.Len()+len(line) > maxBytes {
+            chunks = append(chunks, current.String())
+            current.Reset()
+        }
+        if current.Len() == 0 {
+            current.WriteString(header)
+        }
+        current.WriteString(line)
+    }
+    if current.Len() == 0 {
+        current.WriteString(header)
+    }
+    return append(chunks, current.String()), nil
+}
+
+// defaultMaxPartitions caps partitionByColumn when the caller gives no limit.
+const defaultMaxPartitions = 1000
+
+// partitionByColumn splits the data rows of csvText by their value in col into one CSV
+// string per distinct value, in original row order, each starting with the header row
+// when opts.HasHeader is set. Rows with an empty cell go under the "" key. At most
+// maxParts partitions are made (defaultMaxPartitions when maxParts is not positive);
+// rows for values beyond that are dropped, counted under "droppedRows" and flagged
+// with "truncated".
+func partitionByColumn(csvText string, col, maxParts int, opts csvOptions) (map[string]any, error) {
+    if maxParts <= 0 {
+        maxParts = defaultMaxPartitions
+    }
+    header, rows, err := splitHeader(csvText, opts)
+    if err != nil {
+        return nil, err
+    }
+    if err := checkColumn(col, max(len(header), tableWidth(rows))); err != nil {
+        return nil, err
+    }
+    headerLine := ""
+    if header != nil {
+        if headerLine, err = encodeRecord(header, opts); err != nil {
+            return nil, err
+        }
+    }
+    parts := map[string]*strings.Builder{}
+    dropped := 0
+    for _, row := range rows {
+        key := cell(row, col)
+        part, ok := parts[key]
+        if !ok {
+            if len(parts) >= maxParts {
+                dropped++
+                continue
+            }
+            part = &strings.Builder{}
+            part.WriteString(headerLine)
+            parts[key] = part
+        }
+        line, err := encodeRecord(row, opts)
+        if err != nil {
+            return nil, err
+        }
+        part.WriteString(line)
+    }
+    out := make(map[string]any, len(parts))
+    for key, part := range parts {
+        out[key] = part.String()
+    }
+    return map[string]any{"partitions": out, "truncated": dropped > 0, "droppedRows": dropped}, nil
+}
+
+// mergeCSV reassembles chunks produced by chunkCSV: the header of the first non-empty
+// chunk is kept and every later chunk must start with exactly the same header row,
+// which is dropped. A chunk with a different header is a bad_argument error.
+func mergeCSV(chunks []string, opts csvOptions) (string, error) {
+    var header []string
+    var out strings.Builder
+    for i, chunk := range chunks {
+        rows, err := readAllRecords(chunk, opts)
+        if err != nil {
+            return "", fmt.Errorf("chunk %d: %w", i, err)
+        }
+        if len(rows) == 0 {
+            continue
+        }
+        if header == nil {
+            header = rows[0]
+        } else if !slices.Equal(rows[0], header) {
+            return "", badArgument("chunk %d header %q does not match the first chunk's %q", i, rows[0], header)
+        } else {
+            rows = rows[1:]
+        }
+        for _, row := range rows {
+            line, err := encodeRecord(row, opts)
+            if err != nil {
+                return "", err
+            }
+            out.WriteString(line)
+        }
+    }
+    return out.String(), nil
+}
+
+// concatAligned stacks CSV texts whose headers overlap but need not match, unlike
+// mergeCSV. The output header is the union of the input headers in first-seen order,
+// each input's rows are reordered to it and columns an input lacks are left empty.
+// Every text must start with a header row; cells past the end of their header are
+// dropped, and a name repeated within one header is a bad_argument error.
+func concatAligned(texts []string, opts csvOptions) (string, error) {
+    var header []string
+    position := make(map[string]int)
+    type input struct {
+        rows  [][]string
+        index []int
+    }
+    inputs := make([]input, 0, len(texts))
+    for i, text := range texts {
+        rows, err := readAllRecords(text, opts)
+        if err != nil {
+            return "", fmt.Errorf("input %d: %w", i, err)
+        }
+        if len(rows) == 0 {
+            continue
+        }
+        index := make([]int, len(rows[0]))
+        seen := make(map[string]bool, len(rows[0]))
+        for c, name := range rows[0] {
+            if seen[name] {
+                return "", badArgument("input %d repeats column %q", i, name)
+            }
+            seen[name] = true
+            p, ok := position[name]
+            if !ok {
+                p = len(header)
+                position[name] = p
+                header = append(header, name)
+            }
+            index[c] = p
+        }
+        inputs = append(inputs, input{rows: rows[1:], index: index})
+    }
+    if header == nil {
+        return "", nil
+    }
+    var out strings.Builder
+    line, err := encodeRecord(header, opts)
+    if err != nil {
+        return "", err
+    }
+    out.WriteString(line)
+    for _, in := range inputs {
+        for _, row := range in.rows {
+            aligned := make([]string, len(header))
+            for c, p := range in.index {
+                aligned[p] = cell(row, c)
+            }
+            line, err := encodeRecord(aligned, opts)
+            if err != nil {
+                return "", err
+            }
+            out.WriteString(line)
+        }
+    }
+    return out.String(), nil
+}
+
+// encodeRecord writes a single record as CSV using the delimiter from opts.
+func encodeRecord(record []string, opts csvOptions) (string, error) {
+    var out strings.Builder
+    writer := csv.NewWriter(&out)
+    if opts.Delimiter != 0 {
+        writer.Comma = opts.Delimiter
+    }
+    if err := writer.WriteAll([][]string{record}); err != nil {
+        return "", err
+    }
+    return out.String(), nil
+}
@@ -0,0 +1,908 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestSelectColumns(t *testing.T) {
+    text := "a,b,c\n1,2,3\n4,5\n"
+    tests := []struct {
+        name    string
+        indices []int
+        want    [][]string
+    }{
+        {"reorder", []int{2, 0}, [][]string{{"c", "a"}, {"3", "1"}, {"", "4"}}},
+        {"repeat", []int{1, 1}, [][]string{{"b", "b"}, {"2", "2"}, {"5", "5"}}},
+        {"none", nil, [][]string{{}, {}, {}}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := selectColumns(text, tt.indices)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("selectColumns(%v) = %q; want %q", tt.indices, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSelectColumnsOutOfRange(t *testing.T) {
+    for _, idx := range []int{3, -1} {
+        _, err := selectColumns("a,b,c\n1,2,3\n", []int{0, idx})
+        if err == nil {
+            t.Fatalf("index %d accepted", idx)
+        }
+        m := errorMap(err)
+        if want := fmt.Sprintf("column index %d out of range (table has 3 columns)", idx); m["code"] != codeBadArgument || m["message"] != want {
+            t.Errorf("index %d: got %v; want bad_argument %q", idx, m, want)
+        }
+    }
+}
+
+func TestTransposeCSV(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        want string
+    }{
+        {"square", "a,b,c\n1,2,3\n4,5,6\n", "a,1,4\nb,2,5\nc,3,6\n"},
+        {"wide to tall", "k,v\n", "k\nv\n"},
+        {"quoting survives", "name,note\nann,\"x, y\"\n", "name,ann\nnote,\"x, y\"\n"},
+        {"empty", "", ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := transposeCSV(tt.text, false)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("transposeCSV = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestTransposeCSVRagged(t *testing.T) {
+    _, err := transposeCSV("a,b\n1\n", false)
+    m := errorMap(err)
+    if m["code"] != codeBadArgument || m["message"] != "cannot transpose ragged csv: row 2 has 1 fields, expected 2" {
+        t.Errorf("got %v; want a bad_argument error naming row 2", m)
+    }
+}
+
+func TestSortByColumn(t *testing.T) {
+    const text = "id,v\na,2\nb,\nc,1\nd,2\ne,x\nf,10\n"
+    tests := []struct {
+        name                string
+        numeric, descending bool
+        want                string
+    }{
+        {"numeric ascending", true, false, "id,v\nc,1\na,2\nd,2\nf,10\nb,\ne,x\n"},
+        {"numeric descending keeps ties stable", true, true, "id,v\nf,10\na,2\nd,2\nc,1\ne,x\nb,\n"},
+        {"string ascending", false, false, "id,v\nb,\nc,1\nf,10\na,2\nd,2\ne,x\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := sortByColumn(text, 1, tt.numeric, tt.descending, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("sortByColumn = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSortByColumnOutOfRange(t *testing.T) {
+    if _, err := sortByColumn("a,b\n1,2\n", 2, false, false, csvOptions{}); errorMap(err)["code"] != codeBadArgument {
+        t.Errorf("got %v; want a bad_argument error", err)
+    }
+}
+
+func TestChunkCSV(t *testing.T) {
+    text := "id,note\n1,short\n2,\"has, comma\"\n3,\"two\nlines\"\n4,x\n5,y\n"
+    chunks, err := chunkCSV(text, 30, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(chunks) < 2 {
+        t.Fatalf("got %d chunks; want the input split", len(chunks))
+    }
+    var data [][]string
+    for i, chunk := range chunks {
+        if len(chunk) > 30 {
+            t.Errorf("chunk %d is %d bytes; want at most 30", i, len(chunk))
+        }
+        rows, err := readAllRecords(chunk, csvOptions{})
+        if err != nil {
+            t.Fatalf("chunk %d does not parse: %v", i, err)
+        }
+        if !reflect.DeepEqual(rows[0], []string{"id", "note"}) {
+            t.Errorf("chunk %d starts with %q; want the header", i, rows[0])
+        }
+        data = append(data, rows[1:]...)
+    }
+    all, _ := readAllRecords(text, csvOptions{})
+    if !reflect.DeepEqual(data, all[1:]) {
+        t.Errorf("chunks hold %q; want the original rows %q", data, all[1:])
+    }
+}
+
+func TestChunkCSVOversizedRow(t *testing.T) {
+    chunks, err := chunkCSV("h\n"+"aaaaaaaaaaaaaaaaaaaa\nb\n", 8, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []string{"h\naaaaaaaaaaaaaaaaaaaa\n", "h\nb\n"}
+    if !reflect.DeepEqual(chunks, want) {
+        t.Errorf("chunkCSV = %q; want %q", chunks, want)
+    }
+}
+
+func TestChunkCSVEdgeCases(t *testing.T) {
+    if chunks, err := chunkCSV("", 10, csvOptions{}); err != nil || len(chunks) != 0 {
+        t.Errorf("empty input: %q, %v; want no chunks", chunks, err)
+    }
+    if chunks, err := chunkCSV("h\n", 10, csvOptions{}); err != nil || !reflect.DeepEqual(chunks, []string{"h\n"}) {
+        t.Errorf("header only: %q, %v; want the header alone", chunks, err)
+    }
+    if _, err := chunkCSV("h\n", 0, csvOptions{}); errorMap(err)["code"] != codeBadArgument {
+        t.Errorf("maxBytes 0: %v; want a bad_argument error", err)
+    }
+}
+
+func TestMergeCSVRoundTrip(t *testing.T) {
+    text := "id,note\n1,short\n2,\"has, comma\"\n3,\"two\nlines\"\n4,x\n5,y\n"
+    chunks, err := chunkCSV(text, 30, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    merged, err := mergeCSV(chunks, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if merged != text {
+        t.Errorf("mergeCSV(chunkCSV(text)) = %q; want %q", merged, text)
+    }
+}
+
+func TestMergeCSV(t *testing.T) {
+    got, err := mergeCSV([]string{"", "a,b\n1,2\n", "a,b\n", "a,b\n3,4\n"}, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "a,b\n1,2\n3,4\n"; got != want {
+        t.Errorf("mergeCSV = %q; want %q", got, want)
+    }
+    _, err = mergeCSV([]string{"a,b\n1,2\n", "a,c\n3,4\n"}, csvOptions{})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != `chunk 1 header ["a" "c"] does not match the first chunk's ["a" "b"]` {
+        t.Errorf("mismatched header: got %v", m)
+    }
+    if _, err := mergeCSV([]string{"a\n", "a\n\"oops\n"}, csvOptions{}); errorMap(err)["code"] != codeParseError {
+        t.Errorf("unparseable chunk: %v; want parse_error", err)
+    }
+}
+
+func TestRemapColumn(t *testing.T) {
+    mapping := map[string]string{"Y": "Yes", "N": "No"}
+    got, err := remapColumn("id,ok\n1,Y\n2,N\n3,maybe\n4\n", 1, mapping, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "id,ok\n1,Yes\n2,No\n3,maybe\n4\n"; got != want {
+        t.Errorf("remapColumn = %q; want %q", got, want)
+    }
+    got, err = remapColumn("Y\nY\n", 0, mapping, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "Y\nYes\n"; got != want {
+        t.Errorf("header remapped: got %q; want %q", got, want)
+    }
+    _, err = remapColumn("a,b\n1,2\n", 2, mapping, csvOptions{HasHeader: true})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != "column index 2 out of range (table has 2 columns)" {
+        t.Errorf("out-of-range column: got %v", m)
+    }
+}
+
+func TestSliceCSV(t *testing.T) {
+    const text = "a,b,c\n1,2,3\n4,5,6\n7,8\n"
+    tests := []struct {
+        name             string
+        startRow, endRow int
+        startCol, endCol int
+        want             string
+    }{
+        {"interior", 1, 3, 1, 3, "2,3\n5,6\n"},
+        {"full range", 0, 4, 0, 3, text[:len(text)-1] + ",\n"},
+        {"clamped", -2, 99, 1, 99, "b,c\n2,3\n5,6\n8,\n"},
+        {"empty", 2, 2, 0, 3, ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := sliceCSV(text, tt.startRow, tt.endRow, tt.startCol, tt.endCol, csvOptions{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("sliceCSV = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSliceCSVStartAfterEnd(t *testing.T) {
+    _, err := sliceCSV("a,b\n", 2, 1, 0, 1, csvOptions{})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != "slice start must not exceed end (rows 2:1, columns 0:1)" {
+        t.Errorf("got %v", m)
+    }
+}
+
+func TestWidthDistribution(t *testing.T) {
+    ragged := [][]string{{"a", "b", "c"}, {"1", "2", "3"}, {"4", "5", "6", "7", "8"}}
+    if got, want := widthDistribution(ragged), map[int]int{3: 2, 5: 1}; !reflect.DeepEqual(got, want) {
+        t.Errorf("widthDistribution(ragged) = %v; want %v", got, want)
+    }
+    rect := [][]string{{"a", "b"}, {"1", "2"}}
+    if got, want := widthDistribution(rect), map[int]int{2: 2}; !reflect.DeepEqual(got, want) {
+        t.Errorf("widthDistribution(rectangular) = %v; want %v", got, want)
+    }
+}
+
+func TestIsRectangular(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        ok   bool
+        line int
+    }{
+        {"rectangular", "a,b\n1,2\n3,4\n", true, 0},
+        {"empty", "", true, 0},
+        {"short row", "a,b\n1,2\n3\n4,5\n", false, 3},
+        {"long row", "a,b\n1,2,3\n", false, 2},
+        // The offending record is on line 4, after a field spanning lines 2 and 3.
+        {"after multiline field", "a,b\n\"x\ny\",2\n3\n", false, 4},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            ok, line, err := isRectangular(tt.text, csvOptions{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if ok != tt.ok || line != tt.line {
+                t.Errorf("isRectangular = %v, %d; want %v, %d", ok, line, tt.ok, tt.line)
+            }
+        })
+    }
+}
+
+func TestRedactColumns(t *testing.T) {
+    const text = "name,email,city\nZoë,zoë@例え.jp,東京\nbob,,Oslo\ncy\n"
+    got, err := redactColumns(text, []int{1, 2}, '*', csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    // zoë@例え.jp is 9 runes but 14 bytes; 東京 is 2 runes.
+    if want := "name,email,city\nZoë,*********,**\nbob,,****\ncy\n"; got != want {
+        t.Errorf("redactColumns = %q; want %q", got, want)
+    }
+    got, err = redactColumns("secret\nx\n", []int{0}, '#', csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "######\n#\n"; got != want {
+        t.Errorf("without a header: got %q; want %q", got, want)
+    }
+    _, err = redactColumns(text, []int{3}, '*', csvOptions{HasHeader: true})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != "column index 3 out of range (table has 3 columns)" {
+        t.Errorf("out-of-range column: got %v", m)
+    }
+}
+
+func TestFillDown(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        cols []int
+        want string
+    }{
+        {
+            "grouped column",
+            "region,city,sales\nnorth,oslo,1\n,bergen,2\n,,3\nsouth,rome,4\n,milan,5\n",
+            []int{0},
+            "region,city,sales\nnorth,oslo,1\nnorth,bergen,2\nnorth,,3\nsouth,rome,4\nsouth,milan,5\n",
+        },
+        {
+            "two columns",
+            "region,city,sales\nnorth,oslo,1\n,,2\n",
+            []int{0, 1},
+            "region,city,sales\nnorth,oslo,1\nnorth,oslo,2\n",
+        },
+        {
+            // The header is not a value to fill from, so the first rows stay blank.
+            "leading empties",
+            "group,v\n,1\n,2\na,3\n,4\n",
+            []int{0},
+            "group,v\n,1\n,2\na,3\na,4\n",
+        },
+        {"short row padded", "a,b\nx,y\n1\n", []int{1}, "a,b\nx,y\n1,y\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := fillDown(tt.text, tt.cols, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("fillDown = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCleanControlChars(t *testing.T) {
+    const text = "a,b\nx\x00y,\"keep\ttab\nand newline\"\nbell\a\v,del\x7f\u0085\n"
+    tests := []struct {
+        replacement string
+        want        string
+    }{
+        {"", "a,b\nxy,\"keep\ttab\nand newline\"\nbell,del\n"},
+        {"?", "a,b\nx?y,\"keep\ttab\nand newline\"\nbell??,del??\n"},
+    }
+    for _, tt := range tests {
+        got, err := cleanControlChars(text, tt.replacement, csvOptions{})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got != tt.want {
+            t.Errorf("cleanControlChars(%q) = %q; want %q", tt.replacement, got, tt.want)
+        }
+    }
+}
+
+func TestRenameHeaders(t *testing.T) {
+    tests := []struct {
+        name    string
+        text    string
+        mapping map[string]string
+        want    string
+    }{
+        // Data cells that match a key are not headers and stay as they are.
+        {"rename", "id,nm,city\n1,nm,oslo\n", map[string]string{"nm": "name", "zip": "postcode"}, "id,name,city\n1,nm,oslo\n"},
+        {"swap", "a,b\n1,2\n", map[string]string{"a": "b", "b": "a"}, "b,a\n1,2\n"},
+        {"no match", "a,b\n", map[string]string{"c": "d"}, "a,b\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := renameHeaders(tt.text, tt.mapping, csvOptions{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("renameHeaders = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestRenameHeadersConflicts(t *testing.T) {
+    tests := []struct {
+        name    string
+        text    string
+        mapping map[string]string
+        msg     string
+    }{
+        {"onto a later column", "a,b\n", map[string]string{"a": "b"}, `renaming "a" to "b" clashes with column 1`},
+        {"onto an earlier column", "a,b\n", map[string]string{"b": "a"}, `renaming "b" to "a" clashes with column 0`},
+        {"two onto one", "a,b,c\n", map[string]string{"a": "x", "c": "x"}, `renaming "c" to "x" clashes with column 0`},
+        {"empty input", "", map[string]string{"a": "b"}, "no header row to rename"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := renameHeaders(tt.text, tt.mapping, csvOptions{})
+            if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.msg {
+                t.Errorf("got %v; want bad_argument %q", m, tt.msg)
+            }
+        })
+    }
+}
+
+func TestCoalesceColumns(t *testing.T) {
+    const text = "first,middle,last,age\nAda,,Lovelace,36\n,,Turing,41\nGrace,B.,Hopper\n,,,\n"
+    tests := []struct {
+        name string
+        drop bool
+        want string
+    }{
+        {
+            "keep sources",
+            false,
+            "first,middle,last,age,full_name\nAda,,Lovelace,36,Ada Lovelace\n,,Turing,41,Turing\nGrace,B.,Hopper,,Grace B. Hopper\n,,,,\n",
+        },
+        {
+            "drop sources",
+            true,
+            "age,full_name\n36,Ada Lovelace\n41,Turing\n,Grace B. Hopper\n,\n",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := coalesceColumns(text, []int{0, 1, 2}, " ", "full_name", tt.drop, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("coalesceColumns = %q; want %q", got, tt.want)
+            }
+        })
+    }
+    got, err := coalesceColumns("a,b\nx,y\n", []int{1, 0}, "-", "", false, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    // Without a header every record is data, and cols set the join order.
+    if want := "a,b,b-a\nx,y,y-x\n"; got != want {
+        t.Errorf("without a header: got %q; want %q", got, want)
+    }
+    if _, err := coalesceColumns(text, []int{4}, " ", "x", false, csvOptions{HasHeader: true}); err == nil {
+        t.Error("out-of-range column accepted")
+    }
+}
+
+func TestApplyExpr(t *testing.T) {
+    const text = "temp_c,station\n10,a\n-40,b\nn/a,c\n,d\n 100 ,e\n3,f\n"
+    got, err := applyExpr(text, 0, "mul", 1.8, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    // n/a is kept and counted; the blank is kept without being counted.
+    want := map[string]any{"csv": "temp_c,station\n18,a\n-72,b\nn/a,c\n,d\n180,e\n5.4,f\n", "skipped": 1}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("applyExpr mul = %v; want %v", got, want)
+    }
+    precision := 1
+    tests := []struct {
+        op      string
+        operand float64
+        want    string
+    }{
+        {"add", 32, "v\n42\n33.25\n"},
+        {"sub", 0.25, "v\n9.75\n1\n"},
+        {"div", 4, "v\n2.5\n0.3125\n"},
+    }
+    for _, tt := range tests {
+        got, err := applyExpr("v\n10\n1.25\n", 0, tt.op, tt.operand, csvOptions{HasHeader: true})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got["csv"] != tt.want {
+            t.Errorf("applyExpr %s %v = %q; want %q", tt.op, tt.operand, got["csv"], tt.want)
+        }
+    }
+    got, err = applyExpr("v\n10\n1.25\n", 0, "div", 3, csvOptions{HasHeader: true, Precision: &precision})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got["csv"] != "v\n3.3\n0.4\n" {
+        t.Errorf("with precision 1: got %q", got["csv"])
+    }
+}
+
+func TestApplyExprErrors(t *testing.T) {
+    tests := []struct {
+        op      string
+        operand float64
+        col     int
+        msg     string
+    }{
+        {"div", 0, 0, "division by zero"},
+        {"pow", 2, 0, `unknown operation "pow" (want add, sub, mul or div)`},
+        {"add", 1, 1, "column index 1 out of range (table has 1 columns)"},
+    }
+    for _, tt := range tests {
+        _, err := applyExpr("v\n1\n", tt.col, tt.op, tt.operand, csvOptions{HasHeader: true})
+        if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.msg {
+            t.Errorf("%s %v on column %d: got %v; want %q", tt.op, tt.operand, tt.col, m, tt.msg)
+        }
+    }
+}
+
+func TestSplitColumn(t *testing.T) {
+    tests := []struct {
+        name     string
+        text     string
+        maxParts int
+        want     string
+    }{
+        {
+            "even split",
+            "id,time,zone\n1,10:30:05,utc\n2,23:59:59,cet\n",
+            3,
+            "id,time_1,time_2,time_3,zone\n1,10,30,05,utc\n2,23,59,59,cet\n",
+        },
+        {
+            // Short cells, blanks and short rows are padded so every row has five fields.
+            "fewer parts",
+            "id,time,zone\n1,10:30,utc\n2,,cet\n3\n",
+            3,
+            "id,time_1,time_2,time_3,zone\n1,10,30,,utc\n2,,,,cet\n3,,,,\n",
+        },
+        {
+            "remainder kept in last part",
+            "id,time\n1,10:30:05\n",
+            2,
+            "id,time_1,time_2\n1,10,30:05\n",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := splitColumn(tt.text, 1, ":", tt.maxParts, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("splitColumn = %q; want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSplitColumnErrors(t *testing.T) {
+    tests := []struct {
+        col, maxParts int
+        sep, msg      string
+    }{
+        {0, 2, "", "separator must not be empty"},
+        {0, 0, ":", "maxParts must be positive, got 0"},
+        {2, 2, ":", "column index 2 out of range (table has 2 columns)"},
+    }
+    for _, tt := range tests {
+        _, err := splitColumn("a,b\n1:2,3\n", tt.col, tt.sep, tt.maxParts, csvOptions{})
+        if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != tt.msg {
+            t.Errorf("splitColumn(col %d, %q, %d): got %v; want %q", tt.col, tt.sep, tt.maxParts, m, tt.msg)
+        }
+    }
+}
+
+func TestImputeMean(t *testing.T) {
+    // The mean of 1.5 and 2 is 1.75, written to one decimal like 1.5; the short row is
+    // padded and filled too.
+    got, err := imputeMean("id,x\n1,1.5\n2,\n3,2\n4,n/a\n5\n", 1, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := map[string]any{"csv": "id,x\n1,1.5\n2,1.8\n3,2\n4,n/a\n5,1.8\n", "filled": 2}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("imputeMean = %v; want %v", got, want)
+    }
+    // Without the header flag "x" is just a non-numeric cell, left as it is.
+    two := 2
+    got, err = imputeMean("x,y\n1,a\n,b\n4,c\n", 0, csvOptions{Precision: &two})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got["csv"] != "x,y\n1,a\n2.50,b\n4,c\n" || got["filled"] != 1 {
+        t.Errorf("imputeMean with precision 2 = %v", got)
+    }
+    _, err = imputeMean("x\n\na\n", 0, csvOptions{HasHeader: true})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != "column 0 has no numeric values" {
+        t.Errorf("no numeric values: got %v", m)
+    }
+}
+
+func TestReverseRows(t *testing.T) {
+    tests := []struct {
+        name, text, want string
+    }{
+        {"data rows reversed", "time,msg\n1,a\n2,b\n3,c\n", "time,msg\n3,c\n2,b\n1,a\n"},
+        {"header only", "time,msg\n", "time,msg\n"},
+        {"empty", "", ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := reverseRows(tt.text, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("reverseRows = %q; want %q", got, tt.want)
+            }
+        })
+    }
+    got, err := reverseRows("a\nb\n", csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got != "b\na\n" {
+        t.Errorf("reverseRows without a header = %q; want %q", got, "b\na\n")
+    }
+}
+
+func TestConcatAligned(t *testing.T) {
+    tests := []struct {
+        name  string
+        texts []string
+        want  string
+    }{
+        {"overlapping columns", []string{"id,name\n1,ann\n", "name,id,age\nbob,2,30\n"}, "id,name,age\n1,ann,\n2,bob,30\n"},
+        {"disjoint columns", []string{"a\n1\n", "b\n2\n"}, "a,b\n1,\n,2\n"},
+        {"empty input skipped", []string{"", "x,y\n1,2\n", ""}, "x,y\n1,2\n"},
+        {"cells past the header dropped", []string{"a,b\n1,2,3\n"}, "a,b\n1,2\n"},
+        {"nothing at all", []string{"", ""}, ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := concatAligned(tt.texts, csvOptions{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("concatAligned = %q; want %q", got, tt.want)
+            }
+        })
+    }
+    _, err := concatAligned([]string{"a\n1\n", "a,a\n1,2\n"}, csvOptions{})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != `input 1 repeats column "a"` {
+        t.Errorf("repeated column: got %v", m)
+    }
+}
+
+func TestRowDeltas(t *testing.T) {
+    tests := []struct {
+        name, text, want string
+        opts             csvOptions
+    }{
+        {"clean column", "t,v\n1,10\n2,12.5\n3,11\n", "t,v,v_delta\n1,10,\n2,12.5,2.5\n3,11,-1.5\n", csvOptions{HasHeader: true}},
+        // x breaks both the delta into it and the one out of it.
+        {"gap at a non-numeric cell", "t,v\n1,10\n2,x\n3,15\n4,14\n", "t,v,v_delta\n1,10,\n2,x,\n3,15,\n4,14,-1\n", csvOptions{HasHeader: true}},
+        {"decimals follow the operands", "1,0.1\n2,0.3\n", "1,0.1,\n2,0.3,0.2\n", csvOptions{}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := rowDeltas(tt.text, 1, tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("rowDeltas = %q; want %q", got, tt.want)
+            }
+        })
+    }
+    if _, err := rowDeltas("a\n1\n", 1, csvOptions{}); err == nil {
+        t.Error("out-of-range column accepted")
+    }
+}
+
+func TestCanonicalizeCSV(t *testing.T) {
+    inputs := []struct {
+        text string
+        opts csvOptions
+    }{
+        {"a,b c,d\n1,\"x,y\",\"say \"\"hi\"\"\"\n", csvOptions{}},
+        {"\"a\",\"b c\",\"d\"\r\n\"1\",\"x,y\",\"say \"\"hi\"\"\"\r\n\r\n", csvOptions{}},
+        {"\ufeffa;b c;d\n1;x,y;\"say \"\"hi\"\"\"", csvOptions{Delimiter: ';'}},
+    }
+    const want = "a,b c,d\n1,\"x,y\",\"say \"\"hi\"\"\"\n"
+    for i, in := range inputs {
+        got, err := canonicalizeCSV(in.text, in.opts)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got != want {
+            t.Errorf("input %d canonicalizes to %q; want %q", i, got, want)
+        }
+    }
+    if _, err := canonicalizeCSV("a,\"b\n", csvOptions{}); err == nil {
+        t.Error("unterminated quote accepted")
+    }
+}
+
+func TestRequote(t *testing.T) {
+    const text = "id,name,score,note\n1,ann,2.5,\n2,\"b,ob\",x,\"say \"\"hi\"\"\"\n"
+    tests := []struct {
+        policy, want string
+    }{
+        {"minimal", "id,name,score,note\n1,ann,2.5,\n2,\"b,ob\",x,\"say \"\"hi\"\"\"\n"},
+        {"all", "\"id\",\"name\",\"score\",\"note\"\n\"1\",\"ann\",\"2.5\",\"\"\n\"2\",\"b,ob\",\"x\",\"say \"\"hi\"\"\"\n"},
+        // Numbers stay bare; text, including the header and the empty note, is quoted.
+        {"nonnumeric", "\"id\",\"name\",\"score\",\"note\"\n1,\"ann\",2.5,\"\"\n2,\"b,ob\",\"x\",\"say \"\"hi\"\"\"\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.policy, func(t *testing.T) {
+            got, err := requote(text, tt.policy, csvOptions{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("requote(%q) = %q; want %q", tt.policy, got, tt.want)
+            }
+            if _, err := readAllRecords(got, csvOptions{}); err != nil {
+                t.Errorf("requoted output does not parse: %v", err)
+            }
+        })
+    }
+    // The output keeps the input delimiter.
+    got, err := requote("1.5;x\n", "nonnumeric", csvOptions{Delimiter: ';'})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got != "1.5;\"x\"\n" {
+        t.Errorf("requote with ';' = %q; want %q", got, "1.5;\"x\"\n")
+    }
+    _, err = requote(text, "some", csvOptions{})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != `unknown quoting policy "some" (want minimal, all or nonnumeric)` {
+        t.Errorf("unknown policy: got %v", m)
+    }
+}
+
+func TestMovingAverage(t *testing.T) {
+    tests := []struct {
+        name, text, want string
+    }{
+        // Averages get two more decimals than the inputs: 7/3 is 2.33.
+        {"full windows", "t,v\n1,1\n2,2\n3,4\n4,5\n", "t,v,v_ma3\n1,1,\n2,2,\n3,4,2.33\n4,5,3.67\n"},
+        // The blank on row 2 empties every window that holds it, up to row 4.
+        {"blank in the window", "t,v\n1,1\n2,\n3,4\n4,5\n5,6\n6,1.5\n", "t,v,v_ma3\n1,1,\n2,,\n3,4,\n4,5,\n5,6,5\n6,1.5,4.167\n"},
+        {"non-numeric in the window", "t,v\n1,1\n2,x\n3,4\n4,5\n5,6\n", "t,v,v_ma3\n1,1,\n2,x,\n3,4,\n4,5,\n5,6,5\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := movingAverage(tt.text, 1, 3, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("movingAverage = %q; want %q", got, tt.want)
+            }
+        })
+    }
+    _, err := movingAverage("a\n1\n", 0, 0, csvOptions{})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != "window must be positive, got 0" {
+        t.Errorf("zero window: got %v", m)
+    }
+}
+
+func TestPartitionByColumn(t *testing.T) {
+    got, err := partitionByColumn("k,v\na,1\nb,2\na,3\n,4\n", 0, 0, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := map[string]any{
+        "partitions":  map[string]any{"a": "k,v\na,1\na,3\n", "b": "k,v\nb,2\n", "": "k,v\n,4\n"},
+        "truncated":   false,
+        "droppedRows": 0,
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("partitionByColumn = %v; want %v", got, want)
+    }
+    // With a cap of two, the rows for c are dropped and counted.
+    got, err = partitionByColumn("k,v\na,1\nb,2\na,3\nc,4\nc,5\n", 0, 2, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    want = map[string]any{
+        "partitions":  map[string]any{"a": "k,v\na,1\na,3\n", "b": "k,v\nb,2\n"},
+        "truncated":   true,
+        "droppedRows": 2,
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("partitionByColumn capped at 2 = %v; want %v", got, want)
+    }
+    if _, err := partitionByColumn("k\na\n", 1, 0, csvOptions{}); err == nil {
+        t.Error("out-of-range column accepted")
+    }
+}
+
+func TestRectangularize(t *testing.T) {
+    const text = "a,b,c\n1\n1,2,3\n1,2,3,4\n"
+    tests := []struct {
+        name string
+        text string
+        opts csvOptions
+        want string
+    }{
+        {"header width", text, csvOptions{HasHeader: true}, "a,b,c\n1,-,-\n1,2,3\n1,2,3\n"},
+        {"widest row without a header", "1\n1,2,3\n", csvOptions{}, "1,-,-\n1,2,3\n"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := rectangularize(tt.text, "-", false, tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("rectangularize = %q; want %q", got, tt.want)
+            }
+        })
+    }
+    _, err := rectangularize(text, "", true, csvOptions{HasHeader: true})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != "line 4 has 4 fields, more than the 3 of the header" {
+        t.Errorf("errorOnLong: got %v", m)
+    }
+}
+
+func TestMapColumn(t *testing.T) {
+    upper := func(v string) (string, error) { return strings.ToUpper(v), nil }
+    got, err := mapColumn("id,name\n1,ann\n2,bob\n3\n", 1, upper, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "id,name\n1,ANN\n2,BOB\n3\n"; got != want {
+        t.Errorf("mapColumn = %q; want %q", got, want)
+    }
+    fail := func(v string) (string, error) {
+        if v == "bob" {
+            return "", errors.New("no bobs")
+        }
+        return v, nil
+    }
+    _, err = mapColumn("id,name\n1,ann\n2,bob\n", 1, fail, csvOptions{HasHeader: true})
+    if m := errorMap(err); m["code"] != codeBadArgument || m["message"] != "line 3: no bobs" {
+        t.Errorf("failing callback: got %v", m)
+    }
+}
+
+func TestColumnDiff(t *testing.T) {
+    // Row 3 has a non-numeric a and row 4 is too short to reach b, so both get an empty
+    // delta; 1.5 - 0.25 keeps the two decimals of the more precise operand.
+    got, err := columnDiff("id,a,b\n1,5,2\n2,1.5,0.25\n3,x,1\n4,2\n", 1, 2, "delta", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := "id,a,b,delta\n1,5,2,3\n2,1.5,0.25,1.25\n3,x,1,\n4,2,,\n"; got != want {
+        t.Errorf("columnDiff = %q; want %q", got, want)
+    }
+    if _, err := columnDiff("a,b\n1,2\n", 0, 2, "d", csvOptions{HasHeader: true}); err == nil {
+        t.Error("out-of-range column accepted")
+    }
+}
+
+func TestChunkedOutput(t *testing.T) {
+    var b strings.Builder
+    b.WriteString("id,name\n")
+    for i := range 25 {
+        fmt.Fprintf(&b, "%d,\"user %d\"\n", i, i)
+    }
+    text := b.String()
+    tests := []struct {
+        name string
+        run  func(opts csvOptions) (string, error)
+    }{
+        {"canonicalize", func(opts csvOptions) (string, error) { return canonicalizeCSV(text, opts) }},
+        {"requote", func(opts csvOptions) (string, error) { return requote(text, "all", opts) }},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            want, err := tt.run(csvOptions{})
+            if err != nil {
+                t.Fatal(err)
+            }
+            var chunks []string
+            got, err := tt.run(csvOptions{Chunk: func(chunk string) { chunks = append(chunks, chunk) }, ChunkRows: 10})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != "" {
+                t.Errorf("returned %q alongside the chunks; want \"\"", got)
+            }
+            // 26 records in chunks of 10 make three calls, the last one short.
+            if len(chunks) != 3 || strings.Count(chunks[2], "\n") != 6 {
+                t.Errorf("chunks = %q; want 10, 10 and 6 records", chunks)
+            }
+            if strings.Join(chunks, "") != want {
+                t.Errorf("reassembled chunks = %q; want %q", strings.Join(chunks, ""), want)
+            }
+        })
+    }
+    if err := (csvOptions{ChunkRows: -1}).validate(); err == nil {
+        t.Error("negative chunkRows accepted")
+    }
+}
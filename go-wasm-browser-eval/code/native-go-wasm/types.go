@@ -0,0 +1,135 @@
+package main
+
+import (
+    "strconv"
+    "strings"
+    "time"
+)
+
+// dateLayouts are the time layouts a cell may match to be classified as a date.
+var dateLayouts = []string{
+    "2006-01-02",
+    time.RFC3339,
+    "2006-01-02 15:04:05",
+    "01/02/2006",
+}
+
+// Column type labels reported to JavaScript.
+const (
+    typeInteger = "integer"
+    typeFloat   = "float"
+    typeBoolean = "boolean"
+    typeDate    = "date"
+    typeString  = "string"
+)
+
+// classifyValue returns the narrowest type label for a single non-empty cell.
+func classifyValue(value string) string {
+    if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+        return typeInteger
+    }
+    if _, err := strconv.ParseFloat(value, 64); err == nil {
+        return typeFloat
+    }
+    if strings.EqualFold(value, "true") || strings.EqualFold(value, "false") {
+        return typeBoolean
+    }
+    for _, layout := range dateLayouts {
+        if _, err := time.Parse(layout, value); err == nil {
+            return typeDate
+        }
+    }
+    return typeString
+}
+
+// typeTracker accumulates the inferred type of one column a value at a time.
+// Empty cells are ignored so they never force a column to string.
+type typeTracker struct {
+    kind string
+}
+
+// observe folds one cell into the running type. Integer and float widen to float;
+// any other disagreement collapses to string.
+func (t *typeTracker) observe(value string) {
+    if value == "" || t.kind == typeString {
+        return
+    }
+    kind := classifyValue(value)
+    switch {
+    case t.kind == "" || t.kind == kind:
+        t.kind = kind
+    case (t.kind == typeInteger || t.kind == typeFloat) && (kind == typeInteger || kind == typeFloat):
+        t.kind = typeFloat
+    default:
+        t.kind = typeString
+    }
+}
+
+// result returns the inferred label, defaulting to string for columns with no values.
+func (t *typeTracker) result() string {
+    if t.kind == "" {
+        return typeString
+    }
+    return t.kind
+}
+
+// inferColumnTypes classifies each column of rows as integer, float, boolean, date or string.
+func inferColumnTypes(rows [][]string) []string {
+    var trackers []typeTracker
+    for _, row := range rows {
+        for len(trackers) < len(row) {
+            trackers = append(trackers, typeTracker{})
+        }
+        for i, value := range row {
+            trackers[i].observe(value)
+        }
+    }
+    types := make([]string, len(trackers))
+    for i := range trackers {
+        types[i] = trackers[i].result()
+    }
+    return types
+}
+
+// typeLabels lists every label classifyValue can return, in widening order.
+var typeLabels = []string{typeInteger, typeFloat, typeBoolean, typeDate, typeString}
+
+// typeConfidence returns, per column, the share of its non-empty cells that classifyValue
+// puts under each of typeLabels, so a column inferred as string can show it is 95%
+// integer. Each cell counts once under its narrowest type, so the shares of a column add
+// up to 1; a column with no non-empty cells reports 0 for every type.
+func typeConfidence(rows [][]string) []map[string]any {
+    width := tableWidth(rows)
+    counts := make([]map[string]int, width)
+    filled := make([]int, width)
+    for i := range counts {
+        counts[i] = make(map[string]int, len(typeLabels))
+    }
+    for _, row := range rows {
+        for i, value := range row {
+            if value == "" {
+                continue
+            }
+            counts[i][classifyValue(value)]++
+            filled[i]++
+        }
+    }
+    out := make([]map[string]any, width)
+    for i := range out {
+        out[i] = typeShares(counts[i], filled[i])
+    }
+    return out
+}
+
+// typeShares turns per-label counts over filled cells into typeConfidence's shares.
+func typeShares(counts map[string]int, filled int) map[string]any {
+    shares := make(map[string]any, len(typeLabels))
+    for _, label := range typeLabels {
+        share := 0.0
+        if filled > 0 {
+            share = float64(counts[label]) / float64(filled)
+        }
+        shares[label] = share
+    }
+    return shares
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+    "fmt"
+    "reflect"
+    "testing"
+)
+
+func TestInferColumnTypes(t *testing.T) {
+    tests := []struct {
+        name string
+        rows [][]string
+        want []string
+    }{
+        {"integers with one empty cell", [][]string{{"1"}, {""}, {"3"}}, []string{typeInteger}},
+        {"int and float collapse to float", [][]string{{"1"}, {"2.5"}, {"3"}}, []string{typeFloat}},
+        {"booleans", [][]string{{"true"}, {"FALSE"}}, []string{typeBoolean}},
+        {"dates", [][]string{{"2024-01-02"}, {"2024-02-03"}}, []string{typeDate}},
+        {"mixed falls back to string", [][]string{{"1"}, {"yes"}}, []string{typeString}},
+        {"all empty is string", [][]string{{""}, {""}}, []string{typeString}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := inferColumnTypes(tt.rows); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("inferColumnTypes = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSummaryTypes(t *testing.T) {
+    result, err := summaryFromCSV("id,score\n1,1\n,2.5\n3,3\n", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := []string{typeInteger, typeFloat}; !reflect.DeepEqual(result["types"], want) {
+        t.Errorf("types = %v; want %v", result["types"], want)
+    }
+}
+
+func TestSummaryTrimSpace(t *testing.T) {
+    tests := []struct {
+        trim bool
+        want string
+    }{
+        {false, "string"},
+        {true, "integer"},
+    }
+    for _, tt := range tests {
+        summary, err := summaryFromCSV("n\n 42 \n", csvOptions{HasHeader: true, TrimSpace: tt.trim})
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got := summary["types"].([]string)[0]; got != tt.want {
+            t.Errorf("trimSpace=%v: type = %q; want %q", tt.trim, got, tt.want)
+        }
+    }
+}
+
+func TestTypeConfidence(t *testing.T) {
+    // Eighteen integers and two stray words: 90% integer, 10% string.
+    rows := make([][]string, 0, 21)
+    for i := range 18 {
+        rows = append(rows, []string{fmt.Sprint(i), "true"})
+    }
+    rows = append(rows, []string{"n/a", "2024-01-02"}, []string{"unknown", ""}, []string{""})
+    got := typeConfidence(rows)
+    want := []map[string]any{
+        {typeInteger: 0.9, typeFloat: 0.0, typeBoolean: 0.0, typeDate: 0.0, typeString: 0.1},
+        {typeInteger: 0.0, typeFloat: 0.0, typeBoolean: 18.0 / 19, typeDate: 1.0 / 19, typeString: 0.0},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("typeConfidence = %v\nwant %v", got, want)
+    }
+    empty := typeConfidence([][]string{{""}})[0]
+    for _, label := range typeLabels {
+        if empty[label] != 0.0 {
+            t.Errorf("share of %s in an empty column = %v; want 0", label, empty[label])
+        }
+    }
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "math"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// maxRowErrors caps how many problems validateCSV reports so a bad file cannot flood JS.
+const maxRowErrors = 1000
+
+// RowError describes one cell that failed schema validation.
+type RowError struct {
+    Line    int
+    Column  int
+    Value   string
+    Message string
+}
+
+// toMap renders e for JavaScript.
+func (e RowError) toMap() map[string]any {
+    return map[string]any{
+        "line":    e.Line,
+        "column":  e.Column,
+        "value":   e.Value,
+        "message": e.Message,
+    }
+}
+
+// schemaCheckers maps each schema type name to a predicate over non-empty cell values.
+var schemaCheckers = map[string]func(string) bool{
+    "int": func(v string) bool {
+        _, err := strconv.ParseInt(v, 10, 64)
+        return err == nil
+    },
+    "float": func(v string) bool {
+        _, err := strconv.ParseFloat(v, 64)
+        return err == nil
+    },
+    "date": func(v string) bool {
+        for _, layout := range dateLayouts {
+            if _, err := time.Parse(layout, v); err == nil {
+                return true
+            }
+        }
+        return false
+    },
+    "string": func(string) bool { return true },
+}
+
+// validateCSV checks every data row of csvText against schema, which names the expected
+// type of each column. Empty cells are accepted; rows with fewer fields than the schema
+// report the first missing column. At most maxRowErrors problems are returned.
+func validateCSV(csvText string, schema []string, opts csvOptions) ([]RowError, error) {
+    checkers := make([]func(string) bool, len(schema))
+    for i, name := range schema {
+        check, ok := schemaCheckers[name]
+        if !ok {
+            return nil, badArgument("unknown schema type %q (want one of int, float, string, date)", name)
+        }
+        checkers[i] = check
+    }
+    problems := []RowError{}
+    skipHeader := opts.HasHeader
+    err := readRecordsAt(strings.NewReader(csvText), opts, func(record []string, line int) bool {
+        if skipHeader {
+            skipHeader = false
+            return true
+        }
+        if len(record) < len(schema) {
+            problems = append(problems, RowError{
+                Line:    line,
+                Column:  len(record),
+                Message: fmt.Sprintf("expected %d fields, got %d", len(schema), len(record)),
+            })
+            return len(problems) < maxRowErrors
+        }
+        for i, check := range checkers {
+            value := record[i]
+            if value == "" || check(value) {
+                continue
+            }
+            problems = append(problems, RowError{
+                Line:    line,
+                Column:  i,
+                Value:   value,
+                Message: fmt.Sprintf("expected %s", schema[i]),
+            })
+            if len(problems) >= maxRowErrors {
+                return false
+            }
+        }
+        return true
+    })
+    if err != nil {
+        return nil, err
+    }
+    return problems, nil
+}
+
+// emailPattern is a deliberately loose address check: one "@", no whitespace, and a dot
+// in the domain with something after it. It is compiled once for every call.
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s.]+$`)
+
+// validateEmailColumn returns the 1-based line numbers of the cells in col that do not
+// look like email addresses under emailPattern, as described on columnFailures.
+func validateEmailColumn(csvText string, col int, opts csvOptions) ([]int, error) {
+    return columnFailures(csvText, col, emailPattern.MatchString, opts)
+}
+
+// regexValidateColumn returns the 1-based line numbers of the cells in col that do not
+// match pattern, as described on columnFailures. The pattern is unanchored, so callers
+// wanting a whole-cell match write ^...$; opts.IgnoreCase adds the (?i) flag.
+func regexValidateColumn(csvText string, col int, pattern string, opts csvOptions) ([]int, error) {
+    match, err := newCellMatcher("regex", pattern, opts.IgnoreCase)
+    if err != nil {
+        return nil, err
+    }
+    return columnFailures(csvText, col, match, opts)
+}
+
+// enumValidateColumn returns the 1-based line numbers of the cells in col whose value is
+// not one of allowed, as described on columnFailures. Values compare exactly, or
+// case-insensitively with opts.IgnoreCase.
+func enumValidateColumn(csvText string, col int, allowed []string, opts csvOptions) ([]int, error) {
+    set := make(map[string]bool, len(allowed))
+    for _, value := range allowed {
+        if opts.IgnoreCase {
+            value = strings.ToLower(value)
+        }
+        set[value] = true
+    }
+    return columnFailures(csvText, col, func(value string) bool {
+        if opts.IgnoreCase {
+            value = strings.ToLower(value)
+        }
+        return set[value]
+    }, opts)
+}
+
+// columnFailures returns the 1-based line numbers of the cells in col that fail match.
+// Empty cells and rows too short to reach col are skipped unless opts.FlagEmpty is set,
+// in which case they are tested as "". The header (when opts.HasHeader is set) is always
+// skipped, and at most maxRowErrors lines are returned.
+func columnFailures(csvText string, col int, match cellMatcher, opts csvOptions) ([]int, error) {
+    lines := []int{}
+    skipHeader := opts.HasHeader
+    width := 0
+    err := readRecordsAt(strings.NewReader(csvText), opts, func(record []string, line int) bool {
+        width = max(width, len(record))
+        if skipHeader {
+            skipHeader = false
+            return true
+        }
+        if value := cell(record, col); (value != "" || opts.FlagEmpty) && !match(value) {
+            lines = append(lines, line)
+        }
+        return len(lines) < maxRowErrors
+    })
+    if err != nil {
+        return nil, err
+    }
+    if len(lines) < maxRowErrors {
+        if err := checkColumn(col, width); err != nil {
+            return nil, err
+        }
+    }
+    return lines, nil
+}
+
+// rangeCheck returns the 1-based line numbers of the numeric cells in col that fall
+// outside [lo, hi], along with how many non-empty cells were not numbers and so went
+// unchecked. Empty cells, short rows and the header (when opts.HasHeader is set) are
+// skipped, and at most maxRowErrors lines are returned; the non-numeric count always
+// covers the whole column.
+func rangeCheck(csvText string, col int, lo, hi float64, opts csvOptions) (map[string]any, error) {
+    if math.IsNaN(lo) || math.IsNaN(hi) || lo > hi {
+        return nil, badArgument("range [%v, %v] is empty", lo, hi)
+    }
+    lines := []int{}
+    nonNumeric := 0
+    skipHeader := opts.HasHeader
+    width := 0
+    err := readRecordsAt(strings.NewReader(csvText), opts, func(record []string, line int) bool {
+        width = max(width, len(record))
+        if skipHeader {
+            skipHeader = false
+            return true
+        }
+        value := cell(record, col)
+        if opts.TrimSpace {
+            value = strings.TrimSpace(value)
+        }
+        if value == "" {
+            return true
+        }
+        x, err := strconv.ParseFloat(normalizeDecimal(value, opts.DecimalSeparator), 64)
+        if err != nil || math.IsNaN(x) {
+            nonNumeric++
+        } else if (x < lo || x > hi) && len(lines) < maxRowErrors {
+            lines = append(lines, line)
+        }
+        return true
+    })
+    if err != nil {
+        return nil, err
+    }
+    if err := checkColumn(col, width); err != nil {
+        return nil, err
+    }
+    return map[string]any{"outOfRange": lines, "nonNumeric": nonNumeric}, nil
+}
+
+// checkMonotonic reports the 1-based line of the first numeric cell in col that does not
+// increase on the previous numeric cell under "breakLine", or 0 when the column never
+// goes down (and, with strictlyIncreasing, never repeats a value). Cells are read as in
+// rangeCheck: empty cells, short rows and the header (when opts.HasHeader is set) are
+// skipped, and non-empty cells that are not numbers are counted under "nonNumeric"
+// without breaking the sequence.
+func checkMonotonic(csvText string, col int, strictlyIncreasing bool, opts csvOptions) (map[string]any, error) {
+    breakLine, nonNumeric := 0, 0
+    prev, seen := 0.0, false
+    skipHeader := opts.HasHeader
+    width := 0
+    err := readRecordsAt(strings.NewReader(csvText), opts, func(record []string, line int) bool {
+        width = max(width, len(record))
+        if skipHeader {
+            skipHeader = false
+            return true
+        }
+        value := cell(record, col)
+        if opts.TrimSpace {
+            value = strings.TrimSpace(value)
+        }
+        if value == "" {
+            return true
+        }
+        x, err := strconv.ParseFloat(normalizeDecimal(value, opts.DecimalSeparator), 64)
+        if err != nil || math.IsNaN(x) {
+            nonNumeric++
+            return true
+        }
+        if seen && breakLine == 0 && (x < prev || strictlyIncreasing && x == prev) {
+            breakLine = line
+        }
+        prev, seen = x, true
+        return true
+    })
+    if err != nil {
+        return nil, err
+    }
+    if err := checkColumn(col, width); err != nil {
+        return nil, err
+    }
+    return map[string]any{"breakLine": breakLine, "nonNumeric": nonNumeric}, nil
+}
+
+// duplicateHeaders maps each header name that occurs more than once in the first record
+// of csvText to the indices of its columns. Names compare exactly, or case-insensitively
+// with opts.IgnoreCase, in which case a duplicate is keyed by its first spelling. The
+// map is empty when every name is unique or the input is empty.
+func duplicateHeaders(csvText string, opts csvOptions) (map[string][]int, error) {
+    header, err := newCSVReader(strings.NewReader(csvText), opts).Read()
+    if err == io.EOF {
+        return map[string][]int{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to parse csv: %w", err)
+    }
+    first := make(map[string]string, len(header))
+    positions := make(map[string][]int, len(header))
+    for i, name := range header {
+        key := name
+        if opts.IgnoreCase {
+            key = strings.ToLower(name)
+        }
+        if _, ok := first[key]; !ok {
+            first[key] = name
+        }
+        positions[key] = append(positions[key], i)
+    }
+    dups := map[string][]int{}
+    for key, cols := range positions {
+        if len(cols) > 1 {
+            dups[first[key]] = cols
+        }
+    }
+    return dups, nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestValidateCSV(t *testing.T) {
+    schema := []string{"int", "string", "date"}
+    tests := []struct {
+        name string
+        text string
+        want []RowError
+    }{
+        {"valid", "a,b,c\n1,x,2024-01-02\n,,\n", []RowError{}},
+        {
+            "type mismatches",
+            "a,b,c\n1,x,2024-01-02\nz,y,nope\n",
+            []RowError{
+                {Line: 3, Column: 0, Value: "z", Message: "expected int"},
+                {Line: 3, Column: 2, Value: "nope", Message: "expected date"},
+            },
+        },
+        {
+            "too few columns",
+            "a,b,c\n3\n",
+            []RowError{{Line: 2, Column: 1, Message: "expected 3 fields, got 1"}},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := validateCSV(tt.text, schema, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("validateCSV = %+v; want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestValidateCSVCapsErrors(t *testing.T) {
+    text := "n\n" + strings.Repeat("x\n", maxRowErrors+50)
+    got, err := validateCSV(text, []string{"int"}, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(got) != maxRowErrors {
+        t.Errorf("reported %d errors; want the %d cap", len(got), maxRowErrors)
+    }
+}
+
+func TestValidateCSVUnknownType(t *testing.T) {
+    if _, err := validateCSV("a\n1\n", []string{"uuid"}, csvOptions{}); err == nil {
+        t.Error("unknown schema type accepted")
+    }
+}
+
+func TestValidateEmailColumn(t *testing.T) {
+    const text = "name,email\n" +
+        "ann,ann@example.com\n" + // 2 valid
+        "bob,bob@mail.example.co.uk\n" + // 3 valid
+        "cy,\n" + // 4 empty, skipped
+        "dee,dee@@example.com\n" + // 5 two @
+        "eve,eve example.com\n" + // 6 no @
+        "fay,fay@localhost\n" + // 7 no dot in the domain
+        "gus,gus@example.\n" + // 8 nothing after the dot
+        "hal, hal@example.com\n" + // 9 whitespace
+        "ivy\n" // 10 short row, skipped
+    got, err := validateEmailColumn(text, 1, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := []int{5, 6, 7, 8, 9}; !reflect.DeepEqual(got, want) {
+        t.Errorf("validateEmailColumn = %v; want %v", got, want)
+    }
+    got, err = validateEmailColumn(text, 1, csvOptions{HasHeader: true, FlagEmpty: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := []int{4, 5, 6, 7, 8, 9, 10}; !reflect.DeepEqual(got, want) {
+        t.Errorf("with flagEmpty = %v; want %v", got, want)
+    }
+    if _, err := validateEmailColumn(text, 2, csvOptions{HasHeader: true}); err == nil {
+        t.Error("out-of-range column accepted")
+    }
+}
+
+func TestRangeCheck(t *testing.T) {
+    const text = "id,score\n" +
+        "1,50\n" + // 2 in range
+        "2,-3\n" + // 3 below min
+        "3,\n" + // 4 empty, skipped
+        "4,abc\n" + // 5 non-numeric, unchecked
+        "5,101\n" + // 6 above max
+        "6,100\n" + // 7 on the bound
+        "7,0\n" // 8 on the bound
+    got, err := rangeCheck(text, 1, 0, 100, csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if want := map[string]any{"outOfRange": []int{3, 6}, "nonNumeric": 1}; !reflect.DeepEqual(got, want) {
+        t.Errorf("rangeCheck = %v; want %v", got, want)
+    }
+    // Without the header flag the header itself is a non-numeric cell.
+    got, err = rangeCheck(text, 1, 0, 100, csvOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got["nonNumeric"] != 2 {
+        t.Errorf("nonNumeric without a header = %v; want 2", got["nonNumeric"])
+    }
+    if _, err := rangeCheck(text, 1, 5, 1, csvOptions{HasHeader: true}); err == nil || err.Error() != "range [5, 1] is empty" {
+        t.Errorf("inverted range: got %v", err)
+    }
+    if _, err := rangeCheck(text, 2, 0, 100, csvOptions{HasHeader: true}); err == nil {
+        t.Error("out-of-range column accepted")
+    }
+}
+
+func TestRegexValidateColumn(t *testing.T) {
+    tests := []struct {
+        name, text string
+        want       []int
+    }{
+        // The empty cell on line 4 is skipped rather than failed.
+        {"passing column", "id,year\n1,2024\n2,1999\n3,\n", []int{}},
+        {"failing cells", "id,year\n1,2024\n2,24\n3,abcd\n4,\n5,20245\n", []int{3, 4, 6}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := regexValidateColumn(tt.text, 1, `^\d{4}$`, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("regexValidateColumn = %v; want %v", got, tt.want)
+            }
+        })
+    }
+    _, err := regexValidateColumn("year\n2024\n", 0, `(`, csvOptions{HasHeader: true})
+    if m := errorMap(err); m["code"] != codeBadArgument || !strings.HasPrefix(m["message"].(string), "invalid regex: ") {
+        t.Errorf("invalid pattern: got %v", m)
+    }
+}
+
+func TestDuplicateHeaders(t *testing.T) {
+    const text = "id,name,id,ID,Name\n1,2,3,4,5\n"
+    tests := []struct {
+        name string
+        text string
+        opts csvOptions
+        want map[string][]int
+    }{
+        {"two id columns", text, csvOptions{}, map[string][]int{"id": {0, 2}}},
+        // Ignoring case, each duplicate is keyed by its first spelling.
+        {"ignore case", text, csvOptions{IgnoreCase: true}, map[string][]int{"id": {0, 2, 3}, "name": {1, 4}}},
+        {"unique", "a,b,c\n", csvOptions{}, map[string][]int{}},
+        {"empty", "", csvOptions{}, map[string][]int{}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := duplicateHeaders(tt.text, tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("duplicateHeaders = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestEnumValidateColumn(t *testing.T) {
+    const text = "id,grade\n" +
+        "1,A\n" + // 2 allowed
+        "2,b\n" + // 3 allowed only ignoring case
+        "3,\n" + // 4 empty
+        "4,D\n" + // 5 outside the set
+        "5\n" // 6 short row
+    allowed := []string{"A", "B", "C"}
+    tests := []struct {
+        name string
+        opts csvOptions
+        want []int
+    }{
+        {"exact", csvOptions{HasHeader: true}, []int{3, 5}},
+        {"ignore case", csvOptions{HasHeader: true, IgnoreCase: true}, []int{5}},
+        {"empties flagged", csvOptions{HasHeader: true, IgnoreCase: true, FlagEmpty: true}, []int{4, 5, 6}},
+        {"header counted as data", csvOptions{IgnoreCase: true}, []int{1, 5}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := enumValidateColumn(text, 1, allowed, tt.opts)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("enumValidateColumn = %v; want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCheckMonotonic(t *testing.T) {
+    tests := []struct {
+        name   string
+        text   string
+        strict bool
+        want   map[string]any
+    }{
+        // The x is counted but does not break the run from 2 to 5.
+        {"increasing", "t\n1\n2\n2\nx\n\n5\n", false, map[string]any{"breakLine": 0, "nonNumeric": 1}},
+        {"repeat under strict", "t\n1\n2\n2\nx\n5\n", true, map[string]any{"breakLine": 4, "nonNumeric": 1}},
+        {"decreasing", "t\n5\n4\n3\n", false, map[string]any{"breakLine": 3, "nonNumeric": 0}},
+        {"strictly increasing", "t\n-1\n0\n0.5\n", true, map[string]any{"breakLine": 0, "nonNumeric": 0}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := checkMonotonic(tt.text, 0, tt.strict, csvOptions{HasHeader: true})
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("checkMonotonic = %v; want %v", got, tt.want)
+            }
+        })
+    }
+    if _, err := checkMonotonic("t\n1\n", 1, false, csvOptions{HasHeader: true}); err == nil {
+        t.Error("out-of-range column accepted")
+    }
+}
@@ -0,0 +1,20 @@
+package main
+
+import "go-wasm-browser-eval/code/buildinfo"
+
+// buildTime is stamped by build_native.sh via -ldflags "-X main.buildTime=...".
+var buildTime = "unknown"
+
+// versionInfo reports which toolchain produced the running module. The compiler comes
+// from buildinfo's build-tagged files, shared with the TinyGo sample, so the same source
+// reports "tinygo" when built with TinyGo.
+func versionInfo() map[string]any {
+    return buildinfo.Version(buildTime)
+}
+
+// capabilities reports which optional features are compiled into the running module so
+// one JS wrapper can feature-detect across builds. gzip, sha256 and normalize follow
+// buildinfo's build-tagged constants and parallel follows the build-tagged tableStats.
+func capabilities() map[string]any {
+    return buildinfo.Capabilities(parallelStats)
+}
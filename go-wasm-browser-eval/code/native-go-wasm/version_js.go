@@ -0,0 +1,15 @@
+//go:build js && wasm
+
+package main
+
+import "syscall/js"
+
+// wrapVersion exposes versionInfo to JavaScript as wasmVersion().
+func wrapVersion(this js.Value, args []js.Value) any {
+    return versionInfo()
+}
+
+// wrapCapabilities exposes capabilities to JavaScript as wasmCapabilities().
+func wrapCapabilities(this js.Value, args []js.Value) any {
+    return capabilities()
+}
@@ -0,0 +1,32 @@
+package main
+
+import "unicode/utf8"
+
+// widthTracker records the longest cell seen in one column, measured in runes so
+// multibyte characters count once.
+type widthTracker struct {
+    max int
+}
+
+// observe folds one cell into the running maximum.
+func (t *widthTracker) observe(value string) {
+    t.max = max(t.max, utf8.RuneCountInString(value))
+}
+
+// maxWidths returns, per column, the rune count of the longest cell in rows.
+func maxWidths(rows [][]string) []int {
+    var trackers []widthTracker
+    for _, row := range rows {
+        for len(trackers) < len(row) {
+            trackers = append(trackers, widthTracker{})
+        }
+        for i, value := range row {
+            trackers[i].observe(value)
+        }
+    }
+    widths := make([]int, len(trackers))
+    for i := range trackers {
+        widths[i] = trackers[i].max
+    }
+    return widths
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestMaxWidths(t *testing.T) {
+    rows := [][]string{{"a", "b"}, {"🙂🙂🙂", "東京都庁"}, {"x"}, {"Zoë", ""}}
+    if got, want := maxWidths(rows), []int{3, 4}; !reflect.DeepEqual(got, want) {
+        t.Errorf("maxWidths = %v; want rune counts %v", got, want)
+    }
+    if got := maxWidths(nil); len(got) != 0 {
+        t.Errorf("maxWidths(nil) = %v; want none", got)
+    }
+}
+
+func TestSummaryMaxWidthsCountsHeader(t *testing.T) {
+    summary, err := summaryFromCSV("identifier,c\n🙂,東京\n", csvOptions{HasHeader: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got, want := summary["maxWidths"], []int{10, 2}; !reflect.DeepEqual(got, want) {
+        t.Errorf("maxWidths = %v; want %v with the header cell counted", got, want)
+    }
+}
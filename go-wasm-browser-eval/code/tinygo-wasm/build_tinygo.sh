@@ -8,7 +8,8 @@ mkdir -p "$OUT_DIR"
 TINYGO_BIN=${TINYGO_BIN:-tinygo}
 
 "$TINYGO_BIN" version 1>&2
-"$TINYGO_BIN" build -target wasm -o "$OUT_DIR/tinygo.wasm" "$TASK_DIR/tinygo-wasm"
+BUILD_TIME=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+"$TINYGO_BIN" build -target wasm -ldflags "-X main.buildTime=$BUILD_TIME" -o "$OUT_DIR/tinygo.wasm" "$TASK_DIR/tinygo-wasm"
 
 TINYGO_ROOT=$(cd -- "$(dirname -- "$TINYGO_BIN")"/.. && pwd)
 cp "$TINYGO_ROOT/lib/wasm_exec.js" "$OUT_DIR/wasm_exec.js"
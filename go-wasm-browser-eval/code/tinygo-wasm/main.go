@@ -1,15 +1,39 @@
+//go:build js && wasm
+
 package main
 
 import (
+    "crypto/sha256"
     "encoding/csv"
+    "encoding/hex"
     "fmt"
+    "runtime"
     "strings"
     "syscall/js"
+    "unicode/utf8"
+
+    "go-wasm-browser-eval/code/buildinfo"
+)
+
+// Error codes mirror the native build so JS can branch on the same values.
+const (
+    codeParseError  = "parse_error"
+    codeBadArgument = "bad_argument"
+    codeUnsupported = "unsupported"
 )
 
+// errorResult builds the same {error, code, message} map as the native build.
+func errorResult(code, msg string) map[string]any {
+    return map[string]any{"error": msg, "code": code, "message": msg}
+}
+
 // csvOverview mirrors the native example but is compiled with TinyGo.
-func csvOverview(csvText string) (map[string]any, error) {
-    reader := csv.NewReader(strings.NewReader(csvText))
+// A leading UTF-8 BOM is stripped before parsing, as in the native build.
+func csvOverview(csvText string, delimiter rune) (map[string]any, error) {
+    reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(csvText, "\ufeff")))
+    if delimiter != 0 {
+        reader.Comma = delimiter
+    }
     rows, err := reader.ReadAll()
     if err != nil {
         return nil, fmt.Errorf("failed to parse csv: %w", err)
@@ -26,11 +50,19 @@ func csvOverview(csvText string) (map[string]any, error) {
 
 func exposeCSV(this js.Value, args []js.Value) any {
     if len(args) < 1 {
-        return map[string]any{"error": "expected a CSV string"}
+        return errorResult(codeBadArgument, "expected a CSV string")
+    }
+    var delimiter rune
+    if len(args) > 1 && !args[1].IsUndefined() && !args[1].IsNull() && args[1].String() != "" {
+        text := args[1].String()
+        if utf8.RuneCountInString(text) != 1 {
+            return errorResult(codeBadArgument, "delimiter must be a single character")
+        }
+        delimiter, _ = utf8.DecodeRuneInString(text)
     }
-    result, err := csvOverview(args[0].String())
+    result, err := csvOverview(args[0].String(), delimiter)
     if err != nil {
-        return map[string]any{"error": err.Error()}
+        return errorResult(codeParseError, err.Error())
     }
     return result
 }
@@ -42,8 +74,95 @@ func exposeUpper(this js.Value, args []js.Value) any {
     return strings.ToUpper(args[0].String())
 }
 
+// exposeSHA256 mirrors wasmSHA256. crypto/sha256 is pure Go and on TinyGo's list of
+// supported packages, so no fallback is needed.
+func exposeSHA256(this js.Value, args []js.Value) any {
+    if len(args) < 1 {
+        return errorResult(codeBadArgument, "expected a string or Uint8Array")
+    }
+    var data []byte
+    if args[0].Type() == js.TypeString {
+        data = []byte(args[0].String())
+    } else if args[0].InstanceOf(js.Global().Get("Uint8Array")) {
+        data = make([]byte, args[0].Length())
+        js.CopyBytesToGo(data, args[0])
+    } else {
+        return errorResult(codeBadArgument, "expected a string or Uint8Array")
+    }
+    sum := sha256.Sum256(data)
+    return hex.EncodeToString(sum[:])
+}
+
+// exposeGzipCSV stands in for wasmGzipCSVSummary: TinyGo's compress/gzip support is
+// too limited to rely on, so JS gets a clear error instead of a missing function.
+func exposeGzipCSV(this js.Value, args []js.Value) any {
+    return errorResult(codeUnsupported, "gzip unsupported in tinygo build")
+}
+
+// buildTime is stamped by build_tinygo.sh via -ldflags "-X main.buildTime=...".
+var buildTime = "unknown"
+
+// exposeVersion mirrors wasmVersion through the same buildinfo.Version, so the compiler
+// field follows the tinygo build tag rather than a hard-coded copy.
+func exposeVersion(this js.Value, args []js.Value) any {
+    return buildinfo.Version(buildTime)
+}
+
+// exposeCapabilities mirrors wasmCapabilities with the same build-tagged flags. This
+// sample has no worker pool, so parallel is always false.
+func exposeCapabilities(this js.Value, args []js.Value) any {
+    return buildinfo.Capabilities(false)
+}
+
+// exposeMemStats mirrors wasmMemStats with the subset of runtime.MemStats TinyGo fills in.
+func exposeMemStats(this js.Value, args []js.Value) any {
+    var m runtime.MemStats
+    runtime.ReadMemStats(&m)
+    return map[string]any{
+        "alloc":       m.Alloc,
+        "totalAlloc":  m.TotalAlloc,
+        "heapInuse":   m.HeapInuse,
+        "unsupported": []any{"numGC"},
+    }
+}
+
+// funcs holds every exported callback so shutdown can release them; done keeps main alive.
+var (
+    funcs = map[string]js.Func{}
+    done  = make(chan struct{})
+)
+
+// expose registers fn on globalThis under name.
+func expose(name string, fn func(this js.Value, args []js.Value) any) {
+    funcs[name] = js.FuncOf(fn)
+    js.Global().Set(name, funcs[name])
+}
+
+// exposeShutdown swaps each export for a pure-JS "runtime stopped" stub and releases it
+// before closing done, mirroring wasmShutdown in the native build.
+func exposeShutdown(this js.Value, args []js.Value) any {
+    select {
+    case <-done:
+        return nil
+    default:
+    }
+    stopped := js.Global().Get("Function").New(`return {"error": "runtime stopped", "code": "stopped", "message": "runtime stopped"};`)
+    for name, f := range funcs {
+        js.Global().Set(name, stopped)
+        f.Release()
+    }
+    close(done)
+    return nil
+}
+
 func main() {
-    js.Global().Set("tinygoCSVOverview", js.FuncOf(exposeCSV))
-    js.Global().Set("tinygoUpper", js.FuncOf(exposeUpper))
-    select {} // keep running
+    expose("tinygoCSVOverview", exposeCSV)
+    expose("tinygoUpper", exposeUpper)
+    expose("tinygoGzipCSVSummary", exposeGzipCSV)
+    expose("tinygoSHA256", exposeSHA256)
+    expose("tinygoShutdown", exposeShutdown)
+    expose("tinygoVersion", exposeVersion)
+    expose("tinygoCapabilities", exposeCapabilities)
+    expose("tinygoMemStats", exposeMemStats)
+    <-done // keep running until tinygoShutdown
 }
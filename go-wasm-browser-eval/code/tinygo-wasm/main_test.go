@@ -0,0 +1,76 @@
+//go:build js && wasm
+
+package main
+
+import (
+    "syscall/js"
+    "testing"
+)
+
+func TestCSVOverviewDelimiters(t *testing.T) {
+    for _, delimiter := range []rune{0, '\t', ';', '|'} {
+        sep := string(delimiter)
+        if delimiter == 0 {
+            sep = ","
+        }
+        overview, err := csvOverview("a"+sep+"b"+sep+"c\n1"+sep+"2"+sep+"3\n", delimiter)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if overview["rows"] != 2 || overview["columns"] != 3 {
+            t.Errorf("%q: rows, columns = %v, %v; want 2, 3", sep, overview["rows"], overview["columns"])
+        }
+    }
+}
+
+func TestCSVOverviewStripsBOM(t *testing.T) {
+    overview, err := csvOverview("\ufeff\"Name\",n\nx,1\n", 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if overview["rows"] != 2 || overview["columns"] != 2 {
+        t.Errorf("rows, columns = %v, %v; want 2, 2", overview["rows"], overview["columns"])
+    }
+}
+
+func TestGzipStub(t *testing.T) {
+    result := exposeGzipCSV(js.Undefined(), nil).(map[string]any)
+    if result["error"] != "gzip unsupported in tinygo build" || result["code"] != codeUnsupported {
+        t.Errorf("got %v; want the unsupported stub", result)
+    }
+}
+
+func TestExposeSHA256(t *testing.T) {
+    const abcDigest = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+    if got := exposeSHA256(js.Undefined(), []js.Value{js.ValueOf("abc")}); got != abcDigest {
+        t.Errorf("sha256(abc) = %v; want %s", got, abcDigest)
+    }
+    data := js.Global().Get("Uint8Array").New(0)
+    if got := exposeSHA256(js.Undefined(), []js.Value{data}); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+        t.Errorf("sha256 of an empty Uint8Array = %v", got)
+    }
+}
+
+func TestExposeMemStats(t *testing.T) {
+    stats := exposeMemStats(js.Undefined(), nil).(map[string]any)
+    if stats["alloc"].(uint64) == 0 {
+        t.Error("alloc is zero")
+    }
+    if missing, ok := stats["unsupported"].([]any); !ok || len(missing) != 1 || missing[0] != "numGC" {
+        t.Errorf("unsupported = %v; want [numGC]", stats["unsupported"])
+    }
+}
+
+func TestTinygoShutdown(t *testing.T) {
+    expose("testUpper", exposeUpper)
+    t.Cleanup(func() { js.Global().Delete("testUpper") })
+    if got := js.Global().Call("testUpper", "abc").String(); got != "ABC" {
+        t.Fatalf("before shutdown: got %q; want ABC", got)
+    }
+    exposeShutdown(js.Undefined(), nil)
+    result := js.Global().Call("testUpper", "abc")
+    if result.Get("error").String() != "runtime stopped" || result.Get("code").String() != "stopped" {
+        t.Errorf("after shutdown: got error %q code %q", result.Get("error").String(), result.Get("code").String())
+    }
+    exposeShutdown(js.Undefined(), nil)
+}
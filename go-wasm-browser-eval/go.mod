@@ -1,3 +1,8 @@
 module go-wasm-browser-eval
 
 go 1.24.3
+
+require (
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/text v0.32.0
+)